@@ -0,0 +1,156 @@
+package address
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/mputil"
+)
+
+// Address is a structured address from the addr:* tags of an element
+// or interpolated along an addr:interpolation way.
+type Address struct {
+	// ID is the element the address is from, the
+	// interpolation way for interpolated addresses.
+	ID          osm.FeatureID
+	HouseNumber string
+	// Street is the addr:street, Place the addr:place for addresses
+	// not on a street, e.g. in small villages or on islands.
+	Street   string
+	Place    string
+	Unit     string
+	Postcode string
+	City     string
+	Suburb   string
+	District string
+	Province string
+	State    string
+	Country  string
+
+	// Point is the location of the node, a point inside the
+	// building or on the interpolation line.
+	Point        geo.Point
+	Interpolated bool
+}
+
+// Range returns the numbers of a house number range, e.g. 10-14.
+// Ranges are not expanded since the house numbers of a
+// building with a range are not always consecutive.
+func (a Address) Range() (int, int, bool) {
+	from, to, ok := strings.Cut(a.HouseNumber, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	f, err1 := strconv.Atoi(strings.TrimSpace(from))
+	t, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil || t < f {
+		return 0, 0, false
+	}
+
+	return f, t, true
+}
+
+// FromTags returns the addresses of the tags, one for every house number
+// of addr:housenumber lists, e.g. "1;3" or "1,3", and every unit of
+// addr:unit lists. Returns nil if there is no house number.
+func FromTags(tags osm.Tags) []Address {
+	a := fields(tags)
+	numbers := HouseNumbers(tags.Find("addr:housenumber"))
+	if len(numbers) == 0 {
+		return nil
+	}
+
+	units := split(tags.Find("addr:unit"))
+	if len(units) == 0 {
+		units = []string{""}
+	}
+
+	result := make([]Address, 0, len(numbers)*len(units))
+	for _, n := range numbers {
+		for _, u := range units {
+			a.HouseNumber, a.Unit = n, u
+			result = append(result, a)
+		}
+	}
+
+	return result
+}
+
+// HouseNumbers splits an addr:housenumber list on semicolons and commas.
+func HouseNumbers(value string) []string {
+	return split(value)
+}
+
+// split splits a list of values on semicolons and commas.
+func split(value string) []string {
+	var result []string
+	for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// FromNode returns the addresses of the node at its location.
+func FromNode(n *osm.Node) []Address {
+	return locate(FromTags(n.Tags), n.FeatureID(), n.Point())
+}
+
+// FromWay returns the addresses of the way, e.g. a building, at a point
+// inside of it or, for open ways, on it. The way must be annotated
+// with the node locations.
+func FromWay(w *osm.Way) []Address {
+	result := FromTags(w.Tags)
+	if len(result) == 0 {
+		return nil
+	}
+
+	return locate(result, w.FeatureID(), w.PointOnSurface())
+}
+
+// FromRelation returns the addresses of a multipolygon or boundary relation
+// at a point inside of it, or of a type=building relation at a point inside
+// its outline. Other relations, e.g. type=site, have no location
+// and return no addresses. The ways must be annotated with the node
+// locations. The errors are those of mputil.MultiPolygon.
+func FromRelation(r *osm.Relation, ways map[osm.WayID]*osm.Way) ([]Address, error) {
+	result := FromTags(r.Tags)
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	if !r.Polygon() && r.Tags.Find("type") != "building" {
+		return nil, nil
+	}
+
+	if r.Tags.Find("type") == "building" {
+		for _, m := range r.Members {
+			if m.Type == osm.TypeWay && m.Role == "outline" && ways[osm.WayID(m.Ref)] != nil {
+				return locate(result, r.FeatureID(), ways[osm.WayID(m.Ref)].PointOnSurface()), nil
+			}
+		}
+
+		return nil, nil
+	}
+
+	p, err := mputil.PointOnSurface(r, ways)
+	if err != nil {
+		return nil, err
+	}
+
+	return locate(result, r.FeatureID(), p), nil
+}
+
+func locate(addresses []Address, id osm.FeatureID, p geo.Point) []Address {
+	for i := range addresses {
+		addresses[i].ID = id
+		addresses[i].Point = p
+	}
+
+	return addresses
+}
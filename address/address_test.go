@@ -0,0 +1,234 @@
+package address
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/mputil"
+)
+
+func TestFromTags(t *testing.T) {
+	tags := osm.Tags{
+		{Key: "addr:housenumber", Value: "1;3, 5"},
+		{Key: "addr:street", Value: "Main Street"},
+		{Key: "addr:unit", Value: "B"},
+		{Key: "addr:postcode", Value: "12345"},
+		{Key: "addr:city", Value: "Springfield"},
+	}
+
+	addresses := FromTags(tags)
+	if len(addresses) != 3 {
+		t.Fatalf("incorrect number of addresses: %v", addresses)
+	}
+
+	for i, n := range []string{"1", "3", "5"} {
+		expected := Address{
+			HouseNumber: n,
+			Street:      "Main Street",
+			Unit:        "B",
+			Postcode:    "12345",
+			City:        "Springfield",
+		}
+
+		if addresses[i] != expected {
+			t.Errorf("incorrect address: %+v", addresses[i])
+		}
+	}
+
+	addresses = FromTags(osm.Tags{{Key: "addr:housenumber", Value: "1;3"}, {Key: "addr:unit", Value: "A; B"}})
+	var numbers []string
+	for _, a := range addresses {
+		numbers = append(numbers, a.HouseNumber+a.Unit)
+	}
+
+	if !reflect.DeepEqual(numbers, []string{"1A", "1B", "3A", "3B"}) {
+		t.Errorf("incorrect unit addresses: %v", numbers)
+	}
+
+	addresses = FromTags(osm.Tags{{Key: "addr:housenumber", Value: "7"}, {Key: "addr:place", Value: "Island"}})
+	if len(addresses) != 1 || addresses[0].Place != "Island" || addresses[0].Street != "" {
+		t.Errorf("incorrect place address: %v", addresses)
+	}
+
+	if addresses := FromTags(osm.Tags{{Key: "addr:street", Value: "Main Street"}}); addresses != nil {
+		t.Errorf("expected no address without house number: %v", addresses)
+	}
+}
+
+func TestAddress_Range(t *testing.T) {
+	cases := []struct {
+		number   string
+		from, to int
+		ok       bool
+	}{
+		{number: "10-14", from: 10, to: 14, ok: true},
+		{number: "10 - 14", from: 10, to: 14, ok: true},
+		{number: "10"},
+		{number: "14-10"},
+		{number: "10a-14"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.number, func(t *testing.T) {
+			from, to, ok := Address{HouseNumber: tc.number}.Range()
+			if from != tc.from || to != tc.to || ok != tc.ok {
+				t.Errorf("incorrect range: %v %v %v", from, to, ok)
+			}
+		})
+	}
+}
+
+func TestFromNode(t *testing.T) {
+	n := &osm.Node{ID: 1, Lon: 1, Lat: 2, Tags: osm.Tags{{Key: "addr:housenumber", Value: "1"}}}
+	addresses := FromNode(n)
+	if len(addresses) != 1 || addresses[0].ID != n.FeatureID() || addresses[0].Point != (geo.Point{1, 2}) {
+		t.Errorf("incorrect addresses: %v", addresses)
+	}
+
+	if addresses := FromNode(&osm.Node{ID: 2}); addresses != nil {
+		t.Errorf("expected no addresses: %v", addresses)
+	}
+}
+
+func square(id osm.WayID, tags osm.Tags) *osm.Way {
+	return &osm.Way{
+		ID:   id,
+		Tags: tags,
+		Nodes: osm.WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+			{ID: 2, Version: 1, Lon: 2, Lat: 0},
+			{ID: 3, Version: 1, Lon: 2, Lat: 2},
+			{ID: 4, Version: 1, Lon: 0, Lat: 2},
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+		},
+	}
+}
+
+func TestFromWay(t *testing.T) {
+	w := square(1, osm.Tags{{Key: "building", Value: "yes"}, {Key: "addr:housenumber", Value: "1"}})
+	addresses := FromWay(w)
+	if len(addresses) != 1 || addresses[0].ID != w.FeatureID() {
+		t.Fatalf("incorrect addresses: %v", addresses)
+	}
+
+	if !(geo.Bound{Min: geo.Point{0, 0}, Max: geo.Point{2, 2}}).Contains(addresses[0].Point) {
+		t.Errorf("point not inside the building: %v", addresses[0].Point)
+	}
+}
+
+func TestFromRelation(t *testing.T) {
+	ways := map[osm.WayID]*osm.Way{1: square(1, nil)}
+	tags := osm.Tags{{Key: "addr:housenumber", Value: "1"}, {Key: "addr:street", Value: "Main Street"}}
+
+	r := &osm.Relation{
+		ID:      1,
+		Tags:    append(osm.Tags{{Key: "type", Value: "multipolygon"}}, tags...),
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 1, Role: "outer"}},
+	}
+
+	addresses, err := FromRelation(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(addresses) != 1 || addresses[0].ID != r.FeatureID() || addresses[0].Point == (geo.Point{}) {
+		t.Errorf("incorrect addresses: %v", addresses)
+	}
+
+	r = &osm.Relation{
+		ID:      2,
+		Tags:    append(osm.Tags{{Key: "type", Value: "building"}}, tags...),
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 1, Role: "outline"}},
+	}
+
+	addresses, err = FromRelation(r, ways)
+	if err != nil || len(addresses) != 1 || addresses[0].Point == (geo.Point{}) {
+		t.Errorf("incorrect building addresses: %v %v", addresses, err)
+	}
+
+	// a site has no location
+	r = &osm.Relation{
+		ID:      3,
+		Tags:    append(osm.Tags{{Key: "type", Value: "site"}}, tags...),
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 1}},
+	}
+
+	addresses, err = FromRelation(r, ways)
+	if err != nil || addresses != nil {
+		t.Errorf("incorrect site addresses: %v %v", addresses, err)
+	}
+
+	r = &osm.Relation{
+		ID:      4,
+		Tags:    append(osm.Tags{{Key: "type", Value: "boundary"}}, tags...),
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 2, Role: "outer"}},
+	}
+
+	var missing *mputil.MissingWayError
+	if _, err := FromRelation(r, ways); !errors.As(err, &missing) || missing.WayID != 2 {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	o := &osm.OSM{
+		Nodes: osm.Nodes{
+			{ID: 1, Lon: 0, Lat: 0, Tags: osm.Tags{{Key: "addr:housenumber", Value: "1"}, {Key: "addr:street", Value: "Main Street"}}},
+			{ID: 2, Lon: 0.001, Lat: 0, Tags: osm.Tags{{Key: "addr:housenumber", Value: "9"}, {Key: "addr:street", Value: "Main Street"}}},
+			{ID: 3, Lon: 1, Lat: 1, Tags: osm.Tags{{Key: "addr:housenumber", Value: "2"}}},
+			{ID: 4, Lon: 1, Lat: 1.001},
+		},
+		Ways: osm.Ways{
+			{
+				ID:    10,
+				Tags:  osm.Tags{{Key: "addr:interpolation", Value: "odd"}},
+				Nodes: osm.WayNodes{{ID: 1}, {ID: 2}},
+			},
+			{
+				ID:    11,
+				Tags:  osm.Tags{{Key: "highway", Value: "residential"}, {Key: "name", Value: "Side Street"}},
+				Nodes: osm.WayNodes{{ID: 3}, {ID: 4}},
+			},
+			{
+				ID:    12,
+				Tags:  osm.Tags{{Key: "addr:interpolation", Value: "even"}},
+				Nodes: osm.WayNodes{{ID: 3}, {ID: 4}},
+			},
+		},
+		Relations: osm.Relations{
+			{
+				ID:   20,
+				Tags: osm.Tags{{Key: "type", Value: "associatedStreet"}},
+				Members: osm.Members{
+					{Type: osm.TypeWay, Ref: 11, Role: "street"},
+					{Type: osm.TypeNode, Ref: 3, Role: "house"},
+				},
+			},
+		},
+	}
+
+	addresses, errs := Extract(o)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrMissingEndpoints) {
+		t.Errorf("incorrect errors: %v", errs)
+	}
+
+	var numbers []string
+	for _, a := range addresses {
+		numbers = append(numbers, a.HouseNumber)
+	}
+
+	if !reflect.DeepEqual(numbers, []string{"1", "9", "2", "3", "5", "7"}) {
+		t.Errorf("incorrect house numbers: %v", numbers)
+	}
+
+	if addresses[2].Street != "Side Street" {
+		t.Errorf("street should be from the associated street: %v", addresses[2])
+	}
+
+	if !addresses[3].Interpolated || addresses[3].Street != "Main Street" {
+		t.Errorf("incorrect interpolated address: %v", addresses[3])
+	}
+}
@@ -0,0 +1,116 @@
+package address
+
+import (
+	"github.com/pchchv/osm"
+)
+
+// Extract returns the addresses of all the elements, including the
+// interpolated addresses. The ways are annotated with the node locations
+// if needed. House number only addresses that are members of an
+// associatedStreet relation get the street from the relation name.
+// The errors are for the relations and interpolation ways the
+// addresses can not be extracted from.
+func Extract(o *osm.OSM) ([]Address, []error) {
+	nodes := make(map[osm.NodeID]*osm.Node, len(o.Nodes))
+	for _, n := range o.Nodes {
+		nodes[n.ID] = n
+	}
+
+	ways := make(map[osm.WayID]*osm.Way, len(o.Ways))
+	for _, w := range o.Ways {
+		ways[w.ID] = annotate(w, nodes)
+	}
+
+	streets := associatedStreets(o.Relations, ways)
+	var result []Address
+	var errs []error
+	add := func(id osm.FeatureID, addresses []Address) {
+		for _, a := range addresses {
+			if a.Street == "" && a.Place == "" {
+				a.Street = streets[id]
+			}
+
+			result = append(result, a)
+		}
+	}
+
+	for _, n := range o.Nodes {
+		add(n.FeatureID(), FromNode(n))
+	}
+
+	for _, w := range o.Ways {
+		w = ways[w.ID]
+		if w.Tags.Find("addr:interpolation") != "" {
+			addresses, err := Interpolate(w, nodes)
+			if err != nil {
+				errs = append(errs, err)
+			}
+
+			result = append(result, addresses...)
+			continue
+		}
+
+		add(w.FeatureID(), FromWay(w))
+	}
+
+	for _, r := range o.Relations {
+		addresses, err := FromRelation(r, ways)
+		if err != nil {
+			errs = append(errs, &Error{ID: r.FeatureID(), Err: err})
+			continue
+		}
+
+		add(r.FeatureID(), addresses)
+	}
+
+	return result, errs
+}
+
+// associatedStreets returns the street names of the house members
+// of the associatedStreet relations.
+func associatedStreets(relations osm.Relations, ways map[osm.WayID]*osm.Way) map[osm.FeatureID]string {
+	result := make(map[osm.FeatureID]string)
+	for _, r := range relations {
+		if r.Tags.Find("type") != "associatedStreet" {
+			continue
+		}
+
+		name := r.Tags.Find("name")
+		for _, m := range r.Members {
+			if name == "" && m.Role == "street" && m.Type == osm.TypeWay && ways[osm.WayID(m.Ref)] != nil {
+				name = ways[osm.WayID(m.Ref)].Tags.Find("name")
+			}
+		}
+
+		if name == "" {
+			continue
+		}
+
+		for _, m := range r.Members {
+			if m.Role == "house" {
+				result[m.FeatureID()] = name
+			}
+		}
+	}
+
+	return result
+}
+
+// annotate returns a copy of the way with the node locations
+// if the way nodes are not annotated, missing nodes are skipped.
+func annotate(w *osm.Way, nodes map[osm.NodeID]*osm.Node) *osm.Way {
+	if len(w.Nodes) == 0 || w.Nodes[0].Version != 0 || w.Nodes[0].Lat != 0 || w.Nodes[0].Lon != 0 {
+		return w
+	}
+
+	result := *w
+	result.Nodes = make(osm.WayNodes, 0, len(w.Nodes))
+	for _, wn := range w.Nodes {
+		if n := nodes[wn.ID]; n != nil {
+			wn.Version, wn.Lat, wn.Lon = n.Version, n.Lat, n.Lon
+			result.Nodes = append(result.Nodes, wn)
+		}
+	}
+
+	return &result
+}
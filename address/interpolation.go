@@ -0,0 +1,271 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+// maxInterpolated limits the addresses between two house numbers
+// to prevent huge results for mistyped numbers.
+const maxInterpolated = 1000
+
+var (
+	// ErrNotInterpolation is returned if the way has no addr:interpolation tag.
+	ErrNotInterpolation = errors.New("address: way is not an interpolation")
+	// ErrUnknownInterpolation is returned for addr:interpolation values other than
+	// odd, even, all, alphabetic or a positive step.
+	ErrUnknownInterpolation = errors.New("address: unknown interpolation")
+	// ErrMissingNode is returned if a node of the interpolation way is not
+	// found and the way is not annotated with the node locations.
+	ErrMissingNode = errors.New("address: interpolation node not found")
+	// ErrMissingEndpoints is returned if fewer than two
+	// nodes of the interpolation way have a house number.
+	ErrMissingEndpoints = errors.New("address: interpolation needs two numbered nodes")
+	// ErrInvalidHouseNumber is returned if the house numbers of the nodes
+	// can not be interpolated, e.g. they are not numbers or too far apart.
+	ErrInvalidHouseNumber = errors.New("address: invalid house numbers for interpolation")
+
+	_ error = &Error{}
+)
+
+// Error is returned for elements the addresses can not be extracted from.
+type Error struct {
+	ID  osm.FeatureID
+	Err error
+}
+
+// Error returns a pretty string of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Interpolate returns the addresses between the numbered nodes of an
+// addr:interpolation way, evenly spaced along the way. The numbered nodes,
+// usually the end nodes, are not included, they are addresses themselves.
+// The street and other fields are from the first numbered node of each
+// section, else the way. The node tags are taken from the map, the locations
+// from the map or the annotated way nodes.
+// An *Error is returned wrapping an error of this package.
+func Interpolate(w *osm.Way, nodes map[osm.NodeID]*osm.Node) ([]Address, error) {
+	kind := w.Tags.Find("addr:interpolation")
+	if kind == "" {
+		return nil, &Error{ID: w.FeatureID(), Err: ErrNotInterpolation}
+	}
+
+	step := 0
+	switch kind {
+	case "odd", "even", "alphabetic":
+	case "all":
+		step = 1
+	default:
+		s, err := strconv.Atoi(kind)
+		if err != nil || s <= 0 {
+			return nil, &Error{ID: w.FeatureID(), Err: ErrUnknownInterpolation}
+		}
+		step = s
+	}
+
+	line := make(geo.LineString, len(w.Nodes))
+	var numbered []int
+	for i, wn := range w.Nodes {
+		n := nodes[wn.ID]
+		switch {
+		case n != nil:
+			line[i] = n.Point()
+			if n.Tags.Find("addr:housenumber") != "" {
+				numbered = append(numbered, i)
+			}
+		case wn.Version != 0 || wn.Lat != 0 || wn.Lon != 0:
+			line[i] = wn.Point()
+		default:
+			return nil, &Error{ID: w.FeatureID(), Err: ErrMissingNode}
+		}
+	}
+
+	if len(numbered) < 2 {
+		return nil, &Error{ID: w.FeatureID(), Err: ErrMissingEndpoints}
+	}
+
+	var result []Address
+	for k := 1; k < len(numbered); k++ {
+		i, j := numbered[k-1], numbered[k]
+		first, last := nodes[w.Nodes[i].ID], nodes[w.Nodes[j].ID]
+
+		var numbers []string
+		var err error
+		if kind == "alphabetic" {
+			numbers, err = letters(first.Tags.Find("addr:housenumber"), last.Tags.Find("addr:housenumber"))
+		} else {
+			numbers, err = between(first.Tags.Find("addr:housenumber"), last.Tags.Find("addr:housenumber"), kind, step)
+		}
+
+		if err != nil {
+			return nil, &Error{ID: w.FeatureID(), Err: err}
+		}
+
+		template := fields(first.Tags, w.Tags, last.Tags)
+		section := line[i : j+1]
+		length := measure.Length(section)
+		for x, number := range numbers {
+			a := template
+			a.ID = w.FeatureID()
+			a.HouseNumber = number
+			a.Interpolated = true
+			a.Point = along(section, length*float64(x+1)/float64(len(numbers)+1))
+			result = append(result, a)
+		}
+	}
+
+	return result, nil
+}
+
+// between returns the numbers strictly between the house numbers
+// matching the kind, odd or even, or the step from the first number.
+func between(from, to, kind string, step int) ([]string, error) {
+	a, err1 := strconv.Atoi(strings.TrimSpace(from))
+	b, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil || abs(b-a) > maxInterpolated {
+		return nil, ErrInvalidHouseNumber
+	}
+
+	dir := 1
+	if b < a {
+		dir = -1
+	}
+
+	var result []string
+	for v := a + dir; v != b && a != b; v += dir {
+		switch {
+		case kind == "odd" && abs(v)%2 == 1,
+			kind == "even" && v%2 == 0,
+			step > 0 && abs(v-a)%step == 0:
+			result = append(result, strconv.Itoa(v))
+		}
+	}
+
+	return result, nil
+}
+
+// letters returns the house numbers with the letters strictly between,
+// e.g. 10b and 10c for 10a and 10d. A number without a letter is before a.
+func letters(from, to string) ([]string, error) {
+	number, start := splitLetter(strings.TrimSpace(from))
+	other, end := splitLetter(strings.TrimSpace(to))
+	if number == "" || number != other {
+		return nil, ErrInvalidHouseNumber
+	}
+
+	if start == 0 {
+		start = 'a' - 1
+	}
+
+	if end == 0 {
+		end = 'a' - 1
+	}
+
+	s, e := int(start), int(end)
+	dir := 1
+	if e < s {
+		dir = -1
+	}
+
+	var result []string
+	for c := s + dir; c != e && s != e; c += dir {
+		result = append(result, number+string(rune(c)))
+	}
+
+	return result, nil
+}
+
+// splitLetter splits a house number like 10a into the number and lower
+// case letter, the letter is 0 if there is none.
+func splitLetter(value string) (string, byte) {
+	if value == "" {
+		return "", 0
+	}
+
+	last := value[len(value)-1]
+	if last >= 'A' && last <= 'Z' {
+		last += 'a' - 'A'
+	}
+
+	if last >= 'a' && last <= 'z' {
+		value = strings.TrimSpace(value[:len(value)-1])
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", 0
+		}
+
+		return value, last
+	}
+
+	if _, err := strconv.Atoi(value); err != nil {
+		return "", 0
+	}
+
+	return value, 0
+}
+
+// fields returns an address with the first value found
+// in the tags for every field, without the house number.
+func fields(tags ...osm.Tags) Address {
+	find := func(key string) string {
+		for _, t := range tags {
+			if v := t.Find(key); v != "" {
+				return v
+			}
+		}
+
+		return ""
+	}
+
+	return Address{
+		Street:   find("addr:street"),
+		Place:    find("addr:place"),
+		Postcode: find("addr:postcode"),
+		City:     find("addr:city"),
+		Suburb:   find("addr:suburb"),
+		District: find("addr:district"),
+		Province: find("addr:province"),
+		State:    find("addr:state"),
+		Country:  find("addr:country"),
+	}
+}
+
+// along returns the point at the distance in meters along the line,
+// measured on the WGS84 ellipsoid as the length of the line.
+// The point is interpolated linearly on the segment.
+func along(line geo.LineString, distance float64) geo.Point {
+	for i := 1; i < len(line); i++ {
+		d := measure.Distance(line[i-1], line[i])
+		if distance <= d && d > 0 {
+			f := distance / d
+			return geo.Point{
+				line[i-1][0] + f*(line[i][0]-line[i-1][0]),
+				line[i-1][1] + f*(line[i][1]-line[i-1][1]),
+			}
+		}
+
+		distance -= d
+	}
+
+	return line[len(line)-1]
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
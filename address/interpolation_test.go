@@ -0,0 +1,128 @@
+package address
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+func interpolationWay(kind string, numbers ...string) (*osm.Way, map[osm.NodeID]*osm.Node) {
+	w := &osm.Way{ID: 1, Tags: osm.Tags{{Key: "addr:interpolation", Value: kind}}}
+	nodes := make(map[osm.NodeID]*osm.Node)
+	for i, n := range numbers {
+		id := osm.NodeID(i + 1)
+		node := &osm.Node{ID: id, Lon: float64(i) * 0.001}
+		if n != "" {
+			node.Tags = osm.Tags{{Key: "addr:housenumber", Value: n}, {Key: "addr:street", Value: "Main Street"}}
+		}
+
+		nodes[id] = node
+		w.Nodes = append(w.Nodes, osm.WayNode{ID: id})
+	}
+
+	return w, nodes
+}
+
+func TestInterpolate(t *testing.T) {
+	cases := []struct {
+		name     string
+		kind     string
+		numbers  []string
+		expected []string
+	}{
+		{name: "odd", kind: "odd", numbers: []string{"1", "9"}, expected: []string{"3", "5", "7"}},
+		{name: "even", kind: "even", numbers: []string{"10", "2"}, expected: []string{"8", "6", "4"}},
+		{name: "all", kind: "all", numbers: []string{"1", "4"}, expected: []string{"2", "3"}},
+		{name: "step", kind: "3", numbers: []string{"1", "10"}, expected: []string{"4", "7"}},
+		{name: "alphabetic", kind: "alphabetic", numbers: []string{"10", "10d"}, expected: []string{"10a", "10b", "10c"}},
+		{name: "intermediate", kind: "even", numbers: []string{"2", "", "6", "10"}, expected: []string{"4", "8"}},
+		{name: "adjacent", kind: "odd", numbers: []string{"1", "3"}, expected: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, nodes := interpolationWay(tc.kind, tc.numbers...)
+			addresses, err := Interpolate(w, nodes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var numbers []string
+			for _, a := range addresses {
+				numbers = append(numbers, a.HouseNumber)
+				if !a.Interpolated || a.Street != "Main Street" || a.ID != w.FeatureID() {
+					t.Errorf("incorrect address: %+v", a)
+				}
+			}
+
+			if !reflect.DeepEqual(numbers, tc.expected) {
+				t.Errorf("incorrect numbers: %v", numbers)
+			}
+		})
+	}
+}
+
+func TestInterpolate_points(t *testing.T) {
+	w, nodes := interpolationWay("odd", "1", "", "9")
+	addresses, err := Interpolate(w, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// evenly spaced along the 0.002 degree line
+	for i, a := range addresses {
+		expected := 0.0005 * float64(i+1)
+		if math.Abs(a.Point[0]-expected) > 1e-9 || a.Point[1] != 0 {
+			t.Errorf("incorrect point %d: %v", i, a.Point)
+		}
+	}
+}
+
+func TestAlong(t *testing.T) {
+	// the distance is measured as the length of the line
+	line := geo.LineString{{0, 0}, {0, 1}, {1, 1}}
+	if p := along(line, measure.Length(line[:2])); math.Abs(p[0]) > 1e-9 || math.Abs(p[1]-1) > 1e-9 {
+		t.Errorf("incorrect point: %v", p)
+	}
+
+	if p := along(line, measure.Length(line)); p != line[2] {
+		t.Errorf("incorrect end point: %v", p)
+	}
+}
+
+func TestInterpolate_errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		kind    string
+		numbers []string
+		err     error
+	}{
+		{name: "not interpolation", kind: "", numbers: []string{"1", "3"}, err: ErrNotInterpolation},
+		{name: "unknown", kind: "sometimes", numbers: []string{"1", "3"}, err: ErrUnknownInterpolation},
+		{name: "one endpoint", kind: "odd", numbers: []string{"1", ""}, err: ErrMissingEndpoints},
+		{name: "not numbers", kind: "odd", numbers: []string{"1a", "9"}, err: ErrInvalidHouseNumber},
+		{name: "too far apart", kind: "all", numbers: []string{"1", "100000"}, err: ErrInvalidHouseNumber},
+		{name: "different alphabetic", kind: "alphabetic", numbers: []string{"1a", "2c"}, err: ErrInvalidHouseNumber},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, nodes := interpolationWay(tc.kind, tc.numbers...)
+			_, err := Interpolate(w, nodes)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("incorrect error: %v", err)
+			}
+		})
+	}
+
+	w, nodes := interpolationWay("odd", "1", "9")
+	delete(nodes, 2)
+	if _, err := Interpolate(w, nodes); !errors.Is(err, ErrMissingNode) {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
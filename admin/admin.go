@@ -0,0 +1,231 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/planar"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+	"github.com/pchchv/osm/internal/rtree"
+	"github.com/pchchv/osm/mputil"
+)
+
+var (
+	// ErrInvalidLevel is reported for boundaries without
+	// an admin_level between 1 and 12.
+	ErrInvalidLevel = errors.New("admin: invalid admin_level")
+
+	_ error = &Error{}
+)
+
+// Error is reported by New for boundaries that are skipped, Err is
+// ErrInvalidLevel or one of the errors of mputil.MultiPolygon,
+// e.g. an *mputil.OpenRingError for broken rings.
+type Error struct {
+	RelationID osm.RelationID
+	Err        error
+}
+
+// Error returns a pretty string of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin: relation %d: %v", e.RelationID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Area is an administrative area built from a boundary relation.
+type Area struct {
+	RelationID osm.RelationID
+	Level      int
+	Name       string
+	// ISO3166_1 is the country code, e.g. DE, ISO3166_2
+	// the subdivision code, e.g. DE-BY, if tagged.
+	ISO3166_1 string
+	ISO3166_2 string
+	Tags      osm.Tags
+
+	Geometry geo.MultiPolygon
+	Bound    geo.Bound
+	// Area is the area in square meters on the WGS84 ellipsoid,
+	// as computed by mputil.Area.
+	Area float64
+
+	// Parent is the smallest area with a lower level containing
+	// the area, nil for the top level areas, e.g. countries.
+	Parent   *Area
+	Children []*Area
+}
+
+// Index is the containment tree of the administrative
+// areas with a spatial index for point lookups.
+// It is safe for concurrent lookups.
+type Index struct {
+	areas []*Area
+	roots []*Area
+	tree  *rtree.Tree
+}
+
+// New builds the index from the boundary=administrative relations,
+// other relations are skipped. The ways are used to build the polygons,
+// see mputil.MultiPolygon. Boundaries with broken geometry or without
+// a valid admin_level are not included and reported as an *Error.
+func New(relations osm.Relations, ways map[osm.WayID]*osm.Way) (*Index, []error) {
+	var errs []error
+	idx := &Index{tree: rtree.New()}
+	for _, r := range relations {
+		if r.Tags.Find("boundary") != "administrative" || !r.Polygon() {
+			continue
+		}
+
+		level, err := strconv.Atoi(r.Tags.Find("admin_level"))
+		if err != nil || level < 1 || level > 12 {
+			errs = append(errs, &Error{RelationID: r.ID, Err: ErrInvalidLevel})
+			continue
+		}
+
+		mp, err := mputil.MultiPolygon(r, ways)
+		if err != nil {
+			errs = append(errs, &Error{RelationID: r.ID, Err: err})
+			continue
+		}
+
+		iso := r.Tags.Find("ISO3166-1")
+		if iso == "" {
+			iso = r.Tags.Find("ISO3166-1:alpha2")
+		}
+
+		idx.areas = append(idx.areas, &Area{
+			RelationID: r.ID,
+			Level:      level,
+			Name:       r.Tags.Find("name"),
+			ISO3166_1:  iso,
+			ISO3166_2:  r.Tags.Find("ISO3166-2"),
+			Tags:       r.Tags,
+			Geometry:   mp,
+			Bound:      mp.Bound(),
+			Area:       measure.Area(mp),
+		})
+	}
+
+	// the largest first so the parents are inserted before their children
+	sort.SliceStable(idx.areas, func(i, j int) bool {
+		return less(idx.areas[i], idx.areas[j])
+	})
+
+	for i, a := range idx.areas {
+		idx.link(a)
+		idx.tree.Insert(a.Bound, int64(i))
+	}
+
+	return idx, errs
+}
+
+// link finds the parent of the area in the areas already in the tree.
+func (idx *Index) link(a *Area) {
+	p := measure.PointOnSurface(a.Geometry)
+	for _, candidate := range idx.lookup(p) {
+		if candidate.Level < a.Level && contains(candidate.Bound, a.Bound) {
+			a.Parent = candidate
+		}
+	}
+
+	if a.Parent == nil {
+		idx.roots = append(idx.roots, a)
+		return
+	}
+
+	a.Parent.Children = append(a.Parent.Children, a)
+}
+
+// Areas returns all the areas by level and decreasing area.
+func (idx *Index) Areas() []*Area {
+	return idx.areas
+}
+
+// Roots returns the areas without a parent, usually the countries.
+func (idx *Index) Roots() []*Area {
+	return idx.roots
+}
+
+// Lookup returns the areas containing the point by level
+// from the lowest, e.g. the country first.
+func (idx *Index) Lookup(p geo.Point) []*Area {
+	return idx.lookup(p)
+}
+
+func (idx *Index) lookup(p geo.Point) []*Area {
+	var result []*Area
+	idx.tree.Search(geo.Bound{Min: p, Max: p}, func(v int64) bool {
+		a := idx.areas[v]
+		if planar.MultiPolygonContains(a.Geometry, p) {
+			result = append(result, a)
+		}
+
+		return true
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+
+	return result
+}
+
+// LookupLevel returns the area at the level containing the point,
+// nil if there is none. If there are overlapping areas
+// at the level the smallest is returned.
+func (idx *Index) LookupLevel(p geo.Point, level int) *Area {
+	var result *Area
+	for _, a := range idx.lookup(p) {
+		if a.Level == level {
+			result = a
+		}
+	}
+
+	return result
+}
+
+// LookupElement returns the areas containing the element. The location
+// is the node, a point on the surface of an annotated way or the first
+// annotated member of a relation. Returns nil if the location is not known.
+func (idx *Index) LookupElement(e osm.Element) []*Area {
+	switch e := e.(type) {
+	case *osm.Node:
+		return idx.lookup(e.Point())
+	case *osm.Way:
+		if len(e.Nodes) == 0 || len(e.LineString()) != len(e.Nodes) {
+			return nil
+		}
+
+		return idx.lookup(e.PointOnSurface())
+	case *osm.Relation:
+		for _, m := range e.Members {
+			if m.Lat != 0 || m.Lon != 0 {
+				return idx.lookup(m.Point())
+			}
+		}
+	}
+
+	return nil
+}
+
+// less sorts by level and then by decreasing area.
+func less(a, b *Area) bool {
+	if a.Level != b.Level {
+		return a.Level < b.Level
+	}
+
+	return a.Area > b.Area
+}
+
+func contains(outer, inner geo.Bound) bool {
+	return outer.Min[0] <= inner.Min[0] && outer.Min[1] <= inner.Min[1] &&
+		outer.Max[0] >= inner.Max[0] && outer.Max[1] >= inner.Max[1]
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/mputil"
+)
+
+// testData returns boundaries with the way of relation n having
+// ids n*10 and nodes n*100+i.
+func testData() (osm.Relations, map[osm.WayID]*osm.Way) {
+	ways := make(map[osm.WayID]*osm.Way)
+	boundary := func(id osm.RelationID, level string, tags osm.Tags, min, max geo.Point, closed bool) *osm.Relation {
+		wid := osm.WayID(id * 10)
+		nid := osm.NodeID(id * 100)
+		w := &osm.Way{ID: wid, Nodes: osm.WayNodes{
+			{ID: nid, Version: 1, Lon: min[0], Lat: min[1]},
+			{ID: nid + 1, Version: 1, Lon: max[0], Lat: min[1]},
+			{ID: nid + 2, Version: 1, Lon: max[0], Lat: max[1]},
+			{ID: nid + 3, Version: 1, Lon: min[0], Lat: max[1]},
+		}}
+		if closed {
+			w.Nodes = append(w.Nodes, w.Nodes[0])
+		}
+		ways[wid] = w
+
+		return &osm.Relation{
+			ID: id,
+			Tags: append(osm.Tags{
+				{Key: "type", Value: "boundary"},
+				{Key: "boundary", Value: "administrative"},
+				{Key: "admin_level", Value: level},
+			}, tags...),
+			Members: osm.Members{{Type: osm.TypeWay, Ref: int64(wid), Role: "outer"}},
+		}
+	}
+
+	relations := osm.Relations{
+		boundary(3, "8", osm.Tags{{Key: "name", Value: "City"}}, geo.Point{1, 1}, geo.Point{2, 2}, true),
+		boundary(1, "2", osm.Tags{{Key: "name", Value: "Country"}, {Key: "ISO3166-1", Value: "CC"}}, geo.Point{0, 0}, geo.Point{10, 10}, true),
+		boundary(2, "4", osm.Tags{{Key: "name", Value: "State"}, {Key: "ISO3166-2", Value: "CC-ST"}}, geo.Point{0, 0}, geo.Point{5, 5}, true),
+		boundary(4, "2", osm.Tags{{Key: "name", Value: "Other"}, {Key: "ISO3166-1:alpha2", Value: "OT"}}, geo.Point{20, 0}, geo.Point{30, 10}, true),
+		boundary(5, "6", nil, geo.Point{6, 6}, geo.Point{7, 7}, false),
+		boundary(6, "none", nil, geo.Point{6, 6}, geo.Point{7, 7}, true),
+		{ID: 7, Tags: osm.Tags{{Key: "type", Value: "boundary"}, {Key: "boundary", Value: "postal_code"}}},
+	}
+
+	return relations, ways
+}
+
+func TestNew(t *testing.T) {
+	idx, errs := New(testData())
+	if len(errs) != 2 {
+		t.Fatalf("incorrect errors: %v", errs)
+	}
+
+	var ore *mputil.OpenRingError
+	if !errors.As(errs[0], &ore) {
+		t.Errorf("expected open ring error: %v", errs[0])
+	}
+
+	var e *Error
+	if !errors.Is(errs[1], ErrInvalidLevel) || !errors.As(errs[1], &e) || e.RelationID != 6 {
+		t.Errorf("incorrect error: %v", errs[1])
+	}
+
+	if len(idx.Areas()) != 4 {
+		t.Fatalf("incorrect areas: %v", len(idx.Areas()))
+	}
+
+	roots := idx.Roots()
+	if len(roots) != 2 || roots[0].Name != "Country" || roots[1].Name != "Other" {
+		t.Fatalf("incorrect roots: %v", roots)
+	}
+
+	if roots[0].ISO3166_1 != "CC" || roots[1].ISO3166_1 != "OT" {
+		t.Errorf("incorrect iso codes")
+	}
+
+	state := roots[0].Children
+	if len(state) != 1 || state[0].Name != "State" || state[0].ISO3166_2 != "CC-ST" {
+		t.Fatalf("incorrect children: %v", state)
+	}
+
+	city := state[0].Children
+	if len(city) != 1 || city[0].Name != "City" || city[0].Parent != state[0] {
+		t.Errorf("incorrect city: %v", city)
+	}
+
+	if roots[0].Area <= state[0].Area || state[0].Area <= 0 {
+		t.Errorf("incorrect areas: %v %v", roots[0].Area, state[0].Area)
+	}
+
+	// the area is measured as by mputil
+	relations, ways := testData()
+	if a, err := mputil.Area(relations[1], ways); err != nil || math.Abs(roots[0].Area-a) > 1e-3 {
+		t.Errorf("incorrect area: %v != %v %v", roots[0].Area, a, err)
+	}
+}
+
+func TestIndex_Lookup(t *testing.T) {
+	idx, _ := New(testData())
+
+	cases := []struct {
+		name     string
+		point    geo.Point
+		expected []string
+	}{
+		{name: "city", point: geo.Point{1.5, 1.5}, expected: []string{"Country", "State", "City"}},
+		{name: "state", point: geo.Point{3, 3}, expected: []string{"Country", "State"}},
+		{name: "country", point: geo.Point{8, 8}, expected: []string{"Country"}},
+		{name: "other", point: geo.Point{25, 5}, expected: []string{"Other"}},
+		{name: "none", point: geo.Point{15, 5}, expected: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var names []string
+			for _, a := range idx.Lookup(tc.point) {
+				names = append(names, a.Name)
+			}
+
+			if len(names) != len(tc.expected) {
+				t.Fatalf("incorrect areas: %v", names)
+			}
+
+			for i := range names {
+				if names[i] != tc.expected[i] {
+					t.Errorf("incorrect areas: %v", names)
+				}
+			}
+		})
+	}
+
+	if a := idx.LookupLevel(geo.Point{1.5, 1.5}, 4); a == nil || a.Name != "State" {
+		t.Errorf("incorrect level 4 area: %v", a)
+	}
+
+	if a := idx.LookupLevel(geo.Point{8, 8}, 4); a != nil {
+		t.Errorf("expected no level 4 area: %v", a)
+	}
+}
+
+func TestIndex_LookupElement(t *testing.T) {
+	idx, _ := New(testData())
+
+	n := &osm.Node{ID: 1, Lon: 3, Lat: 3}
+	if areas := idx.LookupElement(n); len(areas) != 2 {
+		t.Errorf("incorrect node areas: %v", areas)
+	}
+
+	w := &osm.Way{ID: 1, Nodes: osm.WayNodes{
+		{ID: 1, Version: 1, Lon: 1.2, Lat: 1.2},
+		{ID: 2, Version: 1, Lon: 1.4, Lat: 1.4},
+	}}
+	if areas := idx.LookupElement(w); len(areas) != 3 {
+		t.Errorf("incorrect way areas: %v", areas)
+	}
+
+	r := &osm.Relation{ID: 1, Members: osm.Members{{Type: osm.TypeNode, Ref: 1, Lon: 25, Lat: 5}}}
+	if areas := idx.LookupElement(r); len(areas) != 1 || areas[0].Name != "Other" {
+		t.Errorf("incorrect relation areas: %v", areas)
+	}
+
+	if areas := idx.LookupElement(&osm.Way{ID: 2, Nodes: osm.WayNodes{{ID: 1}}}); areas != nil {
+		t.Errorf("expected no areas for unannotated way: %v", areas)
+	}
+}
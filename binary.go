@@ -0,0 +1,801 @@
+package osm
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/pchchv/geo"
+)
+
+// binaryVersion is the version of the binary layout,
+// it is the first byte of the encoded data.
+const binaryVersion = 1
+
+// Flags of the binary layout.
+const (
+	binaryVisible = 1 << iota
+	binaryCommitted
+	binaryBounds
+	binaryOpen
+	binaryDiscussion
+	binaryChange
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &Node{}
+	_ encoding.BinaryUnmarshaler = &Node{}
+	_ encoding.BinaryMarshaler   = &Way{}
+	_ encoding.BinaryUnmarshaler = &Way{}
+	_ encoding.BinaryMarshaler   = &Relation{}
+	_ encoding.BinaryUnmarshaler = &Relation{}
+	_ encoding.BinaryMarshaler   = &Changeset{}
+	_ encoding.BinaryUnmarshaler = &Changeset{}
+	_ encoding.BinaryMarshaler   = &Update{}
+	_ encoding.BinaryUnmarshaler = &Update{}
+
+	// ErrInvalidBinary is returned when unmarshalling data that was not
+	// created by MarshalBinary or has an unsupported version.
+	ErrInvalidBinary = errors.New("osm: invalid binary data")
+)
+
+// MarshalBinary encodes the node into a compact binary format.
+// Integers are varint encoded and coordinates are stored with 7 decimals,
+// like the osm database, if that is lossless.
+// All fields round trip, times are decoded in UTC.
+func (n *Node) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: make([]byte, 0, 64)}
+	e.buf = append(e.buf, binaryVersion)
+	e.flags(n.Visible, n.Committed != nil)
+	e.varint(int64(n.ID))
+	e.elementInfo(n.Version, n.ChangesetID, n.UserID, n.User, n.Timestamp, n.Committed)
+	e.tags(n.Tags)
+
+	fixed := fixedCoords(n.Lat, n.Lon)
+	e.bool(fixed)
+	var prev [2]int64
+	e.coords(fixed, &prev, n.Lat, n.Lon)
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary into the node.
+func (n *Node) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	d.version()
+	flags := d.byte()
+
+	*n = Node{
+		ID:      NodeID(d.varint()),
+		Visible: flags&binaryVisible != 0,
+	}
+	n.Version, n.ChangesetID, n.UserID, n.User, n.Timestamp, n.Committed = d.elementInfo(flags)
+	n.Tags = d.tags()
+
+	fixed := d.bool()
+	var prev [2]int64
+	n.Lat, n.Lon = d.coords(fixed, &prev)
+
+	return d.finish()
+}
+
+// MarshalBinary encodes the way into a compact binary format.
+// The way node ids and coordinates are delta encoded.
+// See Node.MarshalBinary for more details.
+func (w *Way) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: make([]byte, 0, 64+4*len(w.Nodes))}
+	e.buf = append(e.buf, binaryVersion)
+	e.flags(w.Visible, w.Committed != nil, w.Bounds != nil)
+	e.varint(int64(w.ID))
+	e.elementInfo(w.Version, w.ChangesetID, w.UserID, w.User, w.Timestamp, w.Committed)
+	e.tags(w.Tags)
+	e.wayNodes(w.Nodes)
+	e.updates(w.Updates)
+	e.bounds(w.Bounds)
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary into the way.
+func (w *Way) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	d.version()
+	flags := d.byte()
+
+	*w = Way{
+		ID:      WayID(d.varint()),
+		Visible: flags&binaryVisible != 0,
+	}
+	w.Version, w.ChangesetID, w.UserID, w.User, w.Timestamp, w.Committed = d.elementInfo(flags)
+	w.Tags = d.tags()
+	w.Nodes = d.wayNodes()
+	w.Updates = d.updates()
+	w.Bounds = d.bounds(flags)
+
+	return d.finish()
+}
+
+// MarshalBinary encodes the relation into a compact binary format.
+// The member refs and coordinates are delta encoded.
+// See Node.MarshalBinary for more details.
+func (r *Relation) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: make([]byte, 0, 64+8*len(r.Members))}
+	e.buf = append(e.buf, binaryVersion)
+	e.flags(r.Visible, r.Committed != nil, r.Bounds != nil)
+	e.varint(int64(r.ID))
+	e.elementInfo(r.Version, r.ChangesetID, r.UserID, r.User, r.Timestamp, r.Committed)
+	e.tags(r.Tags)
+	e.members(r.Members)
+	e.updates(r.Updates)
+	e.bounds(r.Bounds)
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary into the relation.
+func (r *Relation) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	d.version()
+	flags := d.byte()
+
+	*r = Relation{
+		ID:      RelationID(d.varint()),
+		Visible: flags&binaryVisible != 0,
+	}
+	r.Version, r.ChangesetID, r.UserID, r.User, r.Timestamp, r.Committed = d.elementInfo(flags)
+	r.Tags = d.tags()
+	r.Members = d.members()
+	r.Updates = d.updates()
+	r.Bounds = d.bounds(flags)
+
+	return d.finish()
+}
+
+// MarshalBinary encodes the update into a compact binary format.
+func (u *Update) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: make([]byte, 0, 32)}
+	e.buf = append(e.buf, binaryVersion)
+	e.updates(Updates{*u})
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary into the update.
+func (u *Update) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	d.version()
+	if us := d.updates(); len(us) == 1 {
+		*u = us[0]
+	} else if d.err == nil {
+		d.err = ErrInvalidBinary
+	}
+
+	return d.finish()
+}
+
+// MarshalBinary encodes the changeset into a compact binary format.
+// The Change, if present, is encoded with the nodes,
+// ways and relations of the create, modify and delete sections.
+func (c *Changeset) MarshalBinary() ([]byte, error) {
+	e := &binaryEncoder{buf: make([]byte, 0, 128)}
+	e.buf = append(e.buf, binaryVersion)
+	e.flags(false, false, false, c.Open, c.Discussion != nil, c.Change != nil)
+	e.varint(int64(c.ID))
+	e.string(c.User)
+	e.varint(int64(c.UserID))
+	e.time(c.CreatedAt)
+	e.time(c.ClosedAt)
+	e.varint(int64(c.ChangesCount))
+	e.varint(int64(c.CommentsCount))
+	e.float(c.MinLat)
+	e.float(c.MaxLat)
+	e.float(c.MinLon)
+	e.float(c.MaxLon)
+	e.tags(c.Tags)
+
+	if c.Discussion != nil {
+		e.uvarint(uint64(len(c.Discussion.Comments)))
+		for _, cc := range c.Discussion.Comments {
+			e.string(cc.User)
+			e.varint(int64(cc.UserID))
+			e.time(cc.Timestamp)
+			e.string(cc.Text)
+		}
+	}
+
+	if c.Change != nil {
+		if err := e.change(c.Change); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary into the changeset.
+func (c *Changeset) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	d.version()
+	flags := d.byte()
+
+	*c = Changeset{
+		ID:   ChangesetID(d.varint()),
+		Open: flags&binaryOpen != 0,
+	}
+	c.User = d.string()
+	c.UserID = UserID(d.varint())
+	c.CreatedAt = d.time()
+	c.ClosedAt = d.time()
+	c.ChangesCount = int(d.varint())
+	c.CommentsCount = int(d.varint())
+	c.MinLat = d.float()
+	c.MaxLat = d.float()
+	c.MinLon = d.float()
+	c.MaxLon = d.float()
+	c.Tags = d.tags()
+
+	if flags&binaryDiscussion != 0 {
+		c.Discussion = &ChangesetDiscussion{}
+		if l := d.length(4); l > 0 {
+			c.Discussion.Comments = make([]*ChangesetComment, l)
+			for i := range c.Discussion.Comments {
+				c.Discussion.Comments[i] = &ChangesetComment{
+					User:      d.string(),
+					UserID:    UserID(d.varint()),
+					Timestamp: d.time(),
+					Text:      d.string(),
+				}
+			}
+		}
+	}
+
+	if flags&binaryChange != 0 {
+		c.Change = d.change()
+	}
+
+	return d.finish()
+}
+
+type binaryEncoder struct {
+	buf []byte
+}
+
+func (e *binaryEncoder) flags(flags ...bool) {
+	var b byte
+	for i, f := range flags {
+		if f {
+			b |= 1 << i
+		}
+	}
+
+	e.buf = append(e.buf, b)
+}
+
+func (e *binaryEncoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *binaryEncoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *binaryEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *binaryEncoder) float(v float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// time encodes the time as seconds and nanoseconds since the unix epoch,
+// the zero time is a single byte.
+func (e *binaryEncoder) time(t time.Time) {
+	if t.IsZero() {
+		e.bool(false)
+		return
+	}
+
+	e.bool(true)
+	e.varint(t.Unix())
+	e.uvarint(uint64(t.Nanosecond()))
+}
+
+func (e *binaryEncoder) elementInfo(version int, cs ChangesetID, uid UserID, user string, ts time.Time, committed *time.Time) {
+	e.varint(int64(version))
+	e.varint(int64(cs))
+	e.varint(int64(uid))
+	e.string(user)
+	e.time(ts)
+	if committed != nil {
+		e.time(*committed)
+	}
+}
+
+func (e *binaryEncoder) tags(tags Tags) {
+	e.uvarint(uint64(len(tags)))
+	for _, t := range tags {
+		e.string(t.Key)
+		e.string(t.Value)
+	}
+}
+
+// coords encodes the lat/lon as the delta to the
+// previous coordinates if fixed, as raw floats otherwise.
+func (e *binaryEncoder) coords(fixed bool, prev *[2]int64, lat, lon float64) {
+	if !fixed {
+		e.float(lat)
+		e.float(lon)
+		return
+	}
+
+	v := [2]int64{toFixed(lat), toFixed(lon)}
+	e.varint(v[0] - prev[0])
+	e.varint(v[1] - prev[1])
+	*prev = v
+}
+
+func (e *binaryEncoder) wayNodes(nodes WayNodes) {
+	fixed := true
+	for _, wn := range nodes {
+		if !fixedCoords(wn.Lat, wn.Lon) {
+			fixed = false
+			break
+		}
+	}
+
+	e.uvarint(uint64(len(nodes)))
+	e.bool(fixed)
+
+	var prevID, prevCS int64
+	var prev [2]int64
+	for _, wn := range nodes {
+		e.varint(int64(wn.ID) - prevID)
+		e.varint(int64(wn.Version))
+		e.varint(int64(wn.ChangesetID) - prevCS)
+		e.coords(fixed, &prev, wn.Lat, wn.Lon)
+		prevID, prevCS = int64(wn.ID), int64(wn.ChangesetID)
+	}
+}
+
+func (e *binaryEncoder) members(members Members) {
+	fixed := true
+	for _, m := range members {
+		if !fixedCoords(m.Lat, m.Lon) {
+			fixed = false
+			break
+		}
+	}
+
+	e.uvarint(uint64(len(members)))
+	e.bool(fixed)
+
+	var prevRef, prevCS int64
+	var prev [2]int64
+	for _, m := range members {
+		e.string(string(m.Type))
+		e.varint(m.Ref - prevRef)
+		e.string(m.Role)
+		e.varint(int64(m.Version))
+		e.varint(int64(m.ChangesetID) - prevCS)
+		e.coords(fixed, &prev, m.Lat, m.Lon)
+		e.varint(int64(m.Orientation))
+		e.wayNodes(m.Nodes)
+		prevRef, prevCS = m.Ref, int64(m.ChangesetID)
+	}
+}
+
+func (e *binaryEncoder) updates(updates Updates) {
+	fixed := true
+	for _, u := range updates {
+		if !fixedCoords(u.Lat, u.Lon) {
+			fixed = false
+			break
+		}
+	}
+
+	e.uvarint(uint64(len(updates)))
+	e.bool(fixed)
+
+	var prev [2]int64
+	for _, u := range updates {
+		e.varint(int64(u.Index))
+		e.varint(int64(u.Version))
+		e.time(u.Timestamp)
+		e.varint(int64(u.ChangesetID))
+		e.coords(fixed, &prev, u.Lat, u.Lon)
+		e.bool(u.Reverse)
+	}
+}
+
+func (e *binaryEncoder) bounds(b *Bounds) {
+	if b == nil {
+		return
+	}
+
+	e.float(b.MinLat)
+	e.float(b.MaxLat)
+	e.float(b.MinLon)
+	e.float(b.MaxLon)
+}
+
+// change encodes the header and the nodes, ways and relations of the change.
+func (e *binaryEncoder) change(c *Change) error {
+	e.string(c.Version)
+	e.string(c.Generator)
+	e.string(c.Copyright)
+	e.string(c.Attribution)
+	e.string(c.License)
+
+	for _, o := range []*OSM{c.Create, c.Modify, c.Delete} {
+		e.bool(o != nil)
+		if o == nil {
+			continue
+		}
+
+		var elements []encoding.BinaryMarshaler
+		for _, n := range o.Nodes {
+			elements = append(elements, n)
+		}
+
+		for _, w := range o.Ways {
+			elements = append(elements, w)
+		}
+
+		for _, r := range o.Relations {
+			elements = append(elements, r)
+		}
+
+		e.uvarint(uint64(len(o.Nodes)))
+		e.uvarint(uint64(len(o.Ways)))
+		e.uvarint(uint64(len(o.Relations)))
+		for _, el := range elements {
+			data, err := el.MarshalBinary()
+			if err != nil {
+				return err
+			}
+
+			e.uvarint(uint64(len(data)))
+			e.buf = append(e.buf, data...)
+		}
+	}
+
+	return nil
+}
+
+// binaryDecoder reads the binary layout, the first error is kept
+// and all following reads return zero values.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) fail() {
+	if d.err == nil {
+		d.err = ErrInvalidBinary
+	}
+	d.data = nil
+}
+
+func (d *binaryDecoder) finish() error {
+	if d.err == nil && len(d.data) != 0 {
+		d.err = ErrInvalidBinary
+	}
+
+	return d.err
+}
+
+func (d *binaryDecoder) version() {
+	if d.byte() != binaryVersion {
+		d.fail()
+	}
+}
+
+func (d *binaryDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail()
+		return 0
+	}
+
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *binaryDecoder) bool() bool {
+	switch d.byte() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+
+	d.fail()
+	return false
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	v, l := binary.Uvarint(d.data)
+	if l <= 0 {
+		d.fail()
+		return 0
+	}
+
+	d.data = d.data[l:]
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	v, l := binary.Varint(d.data)
+	if l <= 0 {
+		d.fail()
+		return 0
+	}
+
+	d.data = d.data[l:]
+	return v
+}
+
+// length reads a length and checks there is enough data
+// left for items of at least the given size.
+func (d *binaryDecoder) length(size int) int {
+	l := d.uvarint()
+	if l > uint64(len(d.data)/size) {
+		d.fail()
+		return 0
+	}
+
+	return int(l)
+}
+
+func (d *binaryDecoder) bytes(l int) []byte {
+	if l > len(d.data) {
+		d.fail()
+		return nil
+	}
+
+	b := d.data[:l]
+	d.data = d.data[l:]
+	return b
+}
+
+func (d *binaryDecoder) float() float64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func (d *binaryDecoder) string() string {
+	return string(d.bytes(d.length(1)))
+}
+
+func (d *binaryDecoder) time() time.Time {
+	if !d.bool() {
+		return time.Time{}
+	}
+
+	sec := d.varint()
+	nsec := d.uvarint()
+	if nsec >= 1e9 {
+		d.fail()
+		return time.Time{}
+	}
+
+	return time.Unix(sec, int64(nsec)).UTC()
+}
+
+func (d *binaryDecoder) elementInfo(flags byte) (int, ChangesetID, UserID, string, time.Time, *time.Time) {
+	version := int(d.varint())
+	cs := ChangesetID(d.varint())
+	uid := UserID(d.varint())
+	user := d.string()
+	ts := d.time()
+
+	var committed *time.Time
+	if flags&binaryCommitted != 0 {
+		t := d.time()
+		committed = &t
+	}
+
+	return version, cs, uid, user, ts, committed
+}
+
+func (d *binaryDecoder) tags() Tags {
+	l := d.length(2)
+	if l == 0 {
+		return nil
+	}
+
+	tags := make(Tags, l)
+	for i := range tags {
+		tags[i].Key = d.string()
+		tags[i].Value = d.string()
+	}
+
+	return tags
+}
+
+func (d *binaryDecoder) coords(fixed bool, prev *[2]int64) (float64, float64) {
+	if !fixed {
+		return d.float(), d.float()
+	}
+
+	prev[0] += d.varint()
+	prev[1] += d.varint()
+	return fromFixed(prev[0]), fromFixed(prev[1])
+}
+
+func (d *binaryDecoder) wayNodes() WayNodes {
+	l := d.length(5)
+	fixed := d.bool()
+	if l == 0 {
+		return nil
+	}
+
+	nodes := make(WayNodes, l)
+	var prevID, prevCS int64
+	var prev [2]int64
+	for i := range nodes {
+		prevID += d.varint()
+		nodes[i].ID = NodeID(prevID)
+		nodes[i].Version = int(d.varint())
+		prevCS += d.varint()
+		nodes[i].ChangesetID = ChangesetID(prevCS)
+		nodes[i].Lat, nodes[i].Lon = d.coords(fixed, &prev)
+	}
+
+	return nodes
+}
+
+func (d *binaryDecoder) members() Members {
+	l := d.length(9)
+	fixed := d.bool()
+	if l == 0 {
+		return nil
+	}
+
+	members := make(Members, l)
+	var prevRef, prevCS int64
+	var prev [2]int64
+	for i := range members {
+		m := &members[i]
+		m.Type = Type(d.string())
+		prevRef += d.varint()
+		m.Ref = prevRef
+		m.Role = d.string()
+		m.Version = int(d.varint())
+		prevCS += d.varint()
+		m.ChangesetID = ChangesetID(prevCS)
+		m.Lat, m.Lon = d.coords(fixed, &prev)
+		m.Orientation = geo.Orientation(d.varint())
+		m.Nodes = d.wayNodes()
+	}
+
+	return members
+}
+
+func (d *binaryDecoder) updates() Updates {
+	l := d.length(8)
+	fixed := d.bool()
+	if l == 0 {
+		return nil
+	}
+
+	updates := make(Updates, l)
+	var prev [2]int64
+	for i := range updates {
+		u := &updates[i]
+		u.Index = int(d.varint())
+		u.Version = int(d.varint())
+		u.Timestamp = d.time()
+		u.ChangesetID = ChangesetID(d.varint())
+		u.Lat, u.Lon = d.coords(fixed, &prev)
+		u.Reverse = d.bool()
+	}
+
+	return updates
+}
+
+func (d *binaryDecoder) bounds(flags byte) *Bounds {
+	if flags&binaryBounds == 0 {
+		return nil
+	}
+
+	return &Bounds{
+		MinLat: d.float(),
+		MaxLat: d.float(),
+		MinLon: d.float(),
+		MaxLon: d.float(),
+	}
+}
+
+func (d *binaryDecoder) change() *Change {
+	c := &Change{
+		Version:     d.string(),
+		Generator:   d.string(),
+		Copyright:   d.string(),
+		Attribution: d.string(),
+		License:     d.string(),
+	}
+
+	sections := []**OSM{&c.Create, &c.Modify, &c.Delete}
+	for _, section := range sections {
+		if !d.bool() {
+			continue
+		}
+
+		o := &OSM{}
+		*section = o
+
+		nodes, ways, relations := d.length(1), d.length(1), d.length(1)
+		if nodes > 0 {
+			o.Nodes = make(Nodes, nodes)
+		}
+
+		if ways > 0 {
+			o.Ways = make(Ways, ways)
+		}
+
+		if relations > 0 {
+			o.Relations = make(Relations, relations)
+		}
+
+		var elements []encoding.BinaryUnmarshaler
+		for i := range o.Nodes {
+			o.Nodes[i] = &Node{}
+			elements = append(elements, o.Nodes[i])
+		}
+
+		for i := range o.Ways {
+			o.Ways[i] = &Way{}
+			elements = append(elements, o.Ways[i])
+		}
+
+		for i := range o.Relations {
+			o.Relations[i] = &Relation{}
+			elements = append(elements, o.Relations[i])
+		}
+
+		for _, el := range elements {
+			data := d.bytes(d.length(1))
+			if d.err != nil {
+				return nil
+			}
+
+			if err := el.UnmarshalBinary(data); err != nil {
+				d.err = err
+				return nil
+			}
+		}
+	}
+
+	return c
+}
+
+// fixedCoords returns true if the coordinates can be stored
+// with 7 decimals, like in the osm database, without loss.
+func fixedCoords(lat, lon float64) bool {
+	return fromFixed(toFixed(lat)) == lat && fromFixed(toFixed(lon)) == lon &&
+		math.Abs(lat) < 1e10 && math.Abs(lon) < 1e10
+}
+
+func toFixed(v float64) int64 {
+	return int64(math.Round(v * 1e7))
+}
+
+func fromFixed(v int64) float64 {
+	return float64(v) / 1e7
+}
@@ -0,0 +1,513 @@
+package osm
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pchchv/geo"
+)
+
+func TestNode_MarshalBinary(t *testing.T) {
+	committed := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	cases := []struct {
+		name string
+		node *Node
+	}{
+		{
+			name: "empty",
+			node: &Node{},
+		},
+		{
+			name: "all fields",
+			node: &Node{
+				ID:          123,
+				Lat:         51.5074089,
+				Lon:         -0.1278059,
+				User:        "user",
+				UserID:      42,
+				Visible:     true,
+				Version:     3,
+				ChangesetID: 5678,
+				Timestamp:   time.Date(2019, 5, 6, 7, 8, 9, 0, time.UTC),
+				Tags:        Tags{{Key: "amenity", Value: "cafe"}, {Key: "name", Value: "Café"}},
+				Committed:   &committed,
+			},
+		},
+		{
+			name: "raw coordinates",
+			node: &Node{ID: -1, Lat: 1.0 / 3, Lon: -179.123456789123},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.node.MarshalBinary()
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+
+			n := &Node{}
+			if err := n.UnmarshalBinary(data); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+
+			if !reflect.DeepEqual(n, tc.node) {
+				t.Errorf("incorrect node")
+				t.Logf("%+v", n)
+				t.Logf("%+v", tc.node)
+			}
+		})
+	}
+}
+
+func TestWay_MarshalBinary(t *testing.T) {
+	committed := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := &Way{
+		ID:          10,
+		User:        "user",
+		UserID:      1,
+		Visible:     true,
+		Version:     2,
+		ChangesetID: 30,
+		Timestamp:   time.Date(2019, 5, 6, 7, 8, 9, 0, time.UTC),
+		Nodes: WayNodes{
+			{ID: 100, Version: 1, ChangesetID: 20, Lat: 1.5, Lon: 2.5},
+			{ID: 90, Version: 3, ChangesetID: 25, Lat: -1.25, Lon: 3.1234567},
+			{ID: 100},
+		},
+		Tags:      Tags{{Key: "highway", Value: "residential"}},
+		Committed: &committed,
+		Updates: Updates{
+			{Index: 1, Version: 4, Timestamp: committed, ChangesetID: 31, Lat: 1, Lon: 2, Reverse: true},
+		},
+		Bounds: &Bounds{MinLat: -1.25, MaxLat: 1.5, MinLon: 2.5, MaxLon: 3.1234567},
+	}
+
+	data, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	result := &Way{}
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, w) {
+		t.Errorf("incorrect way")
+		t.Logf("%+v", result)
+		t.Logf("%+v", w)
+	}
+
+	// raw coordinates for one way node
+	w.Nodes[0].Lat = 0.1 + 0.2
+	data, err = w.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, w) {
+		t.Errorf("incorrect way with raw coordinates")
+	}
+}
+
+func TestRelation_MarshalBinary(t *testing.T) {
+	r := &Relation{
+		ID:          20,
+		User:        "user",
+		UserID:      1,
+		Visible:     false,
+		Version:     5,
+		ChangesetID: 30,
+		Timestamp:   time.Date(2019, 5, 6, 7, 8, 9, 0, time.UTC),
+		Tags:        Tags{{Key: "type", Value: "multipolygon"}},
+		Members: Members{
+			{
+				Type: TypeWay, Ref: 10, Role: "outer", Version: 2, ChangesetID: 30,
+				Orientation: geo.CW,
+				Nodes: WayNodes{
+					{ID: 1, Lat: 1, Lon: 1},
+					{ID: 2, Lat: 2, Lon: 1},
+				},
+			},
+			{Type: TypeWay, Ref: 5, Role: "inner", Orientation: geo.CCW},
+			{Type: TypeNode, Ref: 7, Role: "label", Lat: 1.5, Lon: 1.5},
+			{Type: TypeRelation, Ref: 1},
+		},
+		Updates: Updates{{Index: 2, Version: 3, Lat: 1.5, Lon: 1.5}},
+	}
+
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	result := &Relation{}
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, r) {
+		t.Errorf("incorrect relation")
+		t.Logf("%+v", result)
+		t.Logf("%+v", r)
+	}
+}
+
+func TestUpdate_MarshalBinary(t *testing.T) {
+	u := &Update{
+		Index:       1,
+		Version:     2,
+		Timestamp:   time.Date(2019, 5, 6, 7, 8, 9, 10, time.UTC),
+		ChangesetID: 3,
+		Lat:         4.5,
+		Lon:         -5.5,
+		Reverse:     true,
+	}
+
+	data, err := u.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	result := &Update{}
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, u) {
+		t.Errorf("incorrect update: %+v", result)
+	}
+}
+
+func TestChangeset_MarshalBinary(t *testing.T) {
+	data, err := os.ReadFile("testdata/minute_871.osc")
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+
+	change := &Change{}
+	if err := xml.Unmarshal(data, change); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+
+	cs := &Changeset{
+		ID:            123,
+		User:          "user",
+		UserID:        1,
+		CreatedAt:     time.Date(2019, 5, 6, 7, 8, 9, 0, time.UTC),
+		Open:          true,
+		ChangesCount:  10,
+		MinLat:        1,
+		MaxLat:        2,
+		MinLon:        3,
+		MaxLon:        4.123,
+		CommentsCount: 1,
+		Tags:          Tags{{Key: "comment", Value: "fix"}},
+		Discussion: &ChangesetDiscussion{
+			Comments: []*ChangesetComment{
+				{User: "other", UserID: 2, Timestamp: time.Date(2019, 5, 7, 0, 0, 0, 0, time.UTC), Text: "thanks"},
+			},
+		},
+		Change: change,
+	}
+
+	data, err = cs.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	result := &Changeset{}
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, cs) {
+		t.Errorf("incorrect changeset")
+	}
+
+	// empty changeset
+	data, err = (&Changeset{}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, &Changeset{}) {
+		t.Errorf("incorrect empty changeset: %+v", result)
+	}
+}
+
+func TestUnmarshalBinary_invalid(t *testing.T) {
+	w := &Way{
+		ID:    1,
+		Nodes: WayNodes{{ID: 1}, {ID: 2}},
+		Tags:  Tags{{Key: "k", Value: "v"}},
+	}
+
+	data, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	// every truncation must fail
+	for i := 0; i < len(data); i++ {
+		if err := (&Way{}).UnmarshalBinary(data[:i]); !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("truncated at %d: expected invalid, got %v", i, err)
+		}
+	}
+
+	if err := (&Way{}).UnmarshalBinary(append(data, 0)); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("trailing data: expected invalid, got %v", err)
+	}
+
+	data[0] = 2
+	if err := (&Way{}).UnmarshalBinary(data); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("version: expected invalid, got %v", err)
+	}
+}
+
+func BenchmarkChangeset_MarshalBinary(b *testing.B) {
+	cs := benchmarkChangeset(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := cs.MarshalBinary()
+		if err != nil {
+			b.Fatalf("could not marshal: %e", err)
+		}
+	}
+}
+
+func BenchmarkChangeset_UnmarshalBinary(b *testing.B) {
+	cs := benchmarkChangeset(b)
+	data, err := cs.MarshalBinary()
+	if err != nil {
+		b.Fatalf("could not marshal: %e", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		c := &Changeset{}
+		err := c.UnmarshalBinary(data)
+		if err != nil {
+			b.Fatalf("could not unmarshal: %e", err)
+		}
+	}
+}
+
+func BenchmarkChangeset_MarshalJSON(b *testing.B) {
+	cs := benchmarkChangeset(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := json.Marshal(cs)
+		if err != nil {
+			b.Fatalf("could not marshal: %e", err)
+		}
+	}
+}
+
+func BenchmarkChangeset_UnmarshalJSON(b *testing.B) {
+	cs := benchmarkChangeset(b)
+	data, err := json.Marshal(cs)
+	if err != nil {
+		b.Fatalf("could not marshal: %e", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		c := &Changeset{}
+		err := json.Unmarshal(data, c)
+		if err != nil {
+			b.Fatalf("could not unmarshal: %e", err)
+		}
+	}
+}
+
+func benchmarkChangeset(b *testing.B) *Changeset {
+	data, err := os.ReadFile("testdata/minute_871.osc")
+	if err != nil {
+		b.Fatalf("could not read file: %e", err)
+	}
+
+	c := &Change{}
+	err = xml.Unmarshal(data, c)
+	if err != nil {
+		b.Fatalf("could not unmarshal: %e", err)
+	}
+
+	return &Changeset{ID: 1, Change: c}
+}
+
+func BenchmarkNode_MarshalBinary(b *testing.B) {
+	benchmarkMarshalBinary(b, benchmarkNode(b))
+}
+
+func BenchmarkNode_UnmarshalBinary(b *testing.B) {
+	benchmarkUnmarshalBinary(b, benchmarkNode(b), func() encoding.BinaryUnmarshaler { return &Node{} })
+}
+
+func BenchmarkNode_MarshalJSON(b *testing.B) {
+	benchmarkMarshalJSON(b, benchmarkNode(b))
+}
+
+func BenchmarkNode_UnmarshalJSON(b *testing.B) {
+	benchmarkUnmarshalJSON(b, benchmarkNode(b), func() interface{} { return &Node{} })
+}
+
+func BenchmarkWay_MarshalBinary(b *testing.B) {
+	benchmarkMarshalBinary(b, benchmarkWay(b))
+}
+
+func BenchmarkWay_UnmarshalBinary(b *testing.B) {
+	benchmarkUnmarshalBinary(b, benchmarkWay(b), func() encoding.BinaryUnmarshaler { return &Way{} })
+}
+
+func BenchmarkWay_MarshalJSON(b *testing.B) {
+	benchmarkMarshalJSON(b, benchmarkWay(b))
+}
+
+func BenchmarkWay_UnmarshalJSON(b *testing.B) {
+	benchmarkUnmarshalJSON(b, benchmarkWay(b), func() interface{} { return &Way{} })
+}
+
+func BenchmarkRelation_MarshalBinary(b *testing.B) {
+	benchmarkMarshalBinary(b, benchmarkRelation())
+}
+
+func BenchmarkRelation_UnmarshalBinary(b *testing.B) {
+	benchmarkUnmarshalBinary(b, benchmarkRelation(), func() encoding.BinaryUnmarshaler { return &Relation{} })
+}
+
+func BenchmarkRelation_MarshalJSON(b *testing.B) {
+	benchmarkMarshalJSON(b, benchmarkRelation())
+}
+
+func BenchmarkRelation_UnmarshalJSON(b *testing.B) {
+	benchmarkUnmarshalJSON(b, benchmarkRelation(), func() interface{} { return &Relation{} })
+}
+
+func benchmarkMarshalBinary(b *testing.B, m encoding.BinaryMarshaler) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := m.MarshalBinary()
+		if err != nil {
+			b.Fatalf("could not marshal: %e", err)
+		}
+	}
+}
+
+func benchmarkUnmarshalBinary(b *testing.B, m encoding.BinaryMarshaler, empty func() encoding.BinaryUnmarshaler) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		b.Fatalf("could not marshal: %e", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		err := empty().UnmarshalBinary(data)
+		if err != nil {
+			b.Fatalf("could not unmarshal: %e", err)
+		}
+	}
+}
+
+func benchmarkMarshalJSON(b *testing.B, v interface{}) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := json.Marshal(v)
+		if err != nil {
+			b.Fatalf("could not marshal: %e", err)
+		}
+	}
+}
+
+func benchmarkUnmarshalJSON(b *testing.B, v interface{}, empty func() interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.Fatalf("could not marshal: %e", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		err := json.Unmarshal(data, empty())
+		if err != nil {
+			b.Fatalf("could not unmarshal: %e", err)
+		}
+	}
+}
+
+// benchmarkNode returns the node of the test change with the most tags.
+func benchmarkNode(b *testing.B) *Node {
+	var result *Node
+	for _, n := range benchmarkChangeset(b).Change.Modify.Nodes {
+		if result == nil || len(n.Tags) > len(result.Tags) {
+			result = n
+		}
+	}
+
+	return result
+}
+
+// benchmarkWay returns the way of the test change with the most nodes.
+func benchmarkWay(b *testing.B) *Way {
+	var result *Way
+	for _, w := range benchmarkChangeset(b).Change.Modify.Ways {
+		if result == nil || len(w.Nodes) > len(result.Nodes) {
+			result = w
+		}
+	}
+
+	return result
+}
+
+// benchmarkRelation returns a multipolygon relation with 100 way members.
+func benchmarkRelation() *Relation {
+	r := &Relation{
+		ID:          1234567,
+		User:        "user",
+		UserID:      1,
+		Visible:     true,
+		Version:     3,
+		ChangesetID: 40848253,
+		Timestamp:   time.Date(2016, 7, 19, 5, 46, 35, 0, time.UTC),
+		Tags: Tags{
+			{Key: "type", Value: "multipolygon"},
+			{Key: "landuse", Value: "forest"},
+			{Key: "name", Value: "Parc du Mont-Royal"},
+		},
+	}
+
+	for i := 0; i < 100; i++ {
+		role := "outer"
+		if i%10 == 0 {
+			role = "inner"
+		}
+
+		r.Members = append(r.Members, Member{Type: TypeWay, Ref: int64(100000 + i), Role: role})
+	}
+
+	return r
+}
@@ -3,6 +3,7 @@ package osm
 import (
 	"errors"
 
+	"github.com/pchchv/geo"
 	"github.com/pchchv/geo/maptile"
 )
 
@@ -40,6 +41,14 @@ func (b *Bounds) ObjectID() ObjectID {
 	return ObjectID(boundsMask)
 }
 
+// Bound returns the bounds as a geo.Bound.
+func (b *Bounds) Bound() geo.Bound {
+	return geo.Bound{
+		Min: geo.Point{b.MinLon, b.MinLat},
+		Max: geo.Point{b.MaxLon, b.MaxLat},
+	}
+}
+
 // ContainsNode returns true if the node is within the bound.
 // Uses inclusive intervals, ie. returns true if on the boundary.
 func (b *Bounds) ContainsNode(n *Node) bool {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pchchv/geo"
 	"github.com/pchchv/geo/maptile"
 )
 
@@ -57,6 +58,14 @@ func TestBounds_ContainsNode(t *testing.T) {
 	}
 }
 
+func TestBounds_Bound(t *testing.T) {
+	b := &Bounds{MinLat: 1, MaxLat: 2, MinLon: 3, MaxLon: 4}
+	expected := geo.Bound{Min: geo.Point{3, 1}, Max: geo.Point{4, 2}}
+	if v := b.Bound(); !v.Equal(expected) {
+		t.Errorf("incorrect bound: %v", v)
+	}
+}
+
 func mustBounds(t *testing.T, x, y uint32, z maptile.Zoom) *Bounds {
 	bounds, err := NewBoundsFromTile(maptile.New(x, y, z))
 	if err != nil {
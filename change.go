@@ -1,6 +1,81 @@
 package osm
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The different reasons a change can conflict with a dataset.
+const (
+	ConflictExists     ConflictReason = "exists"
+	ConflictMissing    ConflictReason = "missing"
+	ConflictVersion    ConflictReason = "version"
+	ConflictReferenced ConflictReason = "referenced"
+)
+
+var (
+	_ error = &ConflictError{}
+	_ error = ConflictErrors{}
+
+	// ErrUnsorted is returned by ComputeScannerChange and NewSnapshotScanner
+	// if the elements of a scanner are not sorted by type and then id.
+	ErrUnsorted = errors.New("osm: scanner elements are not sorted")
+
+	// ErrNilDataset is returned by Change.ApplyTo if the dataset is nil.
+	ErrNilDataset = errors.New("osm: dataset is nil")
+)
+
+// ConflictReason is a strong type for the reasons a change can not be applied.
+type ConflictReason string
+
+// ConflictError is a single conflict found when applying a change to a dataset.
+type ConflictError struct {
+	Action ActionType
+	Reason ConflictReason
+	// ID is the element in the change that conflicts.
+	ID ElementID
+	// Version is the version of the feature in the dataset, if present.
+	Version int
+	// ReferencedBy lists the ways and relations still
+	// referencing a deleted element.
+	ReferencedBy FeatureIDs
+}
+
+// Error returns a pretty string of the error.
+func (e *ConflictError) Error() string {
+	switch e.Reason {
+	case ConflictExists:
+		return fmt.Sprintf("osm: %s %v: already exists", e.Action, e.ID)
+	case ConflictMissing:
+		return fmt.Sprintf("osm: %s %v: not found", e.Action, e.ID)
+	case ConflictVersion:
+		return fmt.Sprintf("osm: %s %v: version not newer than %d", e.Action, e.ID, e.Version)
+	case ConflictReferenced:
+		return fmt.Sprintf("osm: %s %v: still referenced by %v", e.Action, e.ID, e.ReferencedBy)
+	}
+
+	return fmt.Sprintf("osm: %s %v: %s", e.Action, e.ID, e.Reason)
+}
+
+// ConflictErrors is returned by Change.ApplyTo
+// with all the conflicts found.
+type ConflictErrors []*ConflictError
+
+// Error returns a pretty string of the errors.
+func (e ConflictErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e))
+	for _, c := range e {
+		msgs = append(msgs, strings.TrimPrefix(c.Error(), "osm: "))
+	}
+
+	return fmt.Sprintf("osm: %d conflicts: %s", len(e), strings.Join(msgs, "; "))
+}
 
 // Change is the structure of a changeset to be uploaded or downloaded from the osm api server.
 type Change struct {
@@ -95,6 +170,468 @@ func (c *Change) HistoryDatasource() *HistoryDatasource {
 	return ds
 }
 
+// ApplyTo applies the creates, modifies and deletes of the change to the
+// nodes, ways and relations of the dataset, matching elements by feature id.
+// Within each section the elements are applied in version order,
+// so a change may contain several versions of the same feature.
+// Versions must increase, e.g. a modify must have a version greater than
+// the current one, unless one of the versions is unknown, i.e. zero.
+// Deletes of elements still referenced by a way or relation,
+// after the whole change is applied, are also reported as conflicts.
+// If there are any conflicts the dataset is not modified and
+// a ConflictErrors is returned.
+// Deleted elements are removed, created elements are appended.
+// If the dataset has several versions of a feature only the latest is kept.
+// A nil change is a no-op, a nil dataset returns ErrNilDataset.
+func (c *Change) ApplyTo(o *OSM) error {
+	if o == nil {
+		return ErrNilDataset
+	}
+
+	if c == nil {
+		return nil
+	}
+
+	ds := newApplyDataset(o)
+	var conflicts ConflictErrors
+	for _, a := range c.actions() {
+		if conflict := ds.apply(a.action, a.element); conflict != nil {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	conflicts = append(conflicts, ds.referenced()...)
+	if len(conflicts) > 0 {
+		return conflicts
+	}
+
+	ds.writeTo(o)
+	return nil
+}
+
+// applyDataset is the working copy of a dataset
+// used to apply a change.
+type applyDataset struct {
+	current map[FeatureID]Element
+	created []Element
+	deleted map[FeatureID]ElementID
+}
+
+func newApplyDataset(o *OSM) *applyDataset {
+	ds := &applyDataset{
+		current: make(map[FeatureID]Element),
+		deleted: make(map[FeatureID]ElementID),
+	}
+
+	// the dataset may contain several versions of a feature, use the latest
+	for _, e := range o.Elements() {
+		if existing, ok := ds.current[e.FeatureID()]; ok &&
+			existing.ElementID().Version() > e.ElementID().Version() {
+			continue
+		}
+
+		ds.current[e.FeatureID()] = e
+	}
+
+	return ds
+}
+
+func (ds *applyDataset) apply(action ActionType, e Element) *ConflictError {
+	id := e.ElementID()
+	existing, ok := ds.current[id.FeatureID()]
+	if action == ActionCreate {
+		if ok {
+			return &ConflictError{Action: action, Reason: ConflictExists, ID: id, Version: existing.ElementID().Version()}
+		}
+
+		ds.current[id.FeatureID()] = e
+		ds.created = append(ds.created, e)
+		delete(ds.deleted, id.FeatureID())
+		return nil
+	}
+
+	if !ok {
+		return &ConflictError{Action: action, Reason: ConflictMissing, ID: id}
+	}
+
+	v := existing.ElementID().Version()
+	if v != 0 && id.Version() != 0 && id.Version() <= v {
+		return &ConflictError{Action: action, Reason: ConflictVersion, ID: id, Version: v}
+	}
+
+	if action == ActionDelete {
+		delete(ds.current, id.FeatureID())
+		ds.deleted[id.FeatureID()] = id
+	} else {
+		ds.current[id.FeatureID()] = e
+	}
+
+	return nil
+}
+
+// referenced returns conflicts for deleted
+// elements still referenced by the current dataset.
+func (ds *applyDataset) referenced() ConflictErrors {
+	if len(ds.deleted) == 0 {
+		return nil
+	}
+
+	refs := make(map[FeatureID]FeatureIDs)
+	add := func(child FeatureID, parent FeatureID) {
+		if _, ok := ds.deleted[child]; ok {
+			refs[child] = append(refs[child], parent)
+		}
+	}
+
+	for _, e := range ds.current {
+		switch e := e.(type) {
+		case *Way:
+			for _, wn := range e.Nodes {
+				add(wn.FeatureID(), e.FeatureID())
+			}
+		case *Relation:
+			for _, m := range e.Members {
+				add(m.FeatureID(), e.FeatureID())
+			}
+		}
+	}
+
+	// map iteration is random, keep the errors stable
+	children := make(FeatureIDs, 0, len(refs))
+	for child := range refs {
+		children = append(children, child)
+	}
+	children.Sort()
+
+	conflicts := make(ConflictErrors, 0, len(refs))
+	for _, child := range children {
+		parents := refs[child]
+		parents.Sort()
+		conflicts = append(conflicts, &ConflictError{
+			Action:       ActionDelete,
+			Reason:       ConflictReferenced,
+			ID:           ds.deleted[child],
+			ReferencedBy: parents,
+		})
+	}
+
+	return conflicts
+}
+
+// writeTo replaces the elements of the dataset with the current ones,
+// keeping the order. Every feature is written once.
+func (ds *applyDataset) writeTo(o *OSM) {
+	elements := append(o.Elements(), ds.created...)
+	written := make(map[FeatureID]struct{}, len(ds.current))
+	o.Nodes, o.Ways, o.Relations = o.Nodes[:0], o.Ways[:0], o.Relations[:0]
+	for _, e := range elements {
+		// created elements can be modified later in the same change
+		id := e.FeatureID()
+		if _, ok := written[id]; ok {
+			continue
+		}
+
+		if e, ok := ds.current[id]; ok {
+			o.Append(e)
+			written[id] = struct{}{}
+		}
+	}
+}
+
+// ComputeChange returns the change needed to go from the before to the after dataset.
+// Elements are compared by feature id and content, i.e. tags, coordinates,
+// way nodes and members, so versions are not required to be consistent.
+// If there are several versions of a feature the latest one is used.
+// Creates and modifies contain the after elements,
+// deletes contain the before elements.
+// The elements in every section are sorted by type and id.
+func ComputeChange(before, after *OSM) *Change {
+	befores := latestVersions(before.Elements())
+	afters := latestVersions(after.Elements())
+
+	c := &Change{}
+	var i, j int
+	for i < len(befores) || j < len(afters) {
+		switch {
+		case j == len(afters) || (i < len(befores) && befores[i].FeatureID() < afters[j].FeatureID()):
+			c.AppendDelete(befores[i])
+			i++
+		case i == len(befores) || afters[j].FeatureID() < befores[i].FeatureID():
+			c.AppendCreate(afters[j])
+			j++
+		default:
+			if !elementContentEqual(befores[i], afters[j]) {
+				c.AppendModify(afters[j])
+			}
+			i++
+			j++
+		}
+	}
+
+	return c
+}
+
+// ComputeScannerChange returns the change needed to go from the elements
+// of the before scanner to the elements of the after scanner.
+// It is the streaming version of ComputeChange and only keeps the
+// current element of each scanner in memory.
+// Both scanners must be sorted by type, node, way, relation,
+// and then id like the planet and extract files.
+// ErrUnsorted is returned if this is not the case.
+// If there are several versions of a feature the last one is used.
+// Objects that are not nodes, ways or relations are skipped.
+// The scanners are not closed.
+func ComputeScannerChange(before, after Scanner) (*Change, error) {
+	br := &sortedElementReader{scanner: before}
+	ar := &sortedElementReader{scanner: after}
+
+	b, err := br.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := ar.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Change{}
+	for b != nil || a != nil {
+		switch {
+		case a == nil || (b != nil && b.FeatureID() < a.FeatureID()):
+			c.AppendDelete(b)
+			if b, err = br.Next(); err != nil {
+				return nil, err
+			}
+		case b == nil || a.FeatureID() < b.FeatureID():
+			c.AppendCreate(a)
+			if a, err = ar.Next(); err != nil {
+				return nil, err
+			}
+		default:
+			if !elementContentEqual(b, a) {
+				c.AppendModify(a)
+			}
+
+			if b, err = br.Next(); err != nil {
+				return nil, err
+			}
+
+			if a, err = ar.Next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// latestVersions sorts the elements by type, id and version
+// and only keeps the latest version of each feature.
+func latestVersions(es Elements) Elements {
+	es.Sort()
+
+	result := es[:0]
+	for i, e := range es {
+		if i+1 < len(es) && es[i+1].FeatureID() == e.FeatureID() {
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// SquashChanges merges an ordered list of changes, e.g. an hour of minutely diffs,
+// into one equivalent change. Only the newest version of each feature is kept,
+// older versions that come later in the list are ignored.
+// A create followed by a delete is removed, a create followed by a modify
+// becomes a create of the newest version and a delete followed by a create
+// or modify, i.e. an undelete, becomes a modify.
+// The elements in every section are sorted by type, id and version.
+// The header attributes, e.g. Version and Generator, are taken from the last change.
+func SquashChanges(changes ...*Change) *Change {
+	type state struct {
+		action  ActionType
+		element Element
+	}
+
+	result := &Change{}
+	states := make(map[FeatureID]*state)
+	for _, c := range changes {
+		if c == nil {
+			continue
+		}
+
+		result.Version = c.Version
+		result.Generator = c.Generator
+		result.Copyright = c.Copyright
+		result.Attribution = c.Attribution
+		result.License = c.License
+
+		for _, a := range c.actions() {
+			id := a.element.ElementID()
+			s := states[id.FeatureID()]
+			if s == nil {
+				states[id.FeatureID()] = &state{action: a.action, element: a.element}
+				continue
+			}
+
+			v := s.element.ElementID().Version()
+			if v != 0 && id.Version() != 0 && id.Version() < v {
+				continue
+			}
+
+			s.element = a.element
+			switch {
+			case s.action == ActionCreate && a.action == ActionDelete:
+				// created and deleted, nothing to do unless it is created again
+				s.action = ""
+			case s.action == "" && a.action != ActionDelete:
+				s.action = ActionCreate
+			case s.action == ActionDelete && a.action != ActionDelete:
+				s.action = ActionModify
+			case s.action == ActionModify:
+				s.action = a.action
+				if a.action == ActionCreate {
+					s.action = ActionModify
+				}
+			}
+		}
+	}
+
+	es := make(Elements, 0, len(states))
+	for _, s := range states {
+		if s.action != "" {
+			es = append(es, s.element)
+		}
+	}
+	es.Sort()
+
+	for _, e := range es {
+		switch states[e.FeatureID()].action {
+		case ActionCreate:
+			result.AppendCreate(e)
+		case ActionModify:
+			result.AppendModify(e)
+		case ActionDelete:
+			result.AppendDelete(e)
+		}
+	}
+
+	return result
+}
+
+type changeAction struct {
+	action  ActionType
+	element Element
+}
+
+// actions returns the elements of the change in the order they are
+// applied, i.e. the creates, modifies and deletes each sorted by
+// type, id and version.
+func (c *Change) actions() []changeAction {
+	var result []changeAction
+	for _, s := range []struct {
+		action ActionType
+		osm    *OSM
+	}{
+		{ActionCreate, c.Create},
+		{ActionModify, c.Modify},
+		{ActionDelete, c.Delete},
+	} {
+		es := s.osm.Elements()
+		es.Sort()
+		for _, e := range es {
+			result = append(result, changeAction{action: s.action, element: e})
+		}
+	}
+
+	return result
+}
+
+// sortedElementReader reads the last version of every
+// feature from a scanner sorted by feature id.
+type sortedElementReader struct {
+	scanner Scanner
+	next    Element
+	done    bool
+}
+
+// Next returns the next feature, nil if there are no more.
+func (r *sortedElementReader) Next() (Element, error) {
+	current := r.next
+	r.next = nil
+	for !r.done {
+		if !r.scanner.Scan() {
+			r.done = true
+			if err := r.scanner.Err(); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		e, ok := r.scanner.Object().(Element)
+		if !ok {
+			continue
+		}
+
+		if current == nil || current.FeatureID() == e.FeatureID() {
+			current = e
+			continue
+		}
+
+		if e.FeatureID() < current.FeatureID() {
+			return nil, ErrUnsorted
+		}
+
+		r.next = e
+		break
+	}
+
+	return current, nil
+}
+
+// elementContentEqual returns true if the elements have the same
+// tags, coordinates, way nodes or members, ignoring the metadata.
+func elementContentEqual(a, b Element) bool {
+	switch a := a.(type) {
+	case *Node:
+		b, ok := b.(*Node)
+		return ok && a.Lat == b.Lat && a.Lon == b.Lon && tagsEqual(a.Tags, b.Tags)
+	case *Way:
+		b, ok := b.(*Way)
+		if !ok || len(a.Nodes) != len(b.Nodes) || !tagsEqual(a.Tags, b.Tags) {
+			return false
+		}
+
+		for i := range a.Nodes {
+			if a.Nodes[i].ID != b.Nodes[i].ID {
+				return false
+			}
+		}
+
+		return true
+	case *Relation:
+		b, ok := b.(*Relation)
+		if !ok || len(a.Members) != len(b.Members) || !tagsEqual(a.Tags, b.Tags) {
+			return false
+		}
+
+		for i := range a.Members {
+			am, bm := a.Members[i], b.Members[i]
+			if am.Type != bm.Type || am.Ref != bm.Ref || am.Role != bm.Role {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
 func marshalInnerChange(e *xml.Encoder, name string, o *OSM) (err error) {
 	if o == nil {
 		return nil
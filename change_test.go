@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -234,6 +235,335 @@ func TestChange_HistoryDatasource(t *testing.T) {
 	}
 }
 
+func TestChange_ApplyTo(t *testing.T) {
+	o := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1},
+			{ID: 2, Version: 1},
+			{ID: 3, Version: 1},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+		},
+	}
+
+	c := &Change{
+		Create: &OSM{
+			Nodes: Nodes{{ID: 4, Version: 1}},
+		},
+		Modify: &OSM{
+			Nodes: Nodes{
+				{ID: 4, Version: 3, Lat: 2},
+				{ID: 4, Version: 2, Lat: 1},
+				{ID: 1, Version: 2, Lat: 1},
+			},
+			Ways: Ways{
+				{ID: 10, Version: 2, Nodes: WayNodes{{ID: 1}, {ID: 4}}},
+			},
+		},
+		Delete: &OSM{
+			Nodes: Nodes{{ID: 2, Version: 2}},
+		},
+	}
+
+	if err := c.ApplyTo(o); err != nil {
+		t.Fatalf("apply error: %v", err)
+	}
+
+	expected := ElementIDs{
+		NodeID(1).ElementID(2),
+		NodeID(3).ElementID(1),
+		NodeID(4).ElementID(3),
+		WayID(10).ElementID(2),
+	}
+	if ids := o.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+
+	if o.Nodes[2].Lat != 2 {
+		t.Errorf("should use latest version: %v", o.Nodes[2])
+	}
+}
+
+func TestChange_ApplyTo_history(t *testing.T) {
+	o := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 2, Lat: 2},
+			{ID: 1, Version: 1, Lat: 1},
+			{ID: 2, Version: 1},
+			{ID: 2, Version: 2},
+		},
+	}
+
+	c := &Change{
+		Modify: &OSM{Nodes: Nodes{{ID: 2, Version: 3, Lat: 3}}},
+	}
+
+	if err := c.ApplyTo(o); err != nil {
+		t.Fatalf("apply error: %v", err)
+	}
+
+	expected := ElementIDs{NodeID(1).ElementID(2), NodeID(2).ElementID(3)}
+	if ids := o.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+}
+
+func TestChange_ApplyTo_nil(t *testing.T) {
+	o := &OSM{Nodes: Nodes{{ID: 1, Version: 1}}}
+
+	var c *Change
+	if err := c.ApplyTo(o); err != nil {
+		t.Errorf("nil change should be a no-op: %v", err)
+	}
+
+	if len(o.Nodes) != 1 {
+		t.Errorf("should not modify dataset: %v", o.Nodes)
+	}
+
+	c = &Change{Create: &OSM{Nodes: Nodes{{ID: 2, Version: 1}}}}
+	if err := c.ApplyTo(nil); err != ErrNilDataset {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestChange_ApplyTo_conflicts(t *testing.T) {
+	o := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1},
+			{ID: 2, Version: 2},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+		},
+		Relations: Relations{
+			{ID: 20, Version: 1, Members: Members{{Type: TypeNode, Ref: 1}}},
+		},
+	}
+
+	c := &Change{
+		Create: &OSM{
+			Nodes: Nodes{{ID: 2, Version: 1}},
+		},
+		Modify: &OSM{
+			Nodes: Nodes{
+				{ID: 2, Version: 2},
+				{ID: 3, Version: 2},
+			},
+		},
+		Delete: &OSM{
+			Nodes: Nodes{{ID: 1, Version: 2}},
+		},
+	}
+
+	err := c.ApplyTo(o)
+	conflicts, ok := err.(ConflictErrors)
+	if !ok {
+		t.Fatalf("incorrect error: %v", err)
+	}
+
+	reasons := []ConflictReason{}
+	for _, c := range conflicts {
+		reasons = append(reasons, c.Reason)
+	}
+
+	expected := []ConflictReason{ConflictExists, ConflictVersion, ConflictMissing, ConflictReferenced}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("incorrect reasons: %v", reasons)
+	}
+
+	refs := conflicts[3].ReferencedBy
+	if !reflect.DeepEqual(refs, FeatureIDs{WayID(10).FeatureID(), RelationID(20).FeatureID()}) {
+		t.Errorf("incorrect references: %v", refs)
+	}
+
+	if len(o.Nodes) != 2 || o.Nodes[0].Version != 1 {
+		t.Errorf("dataset should not be modified: %v", o.ElementIDs())
+	}
+
+	if err.Error() == "" {
+		t.Errorf("should have an error message")
+	}
+}
+
+func TestComputeChange(t *testing.T) {
+	before := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1, Lat: 1},
+			{ID: 2, Version: 1, Tags: Tags{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}},
+			{ID: 3, Version: 1},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+		},
+		Relations: Relations{
+			{ID: 20, Version: 1, Members: Members{{Type: TypeNode, Ref: 1, Role: "a"}}},
+		},
+	}
+
+	after := &OSM{
+		Nodes: Nodes{
+			{ID: 4, Version: 1},
+			{ID: 1, Version: 5, Lat: 2},
+			// different version, same content
+			{ID: 2, Version: 7, Tags: Tags{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}}},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 1, Nodes: WayNodes{{ID: 2}, {ID: 1}}},
+		},
+		Relations: Relations{
+			{ID: 20, Version: 1, Members: Members{{Type: TypeNode, Ref: 1, Role: "b"}}},
+		},
+	}
+
+	c := ComputeChange(before, after)
+	if ids := c.Create.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(4).ElementID(1)}) {
+		t.Errorf("incorrect creates: %v", ids)
+	}
+
+	expected := ElementIDs{NodeID(1).ElementID(5), WayID(10).ElementID(1), RelationID(20).ElementID(1)}
+	if ids := c.Modify.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect modifies: %v", ids)
+	}
+
+	if ids := c.Delete.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(3).ElementID(1)}) {
+		t.Errorf("incorrect deletes: %v", ids)
+	}
+}
+
+func TestComputeChange_history(t *testing.T) {
+	before := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 2, Lat: 2},
+			{ID: 1, Version: 1, Lat: 1},
+			{ID: 2, Version: 1},
+		},
+	}
+
+	after := &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1, Lat: 1},
+			{ID: 1, Version: 2, Lat: 2},
+			{ID: 2, Version: 1},
+			{ID: 2, Version: 2, Lat: 3},
+		},
+	}
+
+	c := ComputeChange(before, after)
+	if ids := c.Create.ElementIDs(); len(ids) != 0 {
+		t.Errorf("incorrect creates: %v", ids)
+	}
+
+	if ids := c.Modify.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(2).ElementID(2)}) {
+		t.Errorf("incorrect modifies: %v", ids)
+	}
+
+	if ids := c.Delete.ElementIDs(); len(ids) != 0 {
+		t.Errorf("incorrect deletes: %v", ids)
+	}
+}
+
+func TestComputeScannerChange(t *testing.T) {
+	before := newTestScanner(
+		&Bounds{},
+		&Node{ID: 1, Version: 1, Lat: 1},
+		&Node{ID: 2, Version: 1},
+		&Node{ID: 3, Version: 1},
+		&Way{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+	)
+
+	after := newTestScanner(
+		&Node{ID: 1, Version: 1, Lat: 1},
+		&Node{ID: 1, Version: 2, Lat: 2},
+		&Node{ID: 2, Version: 1},
+		&Node{ID: 4, Version: 1},
+		&Way{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+		&Relation{ID: 20, Version: 1},
+	)
+
+	c, err := ComputeScannerChange(before, after)
+	if err != nil {
+		t.Fatalf("compute error: %v", err)
+	}
+
+	expected := ElementIDs{NodeID(4).ElementID(1), RelationID(20).ElementID(1)}
+	if ids := c.Create.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect creates: %v", ids)
+	}
+
+	if ids := c.Modify.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(1).ElementID(2)}) {
+		t.Errorf("incorrect modifies: %v", ids)
+	}
+
+	if ids := c.Delete.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(3).ElementID(1)}) {
+		t.Errorf("incorrect deletes: %v", ids)
+	}
+}
+
+func TestComputeScannerChange_unsorted(t *testing.T) {
+	before := newTestScanner(&Node{ID: 2}, &Node{ID: 1})
+	after := newTestScanner(&Node{ID: 1}, &Node{ID: 2})
+
+	if _, err := ComputeScannerChange(before, after); err != ErrUnsorted {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestSquashChanges(t *testing.T) {
+	c1 := &Change{
+		Generator: "first",
+		Create: &OSM{
+			Nodes: Nodes{
+				{ID: 1, Version: 1},
+				{ID: 2, Version: 1},
+			},
+		},
+		Modify: &OSM{
+			Nodes: Nodes{{ID: 3, Version: 2}},
+			Ways:  Ways{{ID: 10, Version: 3}},
+		},
+		Delete: &OSM{
+			Nodes: Nodes{{ID: 5, Version: 4}},
+		},
+	}
+
+	c2 := &Change{
+		Generator: "second",
+		Modify: &OSM{
+			Nodes: Nodes{
+				{ID: 1, Version: 3},
+				{ID: 1, Version: 2},
+				{ID: 5, Version: 5},
+			},
+			Ways: Ways{{ID: 10, Version: 2}},
+		},
+		Delete: &OSM{
+			Nodes: Nodes{
+				{ID: 2, Version: 2},
+				{ID: 3, Version: 3},
+			},
+		},
+	}
+
+	c := SquashChanges(c1, nil, c2)
+	if c.Generator != "second" {
+		t.Errorf("should use last header: %v", c.Generator)
+	}
+
+	if ids := c.Create.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(1).ElementID(3)}) {
+		t.Errorf("incorrect creates: %v", ids)
+	}
+
+	expected := ElementIDs{NodeID(5).ElementID(5), WayID(10).ElementID(3)}
+	if ids := c.Modify.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect modifies: %v", ids)
+	}
+
+	if ids := c.Delete.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{NodeID(3).ElementID(3)}) {
+		t.Errorf("incorrect deletes: %v", ids)
+	}
+}
+
 func BenchmarkChange_MarshalXML(b *testing.B) {
 	data, err := os.ReadFile("testdata/changeset_38162206.osc")
 	if err != nil {
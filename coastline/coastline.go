@@ -0,0 +1,287 @@
+package coastline
+
+import (
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/clip"
+	"github.com/pchchv/geo/clip/smartclip"
+	"github.com/pchchv/geo/maptile"
+	"github.com/pchchv/geo/planar"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/mputil"
+)
+
+// Coastline is the land and water of an extract built
+// from the natural=coastline ways.
+type Coastline struct {
+	bound geo.Bound
+	land  geo.MultiPolygon
+	water geo.MultiPolygon
+}
+
+// line is a joined coastline, oriented with the land on the left.
+type line struct {
+	ways []osm.WayID
+	line geo.LineString
+}
+
+// New joins the natural=coastline ways, other ways are skipped, and
+// builds the land and water polygons in the bound of the extract, e.g.
+// the world bound for planet files. Coastlines that are not closed are
+// closed along the edge of the bound, their ends must be outside of it.
+// The ways must be annotated with the node locations. The problems
+// found are reported as the errors of this package, the result is
+// built from the valid coastlines. If there are only closed coastlines
+// in the bound, they are islands and the rest is water.
+func New(ways osm.Ways, bound geo.Bound) (*Coastline, []error) {
+	var errs []error
+	var segments []mputil.Segment
+	var ids []osm.WayID
+	for _, w := range ways {
+		if w.Tags.Find("natural") != "coastline" {
+			continue
+		}
+
+		ls := w.LineString()
+		if len(ls) < 2 || len(ls) != len(w.Nodes) {
+			errs = append(errs, &IncompleteWayError{WayID: w.ID})
+			continue
+		}
+
+		segments = append(segments, mputil.Segment{Index: uint32(len(ids)), Line: ls})
+		ids = append(ids, w.ID)
+	}
+
+	errs = append(errs, intersections(segments, ids)...)
+
+	var lines []line
+	for _, ms := range mputil.Join(segments) {
+		l, err := orient(ms, ids)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		lines = append(lines, l)
+	}
+
+	var land, holes, open []geo.Ring
+	var holeWays [][]osm.WayID
+	for _, l := range lines {
+		ring := geo.Ring(l.line)
+		if len(ring) >= 4 && ring.Closed() {
+			// rings without an area, e.g. self-intersecting, are skipped
+			switch ring.Orientation() {
+			case geo.CCW:
+				land = append(land, ring)
+			case geo.CW:
+				holes = append(holes, ring)
+				holeWays = append(holeWays, l.ways)
+			}
+
+			continue
+		}
+
+		first, last := l.line[0], l.line[len(l.line)-1]
+		if inside(bound, first) {
+			errs = append(errs, &GapError{WayID: l.ways[0], Point: first})
+		}
+
+		if inside(bound, last) {
+			errs = append(errs, &GapError{WayID: l.ways[len(l.ways)-1], Point: last})
+		}
+
+		if !inside(bound, first) && !inside(bound, last) {
+			open = append(open, ring)
+		}
+	}
+
+	// clockwise rings must be inside of the land, e.g. lakes or lagoons,
+	// the others are islands drawn with the land on the right
+	outer := landPolygons(bound, open, land)
+	var lakes []geo.Ring
+	for i, h := range holes {
+		if contains(outer, h) {
+			lakes = append(lakes, h)
+			continue
+		}
+
+		errs = append(errs, &OrientationError{WayIDs: holeWays[i]})
+		land = append(land, reverse(h))
+	}
+	holes = lakes
+
+	c := &Coastline{bound: bound}
+	if len(open) == 0 {
+		c.land, c.water = islands(bound, land, holes)
+		return c, errs
+	}
+
+	// the land is on the left of the coastlines and the water on the right,
+	// so the water is built the same way from the reversed coastlines
+	var landInput, waterInput geo.MultiPolygon
+	for _, r := range open {
+		landInput = append(landInput, geo.Polygon{r})
+		waterInput = append(waterInput, geo.Polygon{reverse(r)})
+	}
+
+	for _, r := range land {
+		landInput = append(landInput, geo.Polygon{r})
+		waterInput[0] = append(waterInput[0], reverse(r))
+	}
+
+	for _, r := range holes {
+		landInput[0] = append(landInput[0], r)
+		waterInput = append(waterInput, geo.Polygon{reverse(r)})
+	}
+
+	c.land = smartclip.MultiPolygon(bound, landInput, geo.CCW)
+	c.water = smartclip.MultiPolygon(bound, waterInput, geo.CCW)
+	return c, errs
+}
+
+// landPolygons returns the land built from the open coastlines
+// and the islands, without the holes.
+func landPolygons(bound geo.Bound, open, land []geo.Ring) geo.MultiPolygon {
+	var mp geo.MultiPolygon
+	for _, r := range open {
+		mp = append(mp, geo.Polygon{r.Clone()})
+	}
+
+	for _, r := range land {
+		mp = append(mp, geo.Polygon{r.Clone()})
+	}
+
+	if len(open) == 0 {
+		return mp
+	}
+
+	return smartclip.MultiPolygon(bound, mp, geo.CCW)
+}
+
+// contains returns true if the ring is inside of any of the polygons.
+func contains(mp geo.MultiPolygon, r geo.Ring) bool {
+	for _, p := range mp {
+		if planar.RingContains(p[0], r[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// islands builds the land and water if all the coastlines are closed.
+func islands(bound geo.Bound, land, holes []geo.Ring) (geo.MultiPolygon, geo.MultiPolygon) {
+	var mp geo.MultiPolygon
+	for _, r := range land {
+		mp = append(mp, geo.Polygon{r})
+	}
+
+	// the holes, e.g. lagoons, are added to the smallest island containing them
+	for _, h := range holes {
+		best := -1
+		for i, p := range mp {
+			if planar.RingContains(p[0], h[0]) && (best < 0 || planar.Area(p[0]) < planar.Area(mp[best][0])) {
+				best = i
+			}
+		}
+
+		if best >= 0 {
+			mp[best] = append(mp[best], h)
+		}
+	}
+
+	mp = clip.MultiPolygon(bound, mp)
+	water := geo.MultiPolygon{{bound.ToRing()}}
+	for _, p := range mp {
+		water[0] = append(water[0], reverse(p[0]))
+		for _, h := range p[1:] {
+			water = append(water, geo.Polygon{reverse(h)})
+		}
+	}
+
+	return mp, water
+}
+
+// Bound returns the bound of the extract.
+func (c *Coastline) Bound() geo.Bound {
+	return c.bound
+}
+
+// Land returns the land polygons clipped to the bound.
+func (c *Coastline) Land(b geo.Bound) geo.MultiPolygon {
+	// clipping modifies the input
+	return clip.MultiPolygon(b, c.land.Clone())
+}
+
+// Water returns the water polygons clipped to the bound.
+func (c *Coastline) Water(b geo.Bound) geo.MultiPolygon {
+	return clip.MultiPolygon(b, c.water.Clone())
+}
+
+// Tiles returns the land, or water, polygons for the tiles of the zoom
+// covering the bound of the extract. Tiles without land, or water, are
+// not included.
+func (c *Coastline) Tiles(z maptile.Zoom, water bool) map[maptile.Tile]geo.MultiPolygon {
+	min := maptile.At(geo.Point{c.bound.Min[0], c.bound.Max[1]}, z)
+	max := maptile.At(geo.Point{c.bound.Max[0], c.bound.Min[1]}, z)
+
+	result := make(map[maptile.Tile]geo.MultiPolygon)
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			t := maptile.New(x, y, z)
+			mp := c.Land(t.Bound())
+			if water {
+				mp = c.Water(t.Bound())
+			}
+
+			if len(mp) > 0 {
+				result[t] = mp
+			}
+		}
+	}
+
+	return result
+}
+
+// orient returns the joined coastline in the direction of the most ways,
+// the ways in the other direction are reported.
+func orient(ms mputil.MultiSegment, ids []osm.WayID) (line, error) {
+	var forward, backward []osm.WayID
+	for _, s := range ms {
+		if s.Reversed {
+			backward = append(backward, ids[s.Index])
+		} else {
+			forward = append(forward, ids[s.Index])
+		}
+	}
+
+	l := line{line: ms.LineString()}
+	for _, s := range ms {
+		l.ways = append(l.ways, ids[s.Index])
+	}
+
+	if len(backward) > len(forward) {
+		l.line.Reverse()
+		for i, j := 0, len(l.ways)-1; i < j; i, j = i+1, j-1 {
+			l.ways[i], l.ways[j] = l.ways[j], l.ways[i]
+		}
+
+		backward = forward
+	}
+
+	if len(backward) > 0 {
+		return l, &OrientationError{WayIDs: backward}
+	}
+
+	return l, nil
+}
+
+// inside returns true if the point is strictly inside the bound.
+func inside(b geo.Bound, p geo.Point) bool {
+	return p[0] > b.Min[0] && p[0] < b.Max[0] && p[1] > b.Min[1] && p[1] < b.Max[1]
+}
+
+func reverse(r geo.Ring) geo.Ring {
+	result := r.Clone()
+	result.Reverse()
+	return result
+}
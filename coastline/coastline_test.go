@@ -0,0 +1,276 @@
+package coastline
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/maptile"
+	"github.com/pchchv/geo/planar"
+	"github.com/pchchv/osm"
+)
+
+var box = geo.Bound{Min: geo.Point{0, 0}, Max: geo.Point{10, 10}}
+
+// coastline returns an annotated natural=coastline way with node ids id*100+i.
+func coastline(id osm.WayID, points ...geo.Point) *osm.Way {
+	w := &osm.Way{ID: id, Tags: osm.Tags{{Key: "natural", Value: "coastline"}}}
+	for i, p := range points {
+		w.Nodes = append(w.Nodes, osm.WayNode{ID: osm.NodeID(int64(id)*100 + int64(i)), Version: 1, Lon: p[0], Lat: p[1]})
+	}
+
+	return w
+}
+
+func area(mp geo.MultiPolygon) float64 {
+	return math.Abs(planar.Area(mp))
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name string
+		ways osm.Ways
+		land float64
+		in   geo.Point
+		out  geo.Point
+	}{
+		{
+			name: "open coastline closed at the bound",
+			ways: osm.Ways{
+				coastline(2, geo.Point{5, 5}, geo.Point{11, 5}),
+				coastline(1, geo.Point{-1, 5}, geo.Point{5, 5}),
+				{ID: 3, Tags: osm.Tags{{Key: "natural", Value: "water"}}},
+			},
+			land: 50,
+			in:   geo.Point{5, 8},
+			out:  geo.Point{5, 2},
+		},
+		{
+			name: "island",
+			ways: osm.Ways{
+				coastline(1, geo.Point{2, 2}, geo.Point{4, 2}, geo.Point{4, 4}),
+				coastline(2, geo.Point{4, 4}, geo.Point{2, 4}, geo.Point{2, 2}),
+			},
+			land: 4,
+			in:   geo.Point{3, 3},
+			out:  geo.Point{8, 8},
+		},
+		{
+			name: "island with lagoon",
+			ways: osm.Ways{
+				coastline(1, geo.Point{1, 1}, geo.Point{9, 1}, geo.Point{9, 9}, geo.Point{1, 9}, geo.Point{1, 1}),
+				coastline(2, geo.Point{4, 4}, geo.Point{4, 6}, geo.Point{6, 6}, geo.Point{6, 4}, geo.Point{4, 4}),
+			},
+			land: 60,
+			in:   geo.Point{2, 2},
+			out:  geo.Point{5, 5},
+		},
+		{
+			name: "open coastline with island",
+			ways: osm.Ways{
+				coastline(1, geo.Point{-1, 5}, geo.Point{11, 5}),
+				coastline(2, geo.Point{2, 1}, geo.Point{4, 1}, geo.Point{4, 3}, geo.Point{2, 3}, geo.Point{2, 1}),
+			},
+			land: 54,
+			in:   geo.Point{3, 2},
+			out:  geo.Point{8, 2},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, errs := New(tc.ways, box)
+			if len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			land, water := c.Land(box), c.Water(box)
+			if v := area(land); math.Abs(v-tc.land) > 1e-9 {
+				t.Errorf("incorrect land area: %v != %v", v, tc.land)
+			}
+
+			if v := area(water); math.Abs(v-(100-tc.land)) > 1e-9 {
+				t.Errorf("incorrect water area: %v != %v", v, 100-tc.land)
+			}
+
+			if !planar.MultiPolygonContains(land, tc.in) || planar.MultiPolygonContains(water, tc.in) {
+				t.Errorf("%v should be land", tc.in)
+			}
+
+			if planar.MultiPolygonContains(land, tc.out) || !planar.MultiPolygonContains(water, tc.out) {
+				t.Errorf("%v should be water", tc.out)
+			}
+		})
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	t.Run("orientation", func(t *testing.T) {
+		c, errs := New(osm.Ways{
+			coastline(1, geo.Point{-1, 5}, geo.Point{3, 5}),
+			coastline(2, geo.Point{6, 5}, geo.Point{3, 5}),
+			coastline(3, geo.Point{6, 5}, geo.Point{11, 5}),
+		}, box)
+
+		var oe *OrientationError
+		if len(errs) != 1 || !errors.As(errs[0], &oe) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if len(oe.WayIDs) != 1 || oe.WayIDs[0] != 2 {
+			t.Errorf("incorrect ways: %v", oe.WayIDs)
+		}
+
+		if !planar.MultiPolygonContains(c.Land(box), geo.Point{5, 8}) {
+			t.Errorf("land should be north of the coastline")
+		}
+	})
+
+	t.Run("clockwise island", func(t *testing.T) {
+		c, errs := New(osm.Ways{
+			coastline(1, geo.Point{2, 2}, geo.Point{2, 4}, geo.Point{4, 4}),
+			coastline(2, geo.Point{4, 4}, geo.Point{4, 2}, geo.Point{2, 2}),
+		}, box)
+
+		var oe *OrientationError
+		if len(errs) != 1 || !errors.As(errs[0], &oe) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if len(oe.WayIDs) != 2 {
+			t.Errorf("incorrect ways: %v", oe.WayIDs)
+		}
+
+		if v := area(c.Land(box)); math.Abs(v-4) > 1e-9 {
+			t.Errorf("incorrect land area: %v", v)
+		}
+
+		if planar.MultiPolygonContains(c.Water(box), geo.Point{3, 3}) {
+			t.Errorf("island should not be water")
+		}
+	})
+
+	t.Run("clockwise island in the sea", func(t *testing.T) {
+		c, errs := New(osm.Ways{
+			coastline(1, geo.Point{-1, 5}, geo.Point{11, 5}),
+			coastline(2, geo.Point{2, 1}, geo.Point{2, 3}, geo.Point{4, 3}, geo.Point{4, 1}, geo.Point{2, 1}),
+		}, box)
+
+		var oe *OrientationError
+		if len(errs) != 1 || !errors.As(errs[0], &oe) || oe.WayIDs[0] != 2 {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if !planar.MultiPolygonContains(c.Land(box), geo.Point{3, 2}) {
+			t.Errorf("island should be land")
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		c, errs := New(osm.Ways{
+			coastline(1, geo.Point{-1, 5}, geo.Point{5, 5}),
+		}, box)
+
+		var ge *GapError
+		if len(errs) != 1 || !errors.As(errs[0], &ge) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if ge.WayID != 1 || !ge.Point.Equal(geo.Point{5, 5}) {
+			t.Errorf("incorrect error: %v", ge)
+		}
+
+		if len(c.Land(box)) != 0 {
+			t.Errorf("should not have land: %v", c.Land(box))
+		}
+	})
+
+	t.Run("intersection", func(t *testing.T) {
+		_, errs := New(osm.Ways{
+			coastline(1, geo.Point{-1, 5}, geo.Point{11, 5}),
+			coastline(2, geo.Point{5, -1}, geo.Point{5, 11}),
+		}, box)
+
+		var ie *IntersectionError
+		if len(errs) != 1 || !errors.As(errs[0], &ie) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if ie.WayIDs != [2]osm.WayID{1, 2} || !ie.Point.Equal(geo.Point{5, 5}) {
+			t.Errorf("incorrect error: %v", ie)
+		}
+	})
+
+	t.Run("intersection off-centre", func(t *testing.T) {
+		_, errs := New(osm.Ways{
+			coastline(1, geo.Point{-1, 5}, geo.Point{11, 5}),
+			coastline(2, geo.Point{2, -1}, geo.Point{2, 11}),
+		}, box)
+
+		var ie *IntersectionError
+		if len(errs) != 1 || !errors.As(errs[0], &ie) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if !ie.Point.Equal(geo.Point{2, 5}) {
+			t.Errorf("incorrect point: %v", ie.Point)
+		}
+	})
+
+	t.Run("self-intersection", func(t *testing.T) {
+		_, errs := New(osm.Ways{
+			coastline(1, geo.Point{2, 2}, geo.Point{4, 4}, geo.Point{4, 2}, geo.Point{2, 4}, geo.Point{2, 2}),
+		}, box)
+
+		var ie *IntersectionError
+		if len(errs) != 1 || !errors.As(errs[0], &ie) {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+
+		if ie.WayIDs != [2]osm.WayID{1, 1} || !ie.Point.Equal(geo.Point{3, 3}) {
+			t.Errorf("incorrect error: %v", ie)
+		}
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		w := coastline(1, geo.Point{-1, 5}, geo.Point{11, 5})
+		w.Nodes[1] = osm.WayNode{ID: 101}
+
+		_, errs := New(osm.Ways{w}, box)
+
+		var iwe *IncompleteWayError
+		if len(errs) != 1 || !errors.As(errs[0], &iwe) || iwe.WayID != 1 {
+			t.Fatalf("incorrect errors: %v", errs)
+		}
+	})
+}
+
+func TestCoastline_Tiles(t *testing.T) {
+	c, errs := New(osm.Ways{
+		coastline(1, geo.Point{-2, 0.5}, geo.Point{2, 0.5}),
+	}, geo.Bound{Min: geo.Point{-1, -1}, Max: geo.Point{1, 1}})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	land := c.Tiles(1, false)
+	if len(land) != 2 {
+		t.Fatalf("incorrect land tiles: %v", land)
+	}
+
+	for tile := range land {
+		if tile.Y != 0 {
+			t.Errorf("should not have land in %v", tile)
+		}
+	}
+
+	water := c.Tiles(1, true)
+	if len(water) != 4 {
+		t.Errorf("incorrect water tiles: %v", water)
+	}
+
+	if mp := water[maptile.New(0, 1, 1)]; math.Abs(area(mp)-1) > 1e-9 {
+		t.Errorf("incorrect water in tile: %v", mp)
+	}
+}
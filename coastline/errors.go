@@ -0,0 +1,70 @@
+package coastline
+
+import (
+	"fmt"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+var (
+	_ error = &IncompleteWayError{}
+	_ error = &OrientationError{}
+	_ error = &GapError{}
+	_ error = &IntersectionError{}
+)
+
+// IncompleteWayError is reported for coastline ways
+// that are not annotated with all the node locations.
+// The way is skipped.
+type IncompleteWayError struct {
+	WayID osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *IncompleteWayError) Error() string {
+	return fmt.Sprintf("coastline: way %d: missing node locations", e.WayID)
+}
+
+// OrientationError is reported for coastline ways that are reversed
+// compared to the ways they are joined with, the land must be on the left.
+// It is also reported for the ways of a clockwise closed coastline
+// that is not inside of the land, i.e. an island with the land on the right.
+// The ways are reversed to build the polygons.
+type OrientationError struct {
+	WayIDs []osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *OrientationError) Error() string {
+	return fmt.Sprintf("coastline: ways %v: reversed", e.WayIDs)
+}
+
+// GapError is reported for an end of a coastline that is not
+// joined with another coastline and inside the bound, i.e. it can
+// not be closed at the edge of the bound. The coastline is skipped.
+type GapError struct {
+	WayID osm.WayID
+	Point geo.Point
+}
+
+// Error returns a pretty string of the error.
+func (e *GapError) Error() string {
+	return fmt.Sprintf("coastline: way %d: gap at %v", e.WayID, e.Point)
+}
+
+// IntersectionError is reported for coastline ways that cross
+// themselves or each other. The polygons may be invalid.
+type IntersectionError struct {
+	WayIDs [2]osm.WayID
+	Point  geo.Point
+}
+
+// Error returns a pretty string of the error.
+func (e *IntersectionError) Error() string {
+	if e.WayIDs[0] == e.WayIDs[1] {
+		return fmt.Sprintf("coastline: way %d: self-intersection at %v", e.WayIDs[0], e.Point)
+	}
+
+	return fmt.Sprintf("coastline: ways %d and %d: intersection at %v", e.WayIDs[0], e.WayIDs[1], e.Point)
+}
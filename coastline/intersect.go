@@ -0,0 +1,54 @@
+package coastline
+
+import (
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+	"github.com/pchchv/osm/internal/rtree"
+	"github.com/pchchv/osm/mputil"
+)
+
+// intersections returns the crossings of the segments of the ways.
+// Segments touching at their ends, e.g. where the ways are joined,
+// are not reported.
+func intersections(segments []mputil.Segment, ids []osm.WayID) []error {
+	tree := rtree.New()
+	for i, s := range segments {
+		for j := 1; j < len(s.Line); j++ {
+			tree.Insert(segmentBound(s.Line[j-1], s.Line[j]), key(i, j))
+		}
+	}
+
+	var errs []error
+	for i, s := range segments {
+		for j := 1; j < len(s.Line); j++ {
+			a, b := s.Line[j-1], s.Line[j]
+			tree.Search(segmentBound(a, b), func(v int64) bool {
+				if v <= key(i, j) {
+					return true
+				}
+
+				other := segments[v>>32].Line
+				k := int(v & 0xffffffff)
+				if p, ok := measure.Crossing(a, b, other[k-1], other[k]); ok {
+					errs = append(errs, &IntersectionError{
+						WayIDs: [2]osm.WayID{ids[i], ids[v>>32]},
+						Point:  p,
+					})
+				}
+
+				return true
+			})
+		}
+	}
+
+	return errs
+}
+
+func segmentBound(a, b geo.Point) geo.Bound {
+	return geo.Bound{Min: a, Max: a}.Extend(b)
+}
+
+func key(segment, index int) int64 {
+	return int64(segment)<<32 | int64(index)
+}
@@ -0,0 +1,861 @@
+package osm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pchchv/geo"
+)
+
+var (
+	// ErrElementNotFound is returned by the Editor
+	// if an element does not exist or was deleted.
+	ErrElementNotFound = errors.New("osm: element not found")
+	// ErrElementReferenced is returned by the Editor when deleting
+	// an element that is still used by a way or relation.
+	ErrElementReferenced = errors.New("osm: element is referenced")
+	// ErrInvalidEdit is returned by the Editor if an operation
+	// can not be applied, e.g. splitting a way at an end node.
+	ErrInvalidEdit = errors.New("osm: invalid edit")
+	// ErrUnknownReferences is returned by the Editor when deleting an
+	// element of a base datasource that does not know its parents,
+	// so it can not be checked that the element is not used.
+	ErrUnknownReferences = errors.New("osm: element references are unknown")
+)
+
+var _ error = &EditError{}
+
+// EditError is returned by the operations of the Editor.
+// The element is identified by type and ref since new elements
+// have negative placeholder ids.
+type EditError struct {
+	Op   string
+	Type Type
+	Ref  int64
+	// Err is ErrElementNotFound, ErrElementReferenced, ErrInvalidEdit,
+	// ErrUnknownReferences or an error from the base datasource.
+	Err    error
+	Reason string
+}
+
+// Error returns a pretty string of the error.
+func (e *EditError) Error() string {
+	msg := fmt.Sprintf("osm: %s %s/%d: %s", e.Op, e.Type, e.Ref, strings.TrimPrefix(e.Err.Error(), "osm: "))
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error, so errors.Is can be used
+// to check for ErrElementNotFound, ErrElementReferenced and ErrInvalidEdit.
+func (e *EditError) Unwrap() error {
+	return e.Err
+}
+
+// backReferencer is implemented by datasources,
+// like the Store, that know the parents of an element.
+type backReferencer interface {
+	WaysForNode(id NodeID) Ways
+	RelationsForMember(id FeatureID) Relations
+}
+
+// Editor is an edit session on top of a base dataset.
+// The base data is never modified, edits are made on copies of the
+// latest version of the elements and can be collected into a Change
+// ready for upload. New elements get negative placeholder ids.
+//
+// Way and relation memberships are only found for elements
+// of the base datasource if it knows the parents of an element,
+// like the Store, and for elements loaded or created by the editor.
+// Elements of other base datasources can not be deleted.
+// It is not safe for concurrent use.
+type Editor struct {
+	base  HistoryDatasourcer
+	ids   *IDAllocator
+	edits map[editKey]*edit
+}
+
+type editKey struct {
+	Type Type
+	Ref  int64
+}
+
+type edit struct {
+	base    Element // nil if created by the editor
+	current Element // nil if deleted
+}
+
+// NewEditor creates a new edit session on top of the datasource.
+// The last version in the history of an element is edited.
+// The base can be nil to only create new elements.
+func NewEditor(base HistoryDatasourcer) *Editor {
+	return &Editor{
+		base:  base,
+		ids:   NewIDAllocator(),
+		edits: make(map[editKey]*edit),
+	}
+}
+
+// Editor creates a new edit session on top of the osm data.
+// If there are several versions of a feature the last one is edited.
+func (o *OSM) Editor() *Editor {
+	e := NewEditor(o.Store())
+	e.ids.Reserve(o)
+	return e
+}
+
+// Node returns the current state of the node.
+// The element must not be modified and will change with later edits.
+func (e *Editor) Node(ctx context.Context, id NodeID) (*Node, error) {
+	el, err := e.element(ctx, TypeNode, int64(id))
+	if err != nil {
+		return nil, &EditError{Op: "get", Type: TypeNode, Ref: int64(id), Err: err}
+	}
+
+	return el.(*Node), nil
+}
+
+// Way returns the current state of the way.
+// The element must not be modified and will change with later edits.
+func (e *Editor) Way(ctx context.Context, id WayID) (*Way, error) {
+	el, err := e.element(ctx, TypeWay, int64(id))
+	if err != nil {
+		return nil, &EditError{Op: "get", Type: TypeWay, Ref: int64(id), Err: err}
+	}
+
+	return el.(*Way), nil
+}
+
+// Relation returns the current state of the relation.
+// The element must not be modified and will change with later edits.
+func (e *Editor) Relation(ctx context.Context, id RelationID) (*Relation, error) {
+	el, err := e.element(ctx, TypeRelation, int64(id))
+	if err != nil {
+		return nil, &EditError{Op: "get", Type: TypeRelation, Ref: int64(id), Err: err}
+	}
+
+	return el.(*Relation), nil
+}
+
+// CreateNode creates a new node at the point with the tags.
+func (e *Editor) CreateNode(p geo.Point, tags Tags) *Node {
+	n := &Node{
+		ID:      e.ids.NodeID(),
+		Visible: true,
+		Lat:     p.Lat(),
+		Lon:     p.Lon(),
+		Tags:    append(Tags(nil), tags...),
+	}
+	e.edits[editKey{TypeNode, int64(n.ID)}] = &edit{current: n}
+
+	return n
+}
+
+// CreateWay creates a new way with the nodes and tags.
+// All the nodes must exist.
+func (e *Editor) CreateWay(ctx context.Context, nodes []NodeID, tags Tags) (*Way, error) {
+	w := &Way{
+		Visible: true,
+		Nodes:   make(WayNodes, 0, len(nodes)),
+		Tags:    append(Tags(nil), tags...),
+	}
+
+	for _, id := range nodes {
+		if _, err := e.element(ctx, TypeNode, int64(id)); err != nil {
+			return nil, &EditError{Op: "create", Type: TypeNode, Ref: int64(id), Err: err}
+		}
+
+		w.Nodes = append(w.Nodes, WayNode{ID: id})
+	}
+
+	w.ID = e.ids.WayID()
+	e.edits[editKey{TypeWay, int64(w.ID)}] = &edit{current: w}
+
+	return w, nil
+}
+
+// CreateRelation creates a new relation with the members and tags.
+// All the members must exist.
+func (e *Editor) CreateRelation(ctx context.Context, members Members, tags Tags) (*Relation, error) {
+	for _, m := range members {
+		if _, err := e.element(ctx, m.Type, m.Ref); err != nil {
+			return nil, &EditError{Op: "create", Type: m.Type, Ref: m.Ref, Err: err}
+		}
+	}
+
+	r := &Relation{
+		ID:      e.ids.RelationID(),
+		Visible: true,
+		Members: append(Members(nil), members...),
+		Tags:    append(Tags(nil), tags...),
+	}
+	e.edits[editKey{TypeRelation, int64(r.ID)}] = &edit{current: r}
+
+	return r, nil
+}
+
+// MoveNode changes the location of the node.
+func (e *Editor) MoveNode(ctx context.Context, id NodeID, p geo.Point) error {
+	el, err := e.modify(ctx, TypeNode, int64(id))
+	if err != nil {
+		return &EditError{Op: "move", Type: TypeNode, Ref: int64(id), Err: err}
+	}
+
+	n := el.(*Node)
+	n.Lat = p.Lat()
+	n.Lon = p.Lon()
+
+	return nil
+}
+
+// SetTag sets the value of the tag, adding the tag if needed.
+func (e *Editor) SetTag(ctx context.Context, t Type, ref int64, key, value string) error {
+	el, err := e.modify(ctx, t, ref)
+	if err != nil {
+		return &EditError{Op: "set tag", Type: t, Ref: ref, Err: err}
+	}
+
+	tags := elementTags(el)
+	for i := range *tags {
+		if (*tags)[i].Key == key {
+			(*tags)[i].Value = value
+			return nil
+		}
+	}
+
+	*tags = append(*tags, Tag{Key: key, Value: value})
+	return nil
+}
+
+// DeleteTag removes the tag from the element.
+// It is not an error if the element does not have the tag.
+func (e *Editor) DeleteTag(ctx context.Context, t Type, ref int64, key string) error {
+	el, err := e.modify(ctx, t, ref)
+	if err != nil {
+		return &EditError{Op: "delete tag", Type: t, Ref: ref, Err: err}
+	}
+
+	tags := elementTags(el)
+	result := (*tags)[:0]
+	for _, tag := range *tags {
+		if tag.Key != key {
+			result = append(result, tag)
+		}
+	}
+	*tags = result
+
+	return nil
+}
+
+// InsertWayNode inserts the node into the way at the index,
+// 0 prepends and len(way.Nodes) appends the node.
+func (e *Editor) InsertWayNode(ctx context.Context, id WayID, index int, node NodeID) error {
+	if _, err := e.element(ctx, TypeNode, int64(node)); err != nil {
+		return &EditError{Op: "insert way node", Type: TypeNode, Ref: int64(node), Err: err}
+	}
+
+	el, err := e.modify(ctx, TypeWay, int64(id))
+	if err != nil {
+		return &EditError{Op: "insert way node", Type: TypeWay, Ref: int64(id), Err: err}
+	}
+
+	w := el.(*Way)
+	if index < 0 || index > len(w.Nodes) {
+		return &EditError{
+			Op: "insert way node", Type: TypeWay, Ref: int64(id), Err: ErrInvalidEdit,
+			Reason: fmt.Sprintf("index %d out of range", index),
+		}
+	}
+
+	w.Nodes = append(w.Nodes, WayNode{})
+	copy(w.Nodes[index+1:], w.Nodes[index:])
+	w.Nodes[index] = WayNode{ID: node}
+
+	return nil
+}
+
+// RemoveWayNode removes the node at the index from the way.
+// The node itself is not deleted.
+func (e *Editor) RemoveWayNode(ctx context.Context, id WayID, index int) error {
+	el, err := e.modify(ctx, TypeWay, int64(id))
+	if err != nil {
+		return &EditError{Op: "remove way node", Type: TypeWay, Ref: int64(id), Err: err}
+	}
+
+	w := el.(*Way)
+	if index < 0 || index >= len(w.Nodes) {
+		return &EditError{
+			Op: "remove way node", Type: TypeWay, Ref: int64(id), Err: ErrInvalidEdit,
+			Reason: fmt.Sprintf("index %d out of range", index),
+		}
+	}
+
+	w.Nodes = append(w.Nodes[:index], w.Nodes[index+1:]...)
+	return nil
+}
+
+// SplitWay splits the way at the node. The way keeps the nodes up to and
+// including the node, a new way with the same tags gets the rest.
+// The new way is added to all the relations of the way, next to the way.
+// If the previous member connects to the new way it is inserted before
+// the way to keep the order of routes.
+// The node must be an inner node of the way, closed ways can not be split.
+func (e *Editor) SplitWay(ctx context.Context, id WayID, node NodeID) (*Way, error) {
+	el, err := e.element(ctx, TypeWay, int64(id))
+	if err != nil {
+		return nil, &EditError{Op: "split", Type: TypeWay, Ref: int64(id), Err: err}
+	}
+
+	invalid := func(format string, args ...interface{}) error {
+		return &EditError{
+			Op: "split", Type: TypeWay, Ref: int64(id), Err: ErrInvalidEdit,
+			Reason: fmt.Sprintf(format, args...),
+		}
+	}
+
+	w := el.(*Way)
+	if isClosed(w) {
+		return nil, invalid("closed way")
+	}
+
+	index := -1
+	for i, wn := range w.Nodes {
+		if wn.ID != node {
+			continue
+		}
+
+		if index != -1 {
+			return nil, invalid("node/%d is used more than once", node)
+		}
+		index = i
+	}
+
+	switch {
+	case index == -1:
+		return nil, invalid("node/%d is not part of the way", node)
+	case index == 0 || index == len(w.Nodes)-1:
+		return nil, invalid("node/%d is an end node", node)
+	}
+
+	relations, err := e.relationsForMember(ctx, TypeWay, int64(id))
+	if err != nil {
+		return nil, &EditError{Op: "split", Type: TypeWay, Ref: int64(id), Err: err}
+	}
+
+	el, _ = e.modify(ctx, TypeWay, int64(id))
+	w = el.(*Way)
+
+	nw := &Way{
+		ID:      e.ids.WayID(),
+		Visible: true,
+		Nodes:   append(WayNodes(nil), w.Nodes[index:]...),
+		Tags:    append(Tags(nil), w.Tags...),
+	}
+	e.edits[editKey{TypeWay, int64(nw.ID)}] = &edit{current: nw}
+	w.Nodes = append(WayNodes(nil), w.Nodes[:index+1]...)
+
+	for _, r := range relations {
+		el, err := e.modify(ctx, TypeRelation, int64(r.ID))
+		if err != nil {
+			return nil, &EditError{Op: "split", Type: TypeRelation, Ref: int64(r.ID), Err: err}
+		}
+
+		r := el.(*Relation)
+		members := make(Members, 0, len(r.Members)+1)
+		for i, m := range r.Members {
+			if m.Type != TypeWay || m.Ref != int64(id) {
+				members = append(members, m)
+				continue
+			}
+
+			nm := Member{Type: TypeWay, Ref: int64(nw.ID), Role: m.Role}
+			if i > 0 && e.connectsTo(ctx, r.Members[i-1], nw) && !e.connectsTo(ctx, r.Members[i-1], w) {
+				members = append(members, nm, m)
+			} else {
+				members = append(members, m, nm)
+			}
+		}
+		r.Members = members
+	}
+
+	return nw, nil
+}
+
+// MergeWays joins way b onto way a. The ways must share an end node,
+// way b is reversed if needed and then deleted.
+// Way a keeps its id and gets the tags of both ways,
+// the tags must not have conflicting values.
+// In relations way b is replaced by way a,
+// or removed if way a is already a member.
+func (e *Editor) MergeWays(ctx context.Context, a, b WayID) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &EditError{
+			Op: "merge", Type: TypeWay, Ref: int64(a), Err: ErrInvalidEdit,
+			Reason: fmt.Sprintf(format, args...),
+		}
+	}
+
+	if a == b {
+		return invalid("can not merge a way with itself")
+	}
+
+	ela, err := e.element(ctx, TypeWay, int64(a))
+	if err != nil {
+		return &EditError{Op: "merge", Type: TypeWay, Ref: int64(a), Err: err}
+	}
+
+	elb, err := e.element(ctx, TypeWay, int64(b))
+	if err != nil {
+		return &EditError{Op: "merge", Type: TypeWay, Ref: int64(b), Err: err}
+	}
+
+	wa, wb := ela.(*Way), elb.(*Way)
+	if len(wa.Nodes) == 0 || len(wb.Nodes) == 0 || isClosed(wa) || isClosed(wb) {
+		return invalid("closed or empty way")
+	}
+
+	for _, t := range wb.Tags {
+		if v := wa.Tags.FindTag(t.Key); v != nil && v.Value != t.Value {
+			return invalid("conflicting values for tag %q", t.Key)
+		}
+	}
+
+	an, bn := wa.Nodes, wb.Nodes
+	var nodes WayNodes
+	switch {
+	case an[len(an)-1].ID == bn[0].ID:
+		nodes = append(append(nodes, an...), bn[1:]...)
+	case an[len(an)-1].ID == bn[len(bn)-1].ID:
+		nodes = append(append(nodes, an...), reverseWayNodes(bn)[1:]...)
+	case an[0].ID == bn[len(bn)-1].ID:
+		nodes = append(append(nodes, bn...), an[1:]...)
+	case an[0].ID == bn[0].ID:
+		nodes = append(reverseWayNodes(bn), an[1:]...)
+	default:
+		return invalid("way/%d does not share an end node", b)
+	}
+
+	relations, err := e.relationsForMember(ctx, TypeWay, int64(b))
+	if err != nil {
+		return &EditError{Op: "merge", Type: TypeWay, Ref: int64(b), Err: err}
+	}
+
+	for _, r := range relations {
+		el, err := e.modify(ctx, TypeRelation, int64(r.ID))
+		if err != nil {
+			return &EditError{Op: "merge", Type: TypeRelation, Ref: int64(r.ID), Err: err}
+		}
+
+		r := el.(*Relation)
+		hasA := r.hasMember(TypeWay, int64(a))
+		members := r.Members[:0]
+		for _, m := range r.Members {
+			if m.Type == TypeWay && m.Ref == int64(b) {
+				if hasA {
+					continue
+				}
+				m.Ref = int64(a)
+			}
+
+			members = append(members, m)
+		}
+		r.Members = members
+	}
+
+	ela, _ = e.modify(ctx, TypeWay, int64(a))
+	wa = ela.(*Way)
+	wa.Nodes = nodes
+	for _, t := range wb.Tags {
+		if !wa.Tags.HasTag(t.Key) {
+			wa.Tags = append(wa.Tags, t)
+		}
+	}
+
+	return e.remove(ctx, TypeWay, int64(b))
+}
+
+// Delete deletes the element. Nodes used by ways and members of relations
+// can not be deleted, an ErrElementReferenced EditError is returned.
+// Elements of a base datasource that does not know the parents of an
+// element, e.g. a HistoryDatasource, can not be checked and return
+// an ErrUnknownReferences EditError.
+// Deleting a way or relation does not delete its nodes or members.
+func (e *Editor) Delete(ctx context.Context, t Type, ref int64) error {
+	if _, err := e.element(ctx, t, ref); err != nil {
+		return &EditError{Op: "delete", Type: t, Ref: ref, Err: err}
+	}
+
+	if _, ok := e.base.(backReferencer); !ok && ref > 0 {
+		return &EditError{Op: "delete", Type: t, Ref: ref, Err: ErrUnknownReferences}
+	}
+
+	if t == TypeNode {
+		ways, err := e.waysForNode(ctx, NodeID(ref))
+		if err != nil {
+			return &EditError{Op: "delete", Type: t, Ref: ref, Err: err}
+		}
+
+		if len(ways) > 0 {
+			return &EditError{
+				Op: "delete", Type: t, Ref: ref, Err: ErrElementReferenced,
+				Reason: fmt.Sprintf("used by way/%d", ways[0].ID),
+			}
+		}
+	}
+
+	relations, err := e.relationsForMember(ctx, t, ref)
+	if err != nil {
+		return &EditError{Op: "delete", Type: t, Ref: ref, Err: err}
+	}
+
+	if len(relations) > 0 {
+		return &EditError{
+			Op: "delete", Type: t, Ref: ref, Err: ErrElementReferenced,
+			Reason: fmt.Sprintf("member of relation/%d", relations[0].ID),
+		}
+	}
+
+	return e.remove(ctx, t, ref)
+}
+
+// Change returns the edits as a change. New elements are in the create,
+// changed elements in the modify and deleted elements in the delete section.
+// Elements that were created and deleted again, or changed back to their
+// base version, are not included. The elements are copies and
+// are sorted by type, new elements in the order they were created.
+func (e *Editor) Change() *Change {
+	keys := make([]editKey, 0, len(e.edits))
+	for k := range e.edits {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Type != b.Type {
+			return typeOrder(a.Type) < typeOrder(b.Type)
+		}
+
+		// new elements first, -1, -2, ...
+		if (a.Ref < 0) != (b.Ref < 0) {
+			return a.Ref < 0
+		}
+
+		if a.Ref < 0 {
+			return a.Ref > b.Ref
+		}
+
+		return a.Ref < b.Ref
+	})
+
+	// OSM.Append can not be used since it
+	// does not support negative placeholder ids
+	c := &Change{}
+	for _, k := range keys {
+		ed := e.edits[k]
+		switch {
+		case ed.base == nil:
+			if c.Create == nil {
+				c.Create = &OSM{}
+			}
+			appendElement(c.Create, cloneElement(ed.current))
+		case ed.current == nil:
+			if c.Delete == nil {
+				c.Delete = &OSM{}
+			}
+			appendElement(c.Delete, cloneElement(ed.base))
+		case !elementContentEqual(ed.base, ed.current):
+			if c.Modify == nil {
+				c.Modify = &OSM{}
+			}
+			appendElement(c.Modify, cloneElement(ed.current))
+		}
+	}
+
+	return c
+}
+
+// element returns the current state of the element.
+func (e *Editor) element(ctx context.Context, t Type, ref int64) (Element, error) {
+	if ed, ok := e.edits[editKey{t, ref}]; ok {
+		if ed.current == nil {
+			return nil, ErrElementNotFound
+		}
+
+		return ed.current, nil
+	}
+
+	return e.loadBase(ctx, t, ref)
+}
+
+// modify returns the working copy of the element that can be changed.
+func (e *Editor) modify(ctx context.Context, t Type, ref int64) (Element, error) {
+	key := editKey{t, ref}
+	if ed, ok := e.edits[key]; ok {
+		if ed.current == nil {
+			return nil, ErrElementNotFound
+		}
+
+		return ed.current, nil
+	}
+
+	base, err := e.loadBase(ctx, t, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	ed := &edit{base: base, current: cloneElement(base)}
+	e.edits[key] = ed
+
+	return ed.current, nil
+}
+
+// remove marks the element as deleted without checking references.
+func (e *Editor) remove(ctx context.Context, t Type, ref int64) error {
+	key := editKey{t, ref}
+	if ed, ok := e.edits[key]; ok {
+		if ed.base == nil {
+			delete(e.edits, key)
+		} else {
+			ed.current = nil
+		}
+
+		return nil
+	}
+
+	base, err := e.loadBase(ctx, t, ref)
+	if err != nil {
+		return &EditError{Op: "delete", Type: t, Ref: ref, Err: err}
+	}
+	e.edits[key] = &edit{base: base}
+
+	return nil
+}
+
+// loadBase returns the last version of the element from the base datasource.
+func (e *Editor) loadBase(ctx context.Context, t Type, ref int64) (Element, error) {
+	if e.base == nil || ref <= 0 {
+		return nil, ErrElementNotFound
+	}
+
+	var (
+		result Element
+		err    error
+	)
+
+	switch t {
+	case TypeNode:
+		var h Nodes
+		if h, err = e.base.NodeHistory(ctx, NodeID(ref)); err == nil && len(h) > 0 {
+			result = h[len(h)-1]
+		}
+	case TypeWay:
+		var h Ways
+		if h, err = e.base.WayHistory(ctx, WayID(ref)); err == nil && len(h) > 0 {
+			result = h[len(h)-1]
+		}
+	case TypeRelation:
+		var h Relations
+		if h, err = e.base.RelationHistory(ctx, RelationID(ref)); err == nil && len(h) > 0 {
+			result = h[len(h)-1]
+		}
+	}
+
+	if err != nil {
+		if e.base.NotFound(err) {
+			return nil, ErrElementNotFound
+		}
+
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, ErrElementNotFound
+	}
+
+	return result, nil
+}
+
+// waysForNode returns the current ways using the node, sorted by id.
+func (e *Editor) waysForNode(ctx context.Context, id NodeID) (Ways, error) {
+	ids := make(map[int64]struct{})
+	if br, ok := e.base.(backReferencer); ok && id > 0 {
+		for _, w := range br.WaysForNode(id) {
+			ids[int64(w.ID)] = struct{}{}
+		}
+	}
+
+	var result Ways
+	err := e.parents(ctx, TypeWay, ids, func(el Element) {
+		w := el.(*Way)
+		for _, wn := range w.Nodes {
+			if wn.ID == id {
+				result = append(result, w)
+				return
+			}
+		}
+	})
+
+	return result, err
+}
+
+// relationsForMember returns the current relations
+// with the element as a member, sorted by id.
+func (e *Editor) relationsForMember(ctx context.Context, t Type, ref int64) (Relations, error) {
+	ids := make(map[int64]struct{})
+	if br, ok := e.base.(backReferencer); ok && ref > 0 {
+		if fid, err := t.FeatureID(ref); err == nil {
+			for _, r := range br.RelationsForMember(fid) {
+				ids[int64(r.ID)] = struct{}{}
+			}
+		}
+	}
+
+	var result Relations
+	err := e.parents(ctx, TypeRelation, ids, func(el Element) {
+		if r := el.(*Relation); r.hasMember(t, ref) {
+			result = append(result, r)
+		}
+	})
+
+	return result, err
+}
+
+// parents calls the function with the current state of the elements of
+// the type with the given refs and all the edited elements of the type.
+func (e *Editor) parents(ctx context.Context, t Type, refs map[int64]struct{}, fn func(Element)) error {
+	for k, ed := range e.edits {
+		if k.Type == t && ed.current != nil {
+			refs[k.Ref] = struct{}{}
+		}
+	}
+
+	sorted := make([]int64, 0, len(refs))
+	for ref := range refs {
+		sorted = append(sorted, ref)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, ref := range sorted {
+		el, err := e.element(ctx, t, ref)
+		if err == ErrElementNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		fn(el)
+	}
+
+	return nil
+}
+
+// connectsTo returns true if the member is a way sharing an end node with the way.
+func (e *Editor) connectsTo(ctx context.Context, m Member, w *Way) bool {
+	if m.Type != TypeWay {
+		return false
+	}
+
+	el, err := e.element(ctx, TypeWay, m.Ref)
+	if err != nil {
+		return false
+	}
+
+	mw := el.(*Way)
+	if len(mw.Nodes) == 0 || len(w.Nodes) == 0 {
+		return false
+	}
+
+	for _, a := range []NodeID{mw.Nodes[0].ID, mw.Nodes[len(mw.Nodes)-1].ID} {
+		if a == w.Nodes[0].ID || a == w.Nodes[len(w.Nodes)-1].ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Relation) hasMember(t Type, ref int64) bool {
+	for _, m := range r.Members {
+		if m.Type == t && m.Ref == ref {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isClosed(w *Way) bool {
+	return len(w.Nodes) > 1 && w.Nodes[0].ID == w.Nodes[len(w.Nodes)-1].ID
+}
+
+func reverseWayNodes(nodes WayNodes) WayNodes {
+	result := make(WayNodes, len(nodes))
+	for i, wn := range nodes {
+		result[len(nodes)-1-i] = wn
+	}
+
+	return result
+}
+
+func appendElement(o *OSM, e Element) {
+	switch e := e.(type) {
+	case *Node:
+		o.Nodes = append(o.Nodes, e)
+	case *Way:
+		o.Ways = append(o.Ways, e)
+	case *Relation:
+		o.Relations = append(o.Relations, e)
+	}
+}
+
+func elementTags(e Element) *Tags {
+	switch e := e.(type) {
+	case *Node:
+		return &e.Tags
+	case *Way:
+		return &e.Tags
+	case *Relation:
+		return &e.Tags
+	}
+
+	return nil
+}
+
+func typeOrder(t Type) int {
+	switch t {
+	case TypeNode:
+		return 0
+	case TypeWay:
+		return 1
+	}
+
+	return 2
+}
+
+// cloneElement returns a copy of the element
+// with its own tags, way nodes and members.
+func cloneElement(e Element) Element {
+	switch e := e.(type) {
+	case *Node:
+		n := *e
+		n.Tags = append(Tags(nil), e.Tags...)
+		return &n
+	case *Way:
+		w := *e
+		w.Tags = append(Tags(nil), e.Tags...)
+		w.Nodes = append(WayNodes(nil), e.Nodes...)
+		return &w
+	case *Relation:
+		r := *e
+		r.Tags = append(Tags(nil), e.Tags...)
+		r.Members = append(Members(nil), e.Members...)
+		return &r
+	}
+
+	return e
+}
@@ -0,0 +1,402 @@
+package osm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+)
+
+func testEditorData() *OSM {
+	return &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1, Lat: 0, Lon: 0},
+			{ID: 2, Version: 1, Lat: 0, Lon: 1},
+			{ID: 3, Version: 1, Lat: 0, Lon: 2},
+			{ID: 4, Version: 1, Lat: 0, Lon: 3},
+			{ID: 5, Version: 2, Lat: 1, Lon: 1, Tags: Tags{{Key: "amenity", Value: "bench"}}},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 3, Nodes: WayNodes{{ID: 1}, {ID: 2}, {ID: 3}}, Tags: Tags{{Key: "highway", Value: "residential"}}},
+			{ID: 11, Version: 1, Nodes: WayNodes{{ID: 4}, {ID: 3}}, Tags: Tags{{Key: "name", Value: "Main"}}},
+		},
+		Relations: Relations{
+			{ID: 20, Version: 1, Members: Members{
+				{Type: TypeWay, Ref: 10, Role: "forward"},
+				{Type: TypeWay, Ref: 11},
+			}},
+			{ID: 21, Version: 1, Members: Members{
+				{Type: TypeWay, Ref: 11},
+				{Type: TypeWay, Ref: 10},
+			}},
+			{ID: 22, Version: 1, Members: Members{
+				{Type: TypeNode, Ref: 5},
+			}},
+		},
+	}
+}
+
+func TestEditor_MoveNodeAndTags(t *testing.T) {
+	ctx := context.Background()
+	o := testEditorData()
+	e := o.Editor()
+
+	if err := e.MoveNode(ctx, 1, geo.Point{5, 6}); err != nil {
+		t.Fatalf("move error: %v", err)
+	}
+
+	if err := e.SetTag(ctx, TypeWay, 10, "name", "Broadway"); err != nil {
+		t.Fatalf("set tag error: %v", err)
+	}
+
+	if err := e.SetTag(ctx, TypeWay, 10, "highway", "primary"); err != nil {
+		t.Fatalf("set tag error: %v", err)
+	}
+
+	if err := e.DeleteTag(ctx, TypeNode, 5, "amenity"); err != nil {
+		t.Fatalf("delete tag error: %v", err)
+	}
+
+	// no-op edit, not part of the change
+	if err := e.SetTag(ctx, TypeRelation, 20, "type", "route"); err != nil {
+		t.Fatalf("set tag error: %v", err)
+	}
+
+	if err := e.DeleteTag(ctx, TypeRelation, 20, "type"); err != nil {
+		t.Fatalf("delete tag error: %v", err)
+	}
+
+	// base data is not modified
+	if n := o.Nodes[0]; n.Lat != 0 || n.Lon != 0 {
+		t.Errorf("base node modified: %+v", n)
+	}
+
+	if l := len(o.Ways[0].Tags); l != 1 {
+		t.Errorf("base way tags modified: %v", o.Ways[0].Tags)
+	}
+
+	c := e.Change()
+	if c.Create != nil || c.Delete != nil {
+		t.Errorf("should only have modifications: %+v", c)
+	}
+
+	ids := c.Modify.ElementIDs()
+	expected := ElementIDs{NodeID(1).ElementID(1), NodeID(5).ElementID(2), WayID(10).ElementID(3)}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect modify: %v", ids)
+	}
+
+	if n := c.Modify.Nodes[0]; n.Lat != 6 || n.Lon != 5 {
+		t.Errorf("incorrect location: %+v", n)
+	}
+
+	if v := c.Modify.Ways[0].Tags.Map(); !reflect.DeepEqual(v, map[string]string{"name": "Broadway", "highway": "primary"}) {
+		t.Errorf("incorrect tags: %v", v)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("change not valid: %v", err)
+	}
+}
+
+func TestEditor_CreateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	e := testEditorData().Editor()
+
+	n1 := e.CreateNode(geo.Point{1, 2}, Tags{{Key: "a", Value: "b"}})
+	n2 := e.CreateNode(geo.Point{3, 4}, nil)
+	if n1.ID != -1 || n2.ID != -2 {
+		t.Errorf("incorrect ids: %v %v", n1.ID, n2.ID)
+	}
+
+	w, err := e.CreateWay(ctx, []NodeID{n1.ID, n2.ID, 1}, nil)
+	if err != nil {
+		t.Fatalf("create way error: %v", err)
+	}
+
+	if _, err := e.CreateWay(ctx, []NodeID{n1.ID, 100}, nil); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found error: %v", err)
+	}
+
+	r, err := e.CreateRelation(ctx, Members{{Type: TypeWay, Ref: int64(w.ID)}}, Tags{{Key: "type", Value: "route"}})
+	if err != nil {
+		t.Fatalf("create relation error: %v", err)
+	}
+
+	// referenced by the new way
+	err = e.Delete(ctx, TypeNode, 1)
+	if !errors.Is(err, ErrElementReferenced) {
+		t.Errorf("expected referenced error: %v", err)
+	}
+
+	if v := err.Error(); v != "osm: delete node/1: element is referenced: used by way/-1" {
+		t.Errorf("incorrect error: %v", v)
+	}
+
+	// referenced by relation 22
+	if err := e.Delete(ctx, TypeNode, 5); !errors.Is(err, ErrElementReferenced) {
+		t.Errorf("expected referenced error: %v", err)
+	}
+
+	if err := e.Delete(ctx, TypeRelation, int64(r.ID)); err != nil {
+		t.Errorf("delete error: %v", err)
+	}
+
+	if err := e.Delete(ctx, TypeRelation, 22); err != nil {
+		t.Errorf("delete error: %v", err)
+	}
+
+	if err := e.Delete(ctx, TypeNode, 5); err != nil {
+		t.Errorf("delete error: %v", err)
+	}
+
+	if err := e.Delete(ctx, TypeNode, 5); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found error: %v", err)
+	}
+
+	if _, err := e.Node(ctx, 5); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found error: %v", err)
+	}
+
+	c := e.Change()
+	if v := c.Create.Nodes; len(v) != 2 || v[0].ID != -1 || v[1].ID != -2 {
+		t.Errorf("incorrect created nodes: %v", v)
+	}
+
+	if v := c.Create.Ways; len(v) != 1 || v[0].ID != -1 {
+		t.Errorf("incorrect created ways: %v", v)
+	}
+
+	if v := c.Create.Relations; len(v) != 0 {
+		t.Errorf("created and deleted relation should be skipped: %v", v)
+	}
+
+	ids := c.Delete.ElementIDs()
+	expected := ElementIDs{NodeID(5).ElementID(2), RelationID(22).ElementID(1)}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect delete: %v", ids)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("change not valid: %v", err)
+	}
+}
+
+func TestEditor_WayNodes(t *testing.T) {
+	ctx := context.Background()
+	e := testEditorData().Editor()
+
+	if err := e.InsertWayNode(ctx, 10, 3, 4); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	if err := e.InsertWayNode(ctx, 10, 0, 5); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	if err := e.RemoveWayNode(ctx, 10, 2); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+
+	w, _ := e.Way(ctx, 10)
+	if ids := w.Nodes.NodeIDs(); !reflect.DeepEqual(ids, []NodeID{5, 1, 3, 4}) {
+		t.Errorf("incorrect nodes: %v", ids)
+	}
+
+	if err := e.InsertWayNode(ctx, 10, 10, 4); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected invalid edit: %v", err)
+	}
+
+	if err := e.RemoveWayNode(ctx, 10, -1); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected invalid edit: %v", err)
+	}
+
+	if err := e.InsertWayNode(ctx, 10, 0, 100); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found: %v", err)
+	}
+
+	// node 2 is no longer used and can be deleted
+	if err := e.Delete(ctx, TypeNode, 2); err != nil {
+		t.Errorf("delete error: %v", err)
+	}
+}
+
+func TestEditor_SplitWay(t *testing.T) {
+	ctx := context.Background()
+	o := testEditorData()
+	e := o.Editor()
+
+	nw, err := e.SplitWay(ctx, 10, 2)
+	if err != nil {
+		t.Fatalf("split error: %v", err)
+	}
+
+	w, _ := e.Way(ctx, 10)
+	if ids := w.Nodes.NodeIDs(); !reflect.DeepEqual(ids, []NodeID{1, 2}) {
+		t.Errorf("incorrect nodes: %v", ids)
+	}
+
+	if ids := nw.Nodes.NodeIDs(); !reflect.DeepEqual(ids, []NodeID{2, 3}) {
+		t.Errorf("incorrect new nodes: %v", ids)
+	}
+
+	if !tagsEqual(nw.Tags, w.Tags) {
+		t.Errorf("incorrect new tags: %v", nw.Tags)
+	}
+
+	// inserted after the way
+	r, _ := e.Relation(ctx, 20)
+	expected := Members{
+		{Type: TypeWay, Ref: 10, Role: "forward"},
+		{Type: TypeWay, Ref: -1, Role: "forward"},
+		{Type: TypeWay, Ref: 11},
+	}
+	if !reflect.DeepEqual(r.Members, expected) {
+		t.Errorf("incorrect members: %+v", r.Members)
+	}
+
+	// way 11 connects to the new part, so the relation is in reverse
+	r, _ = e.Relation(ctx, 21)
+	expected = Members{
+		{Type: TypeWay, Ref: 11},
+		{Type: TypeWay, Ref: -1},
+		{Type: TypeWay, Ref: 10},
+	}
+	if !reflect.DeepEqual(r.Members, expected) {
+		t.Errorf("incorrect members: %+v", r.Members)
+	}
+
+	if _, err := e.SplitWay(ctx, 10, 1); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected invalid edit for end node: %v", err)
+	}
+
+	if _, err := e.SplitWay(ctx, 11, 1); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected invalid edit for missing node: %v", err)
+	}
+
+	if _, err := e.SplitWay(ctx, 100, 1); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found: %v", err)
+	}
+
+	c := e.Change()
+	if err := c.Validate(); err != nil {
+		t.Errorf("change not valid: %v", err)
+	}
+
+	if l := len(c.Modify.Relations); l != 2 {
+		t.Errorf("incorrect modified relations: %v", l)
+	}
+}
+
+func TestEditor_MergeWays(t *testing.T) {
+	ctx := context.Background()
+	e := testEditorData().Editor()
+
+	if err := e.MergeWays(ctx, 10, 11); err != nil {
+		t.Fatalf("merge error: %v", err)
+	}
+
+	w, _ := e.Way(ctx, 10)
+	if ids := w.Nodes.NodeIDs(); !reflect.DeepEqual(ids, []NodeID{1, 2, 3, 4}) {
+		t.Errorf("incorrect nodes: %v", ids)
+	}
+
+	if v := w.Tags.Map(); !reflect.DeepEqual(v, map[string]string{"highway": "residential", "name": "Main"}) {
+		t.Errorf("incorrect tags: %v", v)
+	}
+
+	r, _ := e.Relation(ctx, 20)
+	if !reflect.DeepEqual(r.Members, Members{{Type: TypeWay, Ref: 10, Role: "forward"}}) {
+		t.Errorf("incorrect members: %+v", r.Members)
+	}
+
+	if _, err := e.Way(ctx, 11); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("way should be deleted: %v", err)
+	}
+
+	c := e.Change()
+	if ids := c.Delete.ElementIDs(); !reflect.DeepEqual(ids, ElementIDs{WayID(11).ElementID(1)}) {
+		t.Errorf("incorrect delete: %v", ids)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("change not valid: %v", err)
+	}
+}
+
+func TestEditor_MergeWaysErrors(t *testing.T) {
+	ctx := context.Background()
+	o := testEditorData()
+	o.Ways[1].Tags = Tags{{Key: "highway", Value: "primary"}}
+	o.Ways = append(o.Ways, &Way{ID: 12, Version: 1, Nodes: WayNodes{{ID: 5}, {ID: 4}}})
+	e := o.Editor()
+
+	if err := e.MergeWays(ctx, 10, 11); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected conflicting tags: %v", err)
+	}
+
+	if err := e.MergeWays(ctx, 10, 12); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected no shared node: %v", err)
+	}
+
+	if err := e.MergeWays(ctx, 10, 10); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("expected same way: %v", err)
+	}
+
+	if err := e.MergeWays(ctx, 10, 100); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found: %v", err)
+	}
+
+	// reversed
+	if err := e.MergeWays(ctx, 11, 12); err != nil {
+		t.Fatalf("merge error: %v", err)
+	}
+
+	w, _ := e.Way(ctx, 11)
+	if ids := w.Nodes.NodeIDs(); !reflect.DeepEqual(ids, []NodeID{5, 4, 3}) {
+		t.Errorf("incorrect nodes: %v", ids)
+	}
+
+	if c := e.Change(); c.Modify.Relations != nil {
+		t.Errorf("relations should not change: %v", c.Modify.Relations)
+	}
+}
+
+func TestEditor_HistoryDatasource(t *testing.T) {
+	ctx := context.Background()
+	o := testEditorData()
+	o.Nodes = append(o.Nodes, &Node{ID: 1, Version: 2, Lat: 10, Lon: 10})
+
+	// no back-references, only edited elements are checked
+	e := NewEditor(o.HistoryDatasource())
+	n, err := e.Node(ctx, 1)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+
+	if n.Version != 2 {
+		t.Errorf("should use the latest version: %v", n.Version)
+	}
+
+	// without back-references the node may still be used by a way
+	if err := e.Delete(ctx, TypeNode, 1); !errors.Is(err, ErrUnknownReferences) {
+		t.Errorf("expected unknown references: %v", err)
+	}
+
+	if c := e.Change(); c.Delete != nil {
+		t.Errorf("should not delete: %v", c.Delete)
+	}
+
+	// new elements can still be deleted
+	created := e.CreateNode(geo.Point{1, 1}, nil)
+	if err := e.Delete(ctx, TypeNode, int64(created.ID)); err != nil {
+		t.Errorf("delete error: %v", err)
+	}
+
+	e = NewEditor(nil)
+	if _, err := e.Node(ctx, 1); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected not found: %v", err)
+	}
+}
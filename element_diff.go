@@ -0,0 +1,397 @@
+package osm
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+// The operations of the way node and member edit scripts.
+const (
+	EditInsert EditOp = "insert"
+	EditDelete EditOp = "delete"
+	// EditRole is a member kept in place with a new role.
+	EditRole EditOp = "role"
+)
+
+var (
+	// ErrElementMismatch is returned by DiffElements if the
+	// elements are not versions of the same feature.
+	ErrElementMismatch = errors.New("osm: elements are not versions of the same feature")
+	// ErrNoElement is returned by Action.ElementDiff if the
+	// action does not contain a node, way or relation.
+	ErrNoElement = errors.New("osm: action does not contain an element")
+)
+
+// EditOp is a strong type for the operations of an edit script.
+type EditOp string
+
+// ElementDiff is the difference between two versions of a node, way or relation.
+type ElementDiff struct {
+	ID         FeatureID
+	OldVersion int
+	NewVersion int
+
+	TagsAdded   Tags
+	TagsRemoved Tags
+	TagsChanged []TagChange
+
+	// Moved is true if the location of the node changed.
+	// Distance is the geodesic distance in meters between the locations
+	// on the WGS84 ellipsoid, as in Way.Length.
+	Moved    bool
+	OldPoint geo.Point
+	NewPoint geo.Point
+	Distance float64
+
+	// WayNodes is the edit script turning the old way nodes into the new ones.
+	WayNodes []WayNodeEdit
+
+	// Members is the edit script turning the old relation members into the new ones.
+	Members []MemberEdit
+}
+
+// TagChange is a tag with a changed value.
+type TagChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// WayNodeEdit is a single step of a way node edit script.
+// Index is the position in the old way nodes for deletes
+// and in the new way nodes for inserts.
+type WayNodeEdit struct {
+	Op    EditOp
+	Index int
+	ID    NodeID
+}
+
+// MemberEdit is a single step of a member edit script.
+// Index is the position in the old members for deletes
+// and in the new members for inserts and role changes.
+// OldRole is only set for role changes.
+type MemberEdit struct {
+	Op      EditOp
+	Index   int
+	Member  Member
+	OldRole string
+}
+
+// DiffElements returns the difference between two versions of a node,
+// way or relation, e.g. from NodeHistory. The old element is nil for
+// created and the new element is nil for deleted features,
+// everything is then reported as added or removed.
+// Way nodes and members are matched with the longest common subsequence,
+// members by type and ref so role changes are reported as EditRole.
+// ErrElementMismatch is returned if the elements are not versions of the
+// same feature.
+func DiffElements(old, new Element) (*ElementDiff, error) {
+	if old == nil && new == nil {
+		return nil, ErrElementMismatch
+	}
+
+	if old != nil && new != nil && old.FeatureID() != new.FeatureID() {
+		return nil, ErrElementMismatch
+	}
+
+	d := &ElementDiff{}
+	if old != nil {
+		d.ID = old.FeatureID()
+		d.OldVersion = old.ElementID().Version()
+	}
+
+	if new != nil {
+		d.ID = new.FeatureID()
+		d.NewVersion = new.ElementID().Version()
+	}
+
+	d.diffTags(elementTagsOrNil(old), elementTagsOrNil(new))
+
+	switch d.ID.Type() {
+	case TypeNode:
+		on, _ := old.(*Node)
+		nn, _ := new.(*Node)
+		if on != nil {
+			d.OldPoint = on.Point()
+		}
+
+		if nn != nil {
+			d.NewPoint = nn.Point()
+		}
+
+		if on != nil && nn != nil && d.OldPoint != d.NewPoint {
+			d.Moved = true
+			d.Distance = measure.Distance(d.OldPoint, d.NewPoint)
+		}
+	case TypeWay:
+		var a, b WayNodes
+		if w, ok := old.(*Way); ok {
+			a = w.Nodes
+		}
+
+		if w, ok := new.(*Way); ok {
+			b = w.Nodes
+		}
+
+		d.diffWayNodes(a, b)
+	case TypeRelation:
+		var a, b Members
+		if r, ok := old.(*Relation); ok {
+			a = r.Members
+		}
+
+		if r, ok := new.(*Relation); ok {
+			b = r.Members
+		}
+
+		d.diffMembers(a, b)
+	}
+
+	return d, nil
+}
+
+// ElementDiff returns the difference between the old and new element of
+// the action. For creates the new element is compared to nothing and
+// for deletes the old element to nothing.
+// ErrNoElement is returned if the action has no node, way or relation.
+func (a Action) ElementDiff() (*ElementDiff, error) {
+	var old, new Element
+	switch a.Type {
+	case ActionCreate:
+		new = firstElement(a.OSM)
+		if new == nil {
+			return nil, ErrNoElement
+		}
+	case ActionDelete:
+		old = firstElement(a.Old)
+		if old == nil {
+			return nil, ErrNoElement
+		}
+	default:
+		old, new = firstElement(a.Old), firstElement(a.New)
+		if old == nil || new == nil {
+			return nil, ErrNoElement
+		}
+	}
+
+	return DiffElements(old, new)
+}
+
+// Empty returns true if the tags, location,
+// way nodes and members are the same.
+func (d *ElementDiff) Empty() bool {
+	return len(d.TagsAdded) == 0 && len(d.TagsRemoved) == 0 && len(d.TagsChanged) == 0 &&
+		!d.Moved && len(d.WayNodes) == 0 && len(d.Members) == 0
+}
+
+func (d *ElementDiff) diffTags(old, new Tags) {
+	oldMap, newMap := old.Map(), new.Map()
+	for _, t := range new {
+		v, ok := oldMap[t.Key]
+		if !ok {
+			d.TagsAdded = append(d.TagsAdded, t)
+		} else if v != t.Value {
+			d.TagsChanged = append(d.TagsChanged, TagChange{Key: t.Key, OldValue: v, NewValue: t.Value})
+		}
+	}
+
+	for _, t := range old {
+		if _, ok := newMap[t.Key]; !ok {
+			d.TagsRemoved = append(d.TagsRemoved, t)
+		}
+	}
+
+	d.TagsAdded.SortByKeyValue()
+	d.TagsRemoved.SortByKeyValue()
+	sort.Slice(d.TagsChanged, func(i, j int) bool {
+		return d.TagsChanged[i].Key < d.TagsChanged[j].Key
+	})
+}
+
+func (d *ElementDiff) diffWayNodes(old, new WayNodes) {
+	lcsEditScript(len(old), len(new),
+		func(i, j int) bool { return old[i].ID == new[j].ID },
+		func(op EditOp, i, j int) {
+			switch op {
+			case EditDelete:
+				d.WayNodes = append(d.WayNodes, WayNodeEdit{Op: op, Index: i, ID: old[i].ID})
+			case EditInsert:
+				d.WayNodes = append(d.WayNodes, WayNodeEdit{Op: op, Index: j, ID: new[j].ID})
+			}
+		},
+	)
+}
+
+func (d *ElementDiff) diffMembers(old, new Members) {
+	lcsEditScript(len(old), len(new),
+		func(i, j int) bool { return old[i].Type == new[j].Type && old[i].Ref == new[j].Ref },
+		func(op EditOp, i, j int) {
+			switch op {
+			case EditDelete:
+				d.Members = append(d.Members, MemberEdit{Op: op, Index: i, Member: old[i]})
+			case EditInsert:
+				d.Members = append(d.Members, MemberEdit{Op: op, Index: j, Member: new[j]})
+			default:
+				if old[i].Role != new[j].Role {
+					d.Members = append(d.Members, MemberEdit{Op: EditRole, Index: j, Member: new[j], OldRole: old[i].Role})
+				}
+			}
+		},
+	)
+}
+
+// lcsEditScript computes the longest common subsequence of two sequences
+// of length n and m and calls the function, in order, for every deleted,
+// inserted and kept item. For kept items the op is empty.
+// It uses the linear space variant of the Myers diff algorithm,
+// so large relations can be compared without a quadratic table.
+func lcsEditScript(n, m int, equal func(i, j int) bool, fn func(op EditOp, i, j int)) {
+	size := 2*(n+m) + 4
+	s := &editScript{
+		equal:    equal,
+		fn:       fn,
+		forward:  make([]int, size),
+		backward: make([]int, size),
+	}
+	s.compare(0, n, 0, m)
+}
+
+// editScript is the state of the Myers diff of two sequences.
+// The forward and backward buffers are reused by every
+// middle snake search since they are not needed during recursion.
+type editScript struct {
+	equal             func(i, j int) bool
+	fn                func(op EditOp, i, j int)
+	forward, backward []int
+}
+
+// compare emits the edit script of old[a0:a1] and new[b0:b1].
+func (s *editScript) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && s.equal(a0, b0) {
+		s.fn("", a0, b0)
+		a0++
+		b0++
+	}
+
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && s.equal(a1-1-suffix, b1-1-suffix) {
+		suffix++
+	}
+	a1 -= suffix
+	b1 -= suffix
+
+	switch {
+	case a0 == a1:
+		for j := b0; j < b1; j++ {
+			s.fn(EditInsert, a0, j)
+		}
+	case b0 == b1:
+		for i := a0; i < a1; i++ {
+			s.fn(EditDelete, i, b0)
+		}
+	default:
+		// without a common prefix or suffix there are at least two edits,
+		// so both halves are smaller than the whole
+		x, y, u, v := s.middleSnake(a0, a1, b0, b1)
+		s.compare(a0, x, b0, y)
+		for ; x < u; x, y = x+1, y+1 {
+			s.fn("", x, y)
+		}
+		s.compare(u, a1, v, b1)
+	}
+
+	for k := 0; k < suffix; k++ {
+		s.fn("", a1+k, b1+k)
+	}
+}
+
+// middleSnake returns the start and end of the middle snake of an
+// optimal edit path between old[a0:a1] and new[b0:b1].
+// The forward search works from the start and the backward search
+// from the end of the sequences until the paths overlap.
+func (s *editScript) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	offset := (n+m+1)/2 + 1
+	vf, vb := s.forward, s.backward
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				px = vf[offset+k+1]
+			} else {
+				px = vf[offset+k-1] + 1
+			}
+
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && s.equal(a0+px, b0+py) {
+				px++
+				py++
+			}
+			vf[offset+k] = px
+
+			if r := delta - k; delta%2 != 0 && r >= -(d-1) && r <= d-1 && px+vb[offset+r] >= n {
+				return a0 + sx, b0 + sy, a0 + px, b0 + py
+			}
+		}
+
+		// the backward search uses the reversed sequences,
+		// its diagonal k is the forward diagonal delta-k
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				px = vb[offset+k+1]
+			} else {
+				px = vb[offset+k-1] + 1
+			}
+
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && s.equal(a1-1-px, b1-1-py) {
+				px++
+				py++
+			}
+			vb[offset+k] = px
+
+			if f := delta - k; delta%2 == 0 && f >= -d && f <= d && px+vf[offset+f] >= n {
+				return a1 - px, b1 - py, a1 - sx, b1 - sy
+			}
+		}
+	}
+
+	// not reachable, the paths always overlap
+	return a0, b0, a0, b0
+}
+
+func elementTagsOrNil(e Element) Tags {
+	if e == nil {
+		return nil
+	}
+
+	if tags := elementTags(e); tags != nil {
+		return *tags
+	}
+
+	return nil
+}
+
+func firstElement(o *OSM) Element {
+	switch {
+	case o == nil:
+		return nil
+	case len(o.Nodes) > 0:
+		return o.Nodes[0]
+	case len(o.Ways) > 0:
+		return o.Ways[0]
+	case len(o.Relations) > 0:
+		return o.Relations[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package osm
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+)
+
+func TestDiffElements_Node(t *testing.T) {
+	old := &Node{ID: 1, Version: 1, Lat: 0, Lon: 0, Tags: Tags{
+		{Key: "name", Value: "a"},
+		{Key: "amenity", Value: "cafe"},
+		{Key: "old", Value: "x"},
+	}}
+	new := &Node{ID: 1, Version: 2, Lat: 0, Lon: 1, Tags: Tags{
+		{Key: "name", Value: "b"},
+		{Key: "amenity", Value: "cafe"},
+		{Key: "new", Value: "y"},
+	}}
+
+	d, err := DiffElements(old, new)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if d.ID != NodeID(1).FeatureID() || d.OldVersion != 1 || d.NewVersion != 2 {
+		t.Errorf("incorrect ids: %+v", d)
+	}
+
+	if !reflect.DeepEqual(d.TagsAdded, Tags{{Key: "new", Value: "y"}}) {
+		t.Errorf("incorrect added: %v", d.TagsAdded)
+	}
+
+	if !reflect.DeepEqual(d.TagsRemoved, Tags{{Key: "old", Value: "x"}}) {
+		t.Errorf("incorrect removed: %v", d.TagsRemoved)
+	}
+
+	if !reflect.DeepEqual(d.TagsChanged, []TagChange{{Key: "name", OldValue: "a", NewValue: "b"}}) {
+		t.Errorf("incorrect changed: %v", d.TagsChanged)
+	}
+
+	if !d.Moved || d.OldPoint != (geo.Point{0, 0}) || d.NewPoint != (geo.Point{1, 0}) {
+		t.Errorf("incorrect move: %+v", d)
+	}
+
+	// one degree at the equator
+	if math.Abs(d.Distance-111319.491) > 1e-2 {
+		t.Errorf("incorrect distance: %v", d.Distance)
+	}
+
+	// the distance is measured as the length of a way
+	moved := &Node{ID: 1, Version: 3, Lat: 51, Lon: 11}
+	d, err = DiffElements(new, moved)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	wn := WayNodes{{ID: 1, Version: 2, Lat: 0, Lon: 1}, {ID: 1, Version: 3, Lat: 51, Lon: 11}}
+	if l := wn.Length(); math.Abs(d.Distance-l) > 1e-6 {
+		t.Errorf("distance not the way length: %v != %v", d.Distance, l)
+	}
+
+	if d.Empty() {
+		t.Errorf("should not be empty")
+	}
+
+	d, _ = DiffElements(old, old)
+	if !d.Empty() {
+		t.Errorf("should be empty: %+v", d)
+	}
+}
+
+func TestDiffElements_Way(t *testing.T) {
+	old := &Way{ID: 1, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}}
+	new := &Way{ID: 1, Version: 2, Nodes: WayNodes{{ID: 1}, {ID: 5}, {ID: 3}, {ID: 4}, {ID: 6}}}
+
+	d, err := DiffElements(old, new)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	expected := []WayNodeEdit{
+		{Op: EditDelete, Index: 1, ID: 2},
+		{Op: EditInsert, Index: 1, ID: 5},
+		{Op: EditInsert, Index: 4, ID: 6},
+	}
+	if !reflect.DeepEqual(d.WayNodes, expected) {
+		t.Errorf("incorrect edits: %+v", d.WayNodes)
+	}
+
+	if d.Moved {
+		t.Errorf("ways are never moved")
+	}
+
+	// created
+	d, err = DiffElements(nil, new)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if l := len(d.WayNodes); l != 5 || d.WayNodes[4] != (WayNodeEdit{Op: EditInsert, Index: 4, ID: 6}) {
+		t.Errorf("incorrect edits: %+v", d.WayNodes)
+	}
+
+	if d.OldVersion != 0 || d.NewVersion != 2 {
+		t.Errorf("incorrect versions: %+v", d)
+	}
+}
+
+func TestDiffElements_Relation(t *testing.T) {
+	old := &Relation{ID: 1, Version: 1, Members: Members{
+		{Type: TypeWay, Ref: 1, Role: "outer"},
+		{Type: TypeWay, Ref: 2, Role: "inner"},
+		{Type: TypeNode, Ref: 2, Role: "label"},
+	}}
+	new := &Relation{ID: 1, Version: 2, Members: Members{
+		{Type: TypeWay, Ref: 1, Role: "outer"},
+		{Type: TypeWay, Ref: 2, Role: "outer"},
+		{Type: TypeWay, Ref: 3, Role: "inner"},
+	}}
+
+	d, err := DiffElements(old, new)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	expected := []MemberEdit{
+		{Op: EditRole, Index: 1, Member: new.Members[1], OldRole: "inner"},
+		{Op: EditDelete, Index: 2, Member: old.Members[2]},
+		{Op: EditInsert, Index: 2, Member: new.Members[2]},
+	}
+	if !reflect.DeepEqual(d.Members, expected) {
+		t.Errorf("incorrect edits: %+v", d.Members)
+	}
+
+	// deleted
+	d, err = DiffElements(old, nil)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if l := len(d.Members); l != 3 || d.Members[0].Op != EditDelete {
+		t.Errorf("incorrect edits: %+v", d.Members)
+	}
+}
+
+func TestDiffElements_largeRelation(t *testing.T) {
+	old := &Relation{ID: 1, Version: 1}
+	for i := 0; i < MaxRelationMembers; i++ {
+		old.Members = append(old.Members, Member{Type: TypeWay, Ref: int64(i + 1)})
+	}
+
+	new := &Relation{ID: 1, Version: 2}
+	new.Members = append(new.Members, old.Members[:100]...)
+	new.Members = append(new.Members, Member{Type: TypeNode, Ref: 1})
+	new.Members = append(new.Members, old.Members[101:20000]...)
+	new.Members = append(new.Members, old.Members[20001:]...)
+
+	d, err := DiffElements(old, new)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	expected := []MemberEdit{
+		{Op: EditDelete, Index: 100, Member: old.Members[100]},
+		{Op: EditInsert, Index: 100, Member: new.Members[100]},
+		{Op: EditDelete, Index: 20000, Member: old.Members[20000]},
+	}
+	if !reflect.DeepEqual(d.Members, expected) {
+		t.Errorf("incorrect edits: %+v", d.Members)
+	}
+}
+
+func TestLCSEditScript(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for c := 0; c < 500; c++ {
+		a := make([]int, r.Intn(20))
+		for i := range a {
+			a[i] = r.Intn(4)
+		}
+
+		b := make([]int, r.Intn(20))
+		for i := range b {
+			b[i] = r.Intn(4)
+		}
+
+		var kept, i, j int
+		lcsEditScript(len(a), len(b),
+			func(i, j int) bool { return a[i] == b[j] },
+			func(op EditOp, oi, oj int) {
+				switch op {
+				case EditDelete:
+					if oi != i {
+						t.Fatalf("%v %v: delete out of order", a, b)
+					}
+					i++
+				case EditInsert:
+					if oj != j {
+						t.Fatalf("%v %v: insert out of order", a, b)
+					}
+					j++
+				default:
+					if oi != i || oj != j || a[oi] != b[oj] {
+						t.Fatalf("%v %v: invalid keep", a, b)
+					}
+					i++
+					j++
+					kept++
+				}
+			},
+		)
+
+		if i != len(a) || j != len(b) {
+			t.Fatalf("%v %v: incomplete script", a, b)
+		}
+
+		if l := lcsLength(a, b); kept != l {
+			t.Errorf("%v %v: kept %d, expected %d", a, b, kept, l)
+		}
+	}
+}
+
+// lcsLength is the quadratic reference implementation.
+func lcsLength(a, b []int) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+func TestDiffElements_Errors(t *testing.T) {
+	if _, err := DiffElements(nil, nil); err != ErrElementMismatch {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	if _, err := DiffElements(&Node{ID: 1}, &Node{ID: 2}); err != ErrElementMismatch {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	if _, err := DiffElements(&Node{ID: 1}, &Way{ID: 1}); err != ErrElementMismatch {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestAction_ElementDiff(t *testing.T) {
+	old := &Node{ID: 1, Version: 1, Tags: Tags{{Key: "a", Value: "b"}}}
+	new := &Node{ID: 1, Version: 2, Tags: Tags{{Key: "a", Value: "c"}}}
+
+	d, err := Action{Type: ActionModify, Old: &OSM{Nodes: Nodes{old}}, New: &OSM{Nodes: Nodes{new}}}.ElementDiff()
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if len(d.TagsChanged) != 1 {
+		t.Errorf("incorrect diff: %+v", d)
+	}
+
+	d, err = Action{Type: ActionCreate, OSM: &OSM{Nodes: Nodes{old}}}.ElementDiff()
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if len(d.TagsAdded) != 1 || d.Moved {
+		t.Errorf("incorrect diff: %+v", d)
+	}
+
+	// the new element of a delete is the deleted version without tags
+	deleted := &Node{ID: 1, Version: 2}
+	d, err = Action{Type: ActionDelete, Old: &OSM{Nodes: Nodes{old}}, New: &OSM{Nodes: Nodes{deleted}}}.ElementDiff()
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+
+	if len(d.TagsRemoved) != 1 || d.NewVersion != 0 {
+		t.Errorf("incorrect diff: %+v", d)
+	}
+
+	if _, err := (Action{Type: ActionModify, Old: &OSM{}}).ElementDiff(); err != ErrNoElement {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
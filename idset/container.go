@@ -0,0 +1,244 @@
+package idset
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// arrayMaxSize is the maximum cardinality of an array container,
+	// at 4096 values the array is as large as the bitmap.
+	arrayMaxSize = 4096
+	bitmapWords  = 1 << 16 / 64
+)
+
+// container holds the low 16 bits of the values with the same high bits.
+// Sparse containers are a sorted array, dense containers a bitmap.
+type container struct {
+	array  []uint16
+	bitmap []uint64
+	n      int
+}
+
+func (c *container) contains(v uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[v>>6]&(1<<(v&63)) != 0
+	}
+
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= v })
+	return i < len(c.array) && c.array[i] == v
+}
+
+// add returns true if the value was not yet in the container.
+func (c *container) add(v uint16) bool {
+	if c.bitmap != nil {
+		w, bit := v>>6, uint64(1)<<(v&63)
+		if c.bitmap[w]&bit != 0 {
+			return false
+		}
+
+		c.bitmap[w] |= bit
+		c.n++
+		return true
+	}
+
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= v })
+	if i < len(c.array) && c.array[i] == v {
+		return false
+	}
+
+	c.array = append(c.array, 0)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = v
+	c.n++
+
+	if c.n > arrayMaxSize {
+		c.toBitmap()
+	}
+
+	return true
+}
+
+// remove returns true if the value was in the container.
+func (c *container) remove(v uint16) bool {
+	if c.bitmap != nil {
+		w, bit := v>>6, uint64(1)<<(v&63)
+		if c.bitmap[w]&bit == 0 {
+			return false
+		}
+
+		c.bitmap[w] &^= bit
+		c.n--
+		if c.n <= arrayMaxSize {
+			c.toArray()
+		}
+
+		return true
+	}
+
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= v })
+	if i == len(c.array) || c.array[i] != v {
+		return false
+	}
+
+	c.array = append(c.array[:i], c.array[i+1:]...)
+	c.n--
+	return true
+}
+
+// each calls the function for every value in increasing order.
+// Returns false if the function returned false.
+func (c *container) each(fn func(v uint16) bool) bool {
+	if c.bitmap == nil {
+		for _, v := range c.array {
+			if !fn(v) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for i, w := range c.bitmap {
+		for w != 0 {
+			t := bits.TrailingZeros64(w)
+			if !fn(uint16(i*64 + t)) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+
+	return true
+}
+
+func (c *container) clone() *container {
+	return &container{
+		array:  append([]uint16(nil), c.array...),
+		bitmap: append([]uint64(nil), c.bitmap...),
+		n:      c.n,
+	}
+}
+
+func (c *container) toBitmap() {
+	c.bitmap = make([]uint64, bitmapWords)
+	for _, v := range c.array {
+		c.bitmap[v>>6] |= 1 << (v & 63)
+	}
+	c.array = nil
+}
+
+func (c *container) toArray() {
+	array := make([]uint16, 0, c.n)
+	c.each(func(v uint16) bool {
+		array = append(array, v)
+		return true
+	})
+	c.array = array
+	c.bitmap = nil
+}
+
+// normalize picks the representation for the cardinality.
+func (c *container) normalize() {
+	if c.bitmap != nil && c.n <= arrayMaxSize {
+		c.toArray()
+	} else if c.bitmap == nil && c.n > arrayMaxSize {
+		c.toBitmap()
+	}
+}
+
+func unionContainers(a, b *container) *container {
+	if a.bitmap != nil || b.bitmap != nil || a.n+b.n > arrayMaxSize {
+		result := &container{bitmap: make([]uint64, bitmapWords)}
+		for _, c := range []*container{a, b} {
+			if c.bitmap != nil {
+				for i, w := range c.bitmap {
+					result.bitmap[i] |= w
+				}
+			} else {
+				for _, v := range c.array {
+					result.bitmap[v>>6] |= 1 << (v & 63)
+				}
+			}
+		}
+
+		for _, w := range result.bitmap {
+			result.n += bits.OnesCount64(w)
+		}
+		result.normalize()
+
+		return result
+	}
+
+	// merge of two sorted arrays
+	result := &container{array: make([]uint16, 0, a.n+b.n)}
+	i, j := 0, 0
+	for i < len(a.array) && j < len(b.array) {
+		switch {
+		case a.array[i] < b.array[j]:
+			result.array = append(result.array, a.array[i])
+			i++
+		case a.array[i] > b.array[j]:
+			result.array = append(result.array, b.array[j])
+			j++
+		default:
+			result.array = append(result.array, a.array[i])
+			i++
+			j++
+		}
+	}
+	result.array = append(result.array, a.array[i:]...)
+	result.array = append(result.array, b.array[j:]...)
+	result.n = len(result.array)
+
+	return result
+}
+
+// intersectContainers returns nil if the intersection is empty.
+func intersectContainers(a, b *container) *container {
+	var result *container
+	switch {
+	case a.bitmap != nil && b.bitmap != nil:
+		result = &container{bitmap: make([]uint64, bitmapWords)}
+		for i := range a.bitmap {
+			w := a.bitmap[i] & b.bitmap[i]
+			result.bitmap[i] = w
+			result.n += bits.OnesCount64(w)
+		}
+		result.normalize()
+	case a.bitmap != nil || b.bitmap != nil:
+		if a.bitmap == nil {
+			a, b = b, a
+		}
+
+		result = &container{}
+		for _, v := range b.array {
+			if a.contains(v) {
+				result.array = append(result.array, v)
+			}
+		}
+		result.n = len(result.array)
+	default:
+		result = &container{}
+		i, j := 0, 0
+		for i < len(a.array) && j < len(b.array) {
+			switch {
+			case a.array[i] < b.array[j]:
+				i++
+			case a.array[i] > b.array[j]:
+				j++
+			default:
+				result.array = append(result.array, a.array[i])
+				i++
+				j++
+			}
+		}
+		result.n = len(result.array)
+	}
+
+	if result.n == 0 {
+		return nil
+	}
+
+	return result
+}
@@ -0,0 +1,150 @@
+package idset
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestContainer(t *testing.T) {
+	c := &container{}
+	expected := map[uint16]bool{}
+
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 20000; i++ {
+		v := uint16(r.Intn(10000))
+		if added := c.add(v); added == expected[v] {
+			t.Fatalf("incorrect add result for %d", v)
+		}
+		expected[v] = true
+	}
+
+	if c.n != len(expected) {
+		t.Errorf("incorrect cardinality: %v != %v", c.n, len(expected))
+	}
+
+	if c.bitmap == nil {
+		t.Errorf("should be a bitmap")
+	}
+
+	for v := 0; v < 1<<16; v++ {
+		if c.contains(uint16(v)) != expected[uint16(v)] {
+			t.Fatalf("incorrect contains for %d", v)
+		}
+	}
+
+	// remove until it is an array again
+	for v := range expected {
+		if !c.remove(v) {
+			t.Fatalf("should remove %d", v)
+		}
+		delete(expected, v)
+
+		if len(expected) == 100 {
+			break
+		}
+	}
+
+	if c.bitmap != nil || c.n != 100 {
+		t.Errorf("should be an array: %v", c.n)
+	}
+
+	if c.remove(10001) {
+		t.Errorf("should not remove missing value")
+	}
+
+	values := containerValues(c)
+	if !sort.SliceIsSorted(values, func(i, j int) bool { return values[i] < values[j] }) {
+		t.Errorf("values not sorted")
+	}
+}
+
+func TestUnionIntersectContainers(t *testing.T) {
+	sparseA := newTestContainer(0, 100, 2)
+	sparseB := newTestContainer(50, 150, 5)
+	denseA := newTestContainer(0, 20000, 2)
+	denseB := newTestContainer(0, 30000, 3)
+
+	cases := []struct {
+		name string
+		a, b *container
+	}{
+		{name: "sparse", a: sparseA, b: sparseB},
+		{name: "sparse dense", a: sparseA, b: denseA},
+		{name: "dense sparse", a: denseB, b: sparseB},
+		{name: "dense", a: denseA, b: denseB},
+		{name: "large arrays", a: newTestContainer(0, 8000, 3), b: newTestContainer(1, 8000, 3)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			union := map[uint16]bool{}
+			intersection := map[uint16]bool{}
+			for _, v := range containerValues(tc.a) {
+				union[v] = true
+			}
+
+			for _, v := range containerValues(tc.b) {
+				if union[v] {
+					intersection[v] = true
+				}
+				union[v] = true
+			}
+
+			u := unionContainers(tc.a, tc.b)
+			if !reflect.DeepEqual(containerValues(u), sortedKeys(union)) || u.n != len(union) {
+				t.Errorf("incorrect union")
+			}
+
+			if (u.bitmap != nil) != (u.n > arrayMaxSize) {
+				t.Errorf("incorrect union representation")
+			}
+
+			i := intersectContainers(tc.a, tc.b)
+			if len(intersection) == 0 {
+				if i != nil {
+					t.Errorf("should be nil")
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(containerValues(i), sortedKeys(intersection)) || i.n != len(intersection) {
+				t.Errorf("incorrect intersection")
+			}
+		})
+	}
+
+	if c := intersectContainers(newTestContainer(0, 10, 2), newTestContainer(1, 10, 2)); c != nil {
+		t.Errorf("should be empty: %v", containerValues(c))
+	}
+}
+
+func newTestContainer(start, end, step int) *container {
+	c := &container{}
+	for v := start; v < end; v += step {
+		c.add(uint16(v))
+	}
+
+	return c
+}
+
+func containerValues(c *container) []uint16 {
+	var result []uint16
+	c.each(func(v uint16) bool {
+		result = append(result, v)
+		return true
+	})
+
+	return result
+}
+
+func sortedKeys(m map[uint16]bool) []uint16 {
+	result := make([]uint16, 0, len(m))
+	for v := range m {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
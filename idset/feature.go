@@ -0,0 +1,196 @@
+package idset
+
+import (
+	"encoding"
+
+	"github.com/pchchv/osm"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &FeatureSet{}
+	_ encoding.BinaryUnmarshaler = &FeatureSet{}
+)
+
+// FeatureSet is a compressed set of feature ids,
+// i.e. nodes, ways and relations.
+// The zero value is an empty set ready to use.
+// It is not safe for concurrent modification.
+type FeatureSet struct {
+	Nodes     NodeSet
+	Ways      WaySet
+	Relations RelationSet
+}
+
+// NewFeatureSet creates a new set with the feature ids.
+func NewFeatureSet(ids ...osm.FeatureID) *FeatureSet {
+	s := &FeatureSet{}
+	for _, id := range ids {
+		s.Add(id)
+	}
+
+	return s
+}
+
+// Add adds the feature id to the set. Returns false if the id was already
+// in the set or is not the id of a node, way or relation.
+func (s *FeatureSet) Add(id osm.FeatureID) bool {
+	switch id.Type() {
+	case osm.TypeNode:
+		return s.Nodes.Add(id.NodeID())
+	case osm.TypeWay:
+		return s.Ways.Add(id.WayID())
+	case osm.TypeRelation:
+		return s.Relations.Add(id.RelationID())
+	}
+
+	return false
+}
+
+// Remove removes the feature id from the set.
+// Returns false if the id was not in the set.
+func (s *FeatureSet) Remove(id osm.FeatureID) bool {
+	switch id.Type() {
+	case osm.TypeNode:
+		return s.Nodes.Remove(id.NodeID())
+	case osm.TypeWay:
+		return s.Ways.Remove(id.WayID())
+	case osm.TypeRelation:
+		return s.Relations.Remove(id.RelationID())
+	}
+
+	return false
+}
+
+// Contains returns true if the feature id is in the set.
+func (s *FeatureSet) Contains(id osm.FeatureID) bool {
+	if s == nil {
+		return false
+	}
+
+	switch id.Type() {
+	case osm.TypeNode:
+		return s.Nodes.Contains(id.NodeID())
+	case osm.TypeWay:
+		return s.Ways.Contains(id.WayID())
+	case osm.TypeRelation:
+		return s.Relations.Contains(id.RelationID())
+	}
+
+	return false
+}
+
+// Len returns the number of feature ids in the set.
+func (s *FeatureSet) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return s.Nodes.Len() + s.Ways.Len() + s.Relations.Len()
+}
+
+// Each calls the function for every feature id in increasing order,
+// i.e. nodes, then ways, then relations, sorted by id.
+// Iteration stops if the function returns false.
+// The set must not be modified during the iteration.
+func (s *FeatureSet) Each(fn func(id osm.FeatureID) bool) {
+	if s == nil {
+		return
+	}
+
+	stop := false
+	s.Nodes.Each(func(id osm.NodeID) bool {
+		stop = !fn(id.FeatureID())
+		return !stop
+	})
+
+	if !stop {
+		s.Ways.Each(func(id osm.WayID) bool {
+			stop = !fn(id.FeatureID())
+			return !stop
+		})
+	}
+
+	if !stop {
+		s.Relations.Each(func(id osm.RelationID) bool {
+			return fn(id.FeatureID())
+		})
+	}
+}
+
+// FeatureIDs returns the ids of the set in increasing order.
+func (s *FeatureSet) FeatureIDs() osm.FeatureIDs {
+	result := make(osm.FeatureIDs, 0, s.Len())
+	s.Each(func(id osm.FeatureID) bool {
+		result = append(result, id)
+		return true
+	})
+
+	return result
+}
+
+// Union returns a new set with the feature ids in either set.
+// A nil set is treated as empty.
+func (s *FeatureSet) Union(o *FeatureSet) *FeatureSet {
+	s, o = s.orEmpty(), o.orEmpty()
+	return &FeatureSet{
+		Nodes:     *s.Nodes.Union(&o.Nodes),
+		Ways:      *s.Ways.Union(&o.Ways),
+		Relations: *s.Relations.Union(&o.Relations),
+	}
+}
+
+// Intersection returns a new set with the feature ids in both sets.
+// A nil set is treated as empty.
+func (s *FeatureSet) Intersection(o *FeatureSet) *FeatureSet {
+	s, o = s.orEmpty(), o.orEmpty()
+	return &FeatureSet{
+		Nodes:     *s.Nodes.Intersection(&o.Nodes),
+		Ways:      *s.Ways.Intersection(&o.Ways),
+		Relations: *s.Relations.Intersection(&o.Relations),
+	}
+}
+
+func (s *FeatureSet) orEmpty() *FeatureSet {
+	if s == nil {
+		return &FeatureSet{}
+	}
+
+	return s
+}
+
+// MarshalBinary encodes the set into a compact binary format.
+func (s *FeatureSet) MarshalBinary() ([]byte, error) {
+	data := []byte{formatVersion}
+	data = s.Nodes.appendBinary(data)
+	data = s.Ways.appendBinary(data)
+	data = s.Relations.appendBinary(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary,
+// replacing the current contents of the set.
+func (s *FeatureSet) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return ErrInvalidData
+	}
+
+	data, err := s.Nodes.readBinary(data[1:])
+	if err != nil {
+		return err
+	}
+
+	if data, err = s.Ways.readBinary(data); err != nil {
+		return err
+	}
+
+	if data, err = s.Relations.readBinary(data); err != nil {
+		return err
+	}
+
+	if len(data) != 0 {
+		return ErrInvalidData
+	}
+
+	return nil
+}
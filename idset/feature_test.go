@@ -0,0 +1,113 @@
+package idset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestFeatureSet(t *testing.T) {
+	s := NewFeatureSet(
+		osm.RelationID(1).FeatureID(),
+		osm.WayID(2).FeatureID(),
+		osm.NodeID(3).FeatureID(),
+		osm.NodeID(1).FeatureID(),
+	)
+
+	if s.Len() != 4 {
+		t.Errorf("incorrect length: %v", s.Len())
+	}
+
+	if !s.Contains(osm.WayID(2).FeatureID()) || s.Contains(osm.WayID(1).FeatureID()) {
+		t.Errorf("incorrect contains")
+	}
+
+	if s.Add(osm.NodeID(3).FeatureID()) {
+		t.Errorf("should already be in the set")
+	}
+
+	expected := osm.FeatureIDs{
+		osm.NodeID(1).FeatureID(),
+		osm.NodeID(3).FeatureID(),
+		osm.WayID(2).FeatureID(),
+		osm.RelationID(1).FeatureID(),
+	}
+	if ids := s.FeatureIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect ids: %v", ids)
+	}
+
+	var count int
+	s.Each(func(id osm.FeatureID) bool {
+		count++
+		return id.Type() != osm.TypeWay
+	})
+
+	if count != 3 {
+		t.Errorf("should stop iteration: %v", count)
+	}
+
+	if !s.Remove(osm.NodeID(1).FeatureID()) || s.Remove(osm.NodeID(1).FeatureID()) {
+		t.Errorf("incorrect remove")
+	}
+}
+
+func TestFeatureSet_UnionIntersection(t *testing.T) {
+	a := NewFeatureSet(osm.NodeID(1).FeatureID(), osm.WayID(1).FeatureID())
+	b := NewFeatureSet(osm.WayID(1).FeatureID(), osm.RelationID(1).FeatureID())
+
+	if ids := a.Union(b).FeatureIDs(); len(ids) != 3 {
+		t.Errorf("incorrect union: %v", ids)
+	}
+
+	if ids := a.Intersection(b).FeatureIDs(); !reflect.DeepEqual(ids, osm.FeatureIDs{osm.WayID(1).FeatureID()}) {
+		t.Errorf("incorrect intersection: %v", ids)
+	}
+}
+
+func TestFeatureSet_UnionIntersection_nil(t *testing.T) {
+	var s *FeatureSet
+	o := NewFeatureSet(osm.NodeID(1).FeatureID(), osm.WayID(1).FeatureID())
+
+	if ids := s.Union(o).FeatureIDs(); !reflect.DeepEqual(ids, o.FeatureIDs()) {
+		t.Errorf("incorrect union: %v", ids)
+	}
+
+	if ids := o.Union(s).FeatureIDs(); !reflect.DeepEqual(ids, o.FeatureIDs()) {
+		t.Errorf("incorrect union: %v", ids)
+	}
+
+	if l := s.Intersection(o).Len(); l != 0 {
+		t.Errorf("incorrect intersection: %v", l)
+	}
+
+	if l := o.Intersection(s).Len(); l != 0 {
+		t.Errorf("incorrect intersection: %v", l)
+	}
+}
+
+func TestFeatureSet_MarshalBinary(t *testing.T) {
+	s := NewFeatureSet(
+		osm.NodeID(1).FeatureID(),
+		osm.WayID(100000).FeatureID(),
+		osm.RelationID(5).FeatureID(),
+	)
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	s2 := &FeatureSet{}
+	if err := s2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(s.FeatureIDs(), s2.FeatureIDs()) {
+		t.Errorf("incorrect round trip: %v", s2.FeatureIDs())
+	}
+
+	if err := s2.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidData {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
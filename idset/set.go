@@ -0,0 +1,361 @@
+package idset
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"sort"
+
+	"github.com/pchchv/osm"
+)
+
+const formatVersion = 1
+
+var (
+	_ encoding.BinaryMarshaler   = &NodeSet{}
+	_ encoding.BinaryUnmarshaler = &NodeSet{}
+
+	// ErrInvalidData is returned when unmarshalling data
+	// that was not created by MarshalBinary.
+	ErrInvalidData = errors.New("idset: invalid data")
+)
+
+type (
+	// NodeSet is a set of node ids.
+	NodeSet = Set[osm.NodeID]
+	// WaySet is a set of way ids.
+	WaySet = Set[osm.WayID]
+	// RelationSet is a set of relation ids.
+	RelationSet = Set[osm.RelationID]
+)
+
+// Set is a compressed set of ids. The ids are split into chunks of 65536
+// by their high bits, each chunk is stored as a sorted array if it is sparse
+// or as a bitmap if it is dense, like roaring bitmaps. A dense chunk uses
+// 8KB, i.e. 1 bit per possible id, a sparse chunk 2 bytes per id.
+// The zero value is an empty set ready to use.
+// It is not safe for concurrent modification.
+type Set[ID ~int64] struct {
+	keys       []uint64
+	containers []*container
+	n          int
+}
+
+// NewSet creates a new set with the ids.
+func NewSet[ID ~int64](ids ...ID) *Set[ID] {
+	s := &Set[ID]{}
+	for _, id := range ids {
+		s.Add(id)
+	}
+
+	return s
+}
+
+// split maps the id to an unsigned value, keeping the order of
+// negative ids, and returns the high and low bits.
+func split[ID ~int64](id ID) (uint64, uint16) {
+	v := uint64(id) ^ (1 << 63)
+	return v >> 16, uint16(v)
+}
+
+func join[ID ~int64](key uint64, low uint16) ID {
+	return ID((key<<16 | uint64(low)) ^ (1 << 63))
+}
+
+func (s *Set[ID]) find(key uint64) (int, bool) {
+	i := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= key })
+	return i, i < len(s.keys) && s.keys[i] == key
+}
+
+// Add adds the id to the set. Returns false if the id was already in the set.
+func (s *Set[ID]) Add(id ID) bool {
+	key, low := split(id)
+	i, ok := s.find(key)
+	if !ok {
+		s.keys = append(s.keys, 0)
+		copy(s.keys[i+1:], s.keys[i:])
+		s.keys[i] = key
+
+		s.containers = append(s.containers, nil)
+		copy(s.containers[i+1:], s.containers[i:])
+		s.containers[i] = &container{}
+	}
+
+	if s.containers[i].add(low) {
+		s.n++
+		return true
+	}
+
+	return false
+}
+
+// Remove removes the id from the set. Returns false if the id was not in the set.
+func (s *Set[ID]) Remove(id ID) bool {
+	key, low := split(id)
+	i, ok := s.find(key)
+	if !ok || !s.containers[i].remove(low) {
+		return false
+	}
+
+	s.n--
+	if s.containers[i].n == 0 {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+		s.containers = append(s.containers[:i], s.containers[i+1:]...)
+	}
+
+	return true
+}
+
+// Contains returns true if the id is in the set.
+func (s *Set[ID]) Contains(id ID) bool {
+	if s == nil {
+		return false
+	}
+
+	key, low := split(id)
+	i, ok := s.find(key)
+	return ok && s.containers[i].contains(low)
+}
+
+// Len returns the number of ids in the set.
+func (s *Set[ID]) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return s.n
+}
+
+// Each calls the function for every id in increasing order.
+// Iteration stops if the function returns false.
+// The set must not be modified during the iteration.
+func (s *Set[ID]) Each(fn func(id ID) bool) {
+	if s == nil {
+		return
+	}
+
+	for i, c := range s.containers {
+		key := s.keys[i]
+		if !c.each(func(v uint16) bool { return fn(join[ID](key, v)) }) {
+			return
+		}
+	}
+}
+
+// IDs returns the ids of the set in increasing order.
+func (s *Set[ID]) IDs() []ID {
+	result := make([]ID, 0, s.Len())
+	s.Each(func(id ID) bool {
+		result = append(result, id)
+		return true
+	})
+
+	return result
+}
+
+// Clone returns a copy of the set.
+// A nil set is cloned into an empty set.
+func (s *Set[ID]) Clone() *Set[ID] {
+	if s == nil {
+		return &Set[ID]{}
+	}
+
+	result := &Set[ID]{
+		keys:       append([]uint64(nil), s.keys...),
+		containers: make([]*container, len(s.containers)),
+		n:          s.n,
+	}
+
+	for i, c := range s.containers {
+		result.containers[i] = c.clone()
+	}
+
+	return result
+}
+
+// Union returns a new set with the ids in either set.
+// A nil set is treated as empty.
+func (s *Set[ID]) Union(o *Set[ID]) *Set[ID] {
+	if s == nil {
+		return o.Clone()
+	}
+
+	if o == nil {
+		return s.Clone()
+	}
+
+	result := &Set[ID]{}
+	i, j := 0, 0
+	for i < len(s.keys) || j < len(o.keys) {
+		var c *container
+		var key uint64
+		switch {
+		case j == len(o.keys) || (i < len(s.keys) && s.keys[i] < o.keys[j]):
+			key, c = s.keys[i], s.containers[i].clone()
+			i++
+		case i == len(s.keys) || o.keys[j] < s.keys[i]:
+			key, c = o.keys[j], o.containers[j].clone()
+			j++
+		default:
+			key, c = s.keys[i], unionContainers(s.containers[i], o.containers[j])
+			i++
+			j++
+		}
+
+		result.keys = append(result.keys, key)
+		result.containers = append(result.containers, c)
+		result.n += c.n
+	}
+
+	return result
+}
+
+// Intersection returns a new set with the ids in both sets.
+// A nil set is treated as empty.
+func (s *Set[ID]) Intersection(o *Set[ID]) *Set[ID] {
+	result := &Set[ID]{}
+	if s == nil || o == nil {
+		return result
+	}
+
+	i, j := 0, 0
+	for i < len(s.keys) && j < len(o.keys) {
+		switch {
+		case s.keys[i] < o.keys[j]:
+			i++
+		case s.keys[i] > o.keys[j]:
+			j++
+		default:
+			if c := intersectContainers(s.containers[i], o.containers[j]); c != nil {
+				result.keys = append(result.keys, s.keys[i])
+				result.containers = append(result.containers, c)
+				result.n += c.n
+			}
+			i++
+			j++
+		}
+	}
+
+	return result
+}
+
+// MarshalBinary encodes the set into a compact binary format.
+func (s *Set[ID]) MarshalBinary() ([]byte, error) {
+	return s.appendBinary([]byte{formatVersion}), nil
+}
+
+func (s *Set[ID]) appendBinary(data []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(s.keys)))
+	for i, c := range s.containers {
+		data = binary.AppendUvarint(data, s.keys[i])
+		data = binary.AppendUvarint(data, uint64(c.n))
+		if c.bitmap != nil {
+			for _, w := range c.bitmap {
+				data = binary.LittleEndian.AppendUint64(data, w)
+			}
+		} else {
+			for _, v := range c.array {
+				data = binary.LittleEndian.AppendUint16(data, v)
+			}
+		}
+	}
+
+	return data
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary,
+// replacing the current contents of the set.
+func (s *Set[ID]) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return ErrInvalidData
+	}
+
+	rest, err := s.readBinary(data[1:])
+	if err != nil {
+		return err
+	}
+
+	if len(rest) != 0 {
+		return ErrInvalidData
+	}
+
+	return nil
+}
+
+func (s *Set[ID]) readBinary(data []byte) ([]byte, error) {
+	*s = Set[ID]{}
+
+	count, data, err := readUvarint(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > uint64(len(data)) {
+		return nil, ErrInvalidData
+	}
+
+	s.keys = make([]uint64, 0, count)
+	s.containers = make([]*container, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var key, n uint64
+		if key, data, err = readUvarint(data); err != nil {
+			return nil, err
+		}
+
+		if n, data, err = readUvarint(data); err != nil {
+			return nil, err
+		}
+
+		if n == 0 || n > 1<<16 || (len(s.keys) > 0 && key <= s.keys[len(s.keys)-1]) {
+			return nil, ErrInvalidData
+		}
+
+		c := &container{n: int(n)}
+		if n > arrayMaxSize {
+			if len(data) < bitmapWords*8 {
+				return nil, ErrInvalidData
+			}
+
+			var ones int
+			c.bitmap = make([]uint64, bitmapWords)
+			for j := range c.bitmap {
+				c.bitmap[j] = binary.LittleEndian.Uint64(data[j*8:])
+				ones += bits.OnesCount64(c.bitmap[j])
+			}
+			data = data[bitmapWords*8:]
+
+			if ones != c.n {
+				return nil, ErrInvalidData
+			}
+		} else {
+			if uint64(len(data)) < n*2 {
+				return nil, ErrInvalidData
+			}
+
+			c.array = make([]uint16, n)
+			for j := range c.array {
+				c.array[j] = binary.LittleEndian.Uint16(data[j*2:])
+				if j > 0 && c.array[j] <= c.array[j-1] {
+					return nil, ErrInvalidData
+				}
+			}
+			data = data[n*2:]
+		}
+
+		s.keys = append(s.keys, key)
+		s.containers = append(s.containers, c)
+		s.n += c.n
+	}
+
+	return data, nil
+}
+
+func readUvarint(data []byte) (uint64, []byte, error) {
+	v, l := binary.Uvarint(data)
+	if l <= 0 {
+		return 0, nil, ErrInvalidData
+	}
+
+	return v, data[l:], nil
+}
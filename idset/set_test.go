@@ -0,0 +1,215 @@
+package idset
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestSet(t *testing.T) {
+	s := &NodeSet{}
+	if s.Contains(1) || s.Len() != 0 {
+		t.Errorf("zero value should be empty")
+	}
+
+	expected := map[osm.NodeID]bool{}
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 50000; i++ {
+		// dense and sparse chunks
+		id := osm.NodeID(r.Int63n(200000))
+		if i%2 == 0 {
+			id = osm.NodeID(r.Int63n(1 << 40))
+		}
+
+		if added := s.Add(id); added == expected[id] {
+			t.Fatalf("incorrect add result for %d", id)
+		}
+		expected[id] = true
+	}
+
+	if s.Len() != len(expected) {
+		t.Errorf("incorrect length: %v != %v", s.Len(), len(expected))
+	}
+
+	for id := range expected {
+		if !s.Contains(id) {
+			t.Fatalf("should contain %d", id)
+		}
+	}
+
+	if s.Contains(1<<41) || s.Contains(-1) {
+		t.Errorf("should not contain missing ids")
+	}
+
+	ids := s.IDs()
+	if !sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }) || len(ids) != len(expected) {
+		t.Errorf("ids not sorted")
+	}
+
+	for id := range expected {
+		if !s.Remove(id) {
+			t.Fatalf("should remove %d", id)
+		}
+	}
+
+	if s.Len() != 0 || len(s.keys) != 0 {
+		t.Errorf("should be empty: %v", s.Len())
+	}
+
+	if s.Remove(1) {
+		t.Errorf("should not remove missing id")
+	}
+}
+
+func TestSet_Negative(t *testing.T) {
+	s := NewSet[osm.WayID](5, -1, -70000, 0, 70000)
+	expected := []osm.WayID{-70000, -1, 0, 5, 70000}
+	if ids := s.IDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect ids: %v", ids)
+	}
+}
+
+func TestSet_Nil(t *testing.T) {
+	var s *RelationSet
+	if s.Contains(1) || s.Len() != 0 || len(s.IDs()) != 0 {
+		t.Errorf("nil set should be empty")
+	}
+
+	o := NewSet[osm.RelationID](1, 2)
+	if ids := s.Union(o).IDs(); !reflect.DeepEqual(ids, []osm.RelationID{1, 2}) {
+		t.Errorf("incorrect union: %v", ids)
+	}
+
+	if ids := o.Union(s).IDs(); !reflect.DeepEqual(ids, []osm.RelationID{1, 2}) {
+		t.Errorf("incorrect union: %v", ids)
+	}
+
+	if l := s.Intersection(o).Len(); l != 0 {
+		t.Errorf("incorrect intersection: %v", l)
+	}
+
+	if l := o.Intersection(s).Len(); l != 0 {
+		t.Errorf("incorrect intersection: %v", l)
+	}
+
+	if l := s.Union(nil).Len(); l != 0 {
+		t.Errorf("incorrect union: %v", l)
+	}
+
+	if l := s.Clone().Len(); l != 0 {
+		t.Errorf("incorrect clone: %v", l)
+	}
+}
+
+func TestSet_UnionIntersection(t *testing.T) {
+	a := &NodeSet{}
+	b := &NodeSet{}
+	for i := osm.NodeID(0); i < 100000; i += 2 {
+		a.Add(i)
+	}
+
+	for i := osm.NodeID(0); i < 300000; i += 3 {
+		b.Add(i)
+	}
+	b.Add(1 << 33)
+
+	u := a.Union(b)
+	in := a.Intersection(b)
+	for i := osm.NodeID(0); i < 300000; i++ {
+		inA, inB := i%2 == 0 && i < 100000, i%3 == 0
+		if u.Contains(i) != (inA || inB) {
+			t.Fatalf("incorrect union for %d", i)
+		}
+
+		if in.Contains(i) != (inA && inB) {
+			t.Fatalf("incorrect intersection for %d", i)
+		}
+	}
+
+	if !u.Contains(1<<33) || in.Contains(1<<33) {
+		t.Errorf("incorrect sparse chunk")
+	}
+
+	if u.Len() != len(u.IDs()) || in.Len() != len(in.IDs()) {
+		t.Errorf("incorrect lengths")
+	}
+
+	// inputs are not modified
+	if a.Len() != 50000 || b.Len() != 100001 {
+		t.Errorf("inputs modified: %v %v", a.Len(), b.Len())
+	}
+
+	c := a.Clone()
+	c.Add(1)
+	if a.Contains(1) {
+		t.Errorf("clone should be a copy")
+	}
+}
+
+func TestSet_Each(t *testing.T) {
+	s := NewSet[osm.NodeID](1, 2, 3, 100000)
+
+	var ids []osm.NodeID
+	s.Each(func(id osm.NodeID) bool {
+		ids = append(ids, id)
+		return id < 2
+	})
+
+	if !reflect.DeepEqual(ids, []osm.NodeID{1, 2}) {
+		t.Errorf("should stop iteration: %v", ids)
+	}
+}
+
+func TestSet_MarshalBinary(t *testing.T) {
+	s := &NodeSet{}
+	for i := osm.NodeID(0); i < 20000; i++ {
+		s.Add(i)
+	}
+	s.Add(1 << 35)
+	s.Add(-5)
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	// dense chunk is stored as a bitmap
+	if l := len(data); l > 9000 {
+		t.Errorf("data too large: %v", l)
+	}
+
+	s2 := NewSet[osm.NodeID](7)
+	if err := s2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(s.IDs(), s2.IDs()) || s2.Len() != s.Len() {
+		t.Errorf("incorrect round trip")
+	}
+
+	for _, bad := range [][]byte{nil, {2}, data[:len(data)-1], append(append([]byte{}, data...), 0)} {
+		if err := s2.UnmarshalBinary(bad); err != ErrInvalidData {
+			t.Errorf("incorrect error: %v", err)
+		}
+	}
+}
+
+func BenchmarkSet_Add(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	ids := make([]osm.NodeID, 1000000)
+	for i := range ids {
+		ids[i] = osm.NodeID(r.Int63n(10000000000))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := &NodeSet{}
+		for _, id := range ids {
+			s.Add(id)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package measure
+
+import (
+	"math"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/geometries"
+)
+
+// The WGS84 ellipsoid.
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+var (
+	// wgs84E is the eccentricity of the ellipsoid.
+	wgs84E = math.Sqrt(wgs84F * (2 - wgs84F))
+	// authalicQP is q of the pole, see authalicQ.
+	authalicQP = authalicQ(1)
+	// authalicR is the radius of the sphere with the area of the ellipsoid.
+	authalicR = wgs84A * math.Sqrt(authalicQP/2)
+)
+
+// Distance returns the geodesic distance in meters between the points
+// on the WGS84 ellipsoid computed with the inverse formula of Vincenty.
+// The formula does not converge for nearly antipodal points,
+// the haversine distance is returned for them.
+func Distance(a, b geo.Point) float64 {
+	l := deg2rad(b[0] - a[0])
+	u1 := math.Atan((1 - wgs84F) * math.Tan(deg2rad(a[1])))
+	u2 := math.Atan((1 - wgs84F) * math.Tan(deg2rad(b[1])))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+
+	lambda := l
+	for i := 0; i < 200; i++ {
+		sinL, cosL := math.Sincos(lambda)
+		sinS := math.Hypot(cosU2*sinL, cosU1*sinU2-sinU1*cosU2*cosL)
+		if sinS == 0 {
+			// coincident points
+			return 0
+		}
+
+		cosS := sinU1*sinU2 + cosU1*cosU2*cosL
+		sigma := math.Atan2(sinS, cosS)
+		sinA := cosU1 * cosU2 * sinL / sinS
+		cos2A := 1 - sinA*sinA
+
+		// points on the equator have no cos2Sm
+		var cos2Sm float64
+		if cos2A != 0 {
+			cos2Sm = cosS - 2*sinU1*sinU2/cos2A
+		}
+
+		c := wgs84F / 16 * cos2A * (4 + wgs84F*(4-3*cos2A))
+		prev := lambda
+		lambda = l + (1-c)*wgs84F*sinA*(sigma+c*sinS*(cos2Sm+c*cosS*(-1+2*cos2Sm*cos2Sm)))
+		if math.Abs(lambda-prev) > 1e-12 {
+			continue
+		}
+
+		u := cos2A * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+		ca := 1 + u/16384*(4096+u*(-768+u*(320-175*u)))
+		cb := u / 1024 * (256 + u*(-128+u*(74-47*u)))
+		ds := cb * sinS * (cos2Sm + cb/4*(cosS*(-1+2*cos2Sm*cos2Sm)-
+			cb/6*cos2Sm*(-3+4*sinS*sinS)*(-3+4*cos2Sm*cos2Sm)))
+
+		return wgs84B * ca * (sigma - ds)
+	}
+
+	return geometries.DistanceHaversine(a, b)
+}
+
+// Length returns the geodesic length in meters
+// of the line string, see Distance.
+func Length(ls geo.LineString) float64 {
+	var length float64
+	for i := 1; i < len(ls); i++ {
+		length += Distance(ls[i-1], ls[i])
+	}
+
+	return length
+}
+
+// Area returns the area in square meters on the WGS84 ellipsoid
+// of the polygons with the inner rings subtracted.
+// The area is computed on the authalic sphere, the sphere with the
+// area of the ellipsoid, using the authalic latitudes of the points.
+// The rings must be closed and must not contain a pole.
+func Area(mp geo.MultiPolygon) float64 {
+	var area float64
+	for _, p := range mp {
+		for i, r := range p {
+			if i == 0 {
+				area += ringArea(r)
+			} else {
+				area -= ringArea(r)
+			}
+		}
+	}
+
+	return area
+}
+
+// ringArea returns the area of the ring, the sum of the spherical
+// excess of the triangles of every edge with the equator.
+func ringArea(r geo.Ring) float64 {
+	var excess float64
+	for i := 1; i < len(r); i++ {
+		l := deg2rad(r[i][0] - r[i-1][0])
+		// the shorter way around the antimeridian
+		if l > math.Pi {
+			l -= 2 * math.Pi
+		} else if l < -math.Pi {
+			l += 2 * math.Pi
+		}
+
+		t1 := math.Tan(authalicLatitude(r[i-1][1]) / 2)
+		t2 := math.Tan(authalicLatitude(r[i][1]) / 2)
+		excess += 2 * math.Atan2(math.Tan(l/2)*(t1+t2), 1+t1*t2)
+	}
+
+	return math.Abs(excess) * authalicR * authalicR
+}
+
+// authalicLatitude returns the latitude in radians on the authalic
+// sphere of the latitude in degrees.
+func authalicLatitude(lat float64) float64 {
+	q := authalicQ(math.Sin(deg2rad(lat)))
+	return math.Asin(math.Max(-1, math.Min(1, q/authalicQP)))
+}
+
+// authalicQ returns q of the sine of the latitude, the area
+// between the equator and the latitude is proportional to it.
+func authalicQ(sin float64) float64 {
+	es := wgs84E * sin
+	return (1 - wgs84E*wgs84E) * (sin/(1-es*es) - math.Log((1-es)/(1+es))/(2*wgs84E))
+}
+
+func deg2rad(d float64) float64 {
+	return d * math.Pi / 180
+}
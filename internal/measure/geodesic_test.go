@@ -0,0 +1,99 @@
+package measure
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pchchv/geo"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		name   string
+		a, b   geo.Point
+		result float64
+	}{
+		{
+			name:   "same point",
+			a:      geo.Point{1, 2},
+			b:      geo.Point{1, 2},
+			result: 0,
+		},
+		{
+			name:   "along the equator",
+			a:      geo.Point{0, 0},
+			b:      geo.Point{1, 0},
+			result: 111319.491,
+		},
+		{
+			name:   "along a meridian",
+			a:      geo.Point{0, 0},
+			b:      geo.Point{0, 1},
+			result: 110574.389,
+		},
+		{
+			// the example of Vincenty's paper
+			name:   "flinders peak to buninyong",
+			a:      geo.Point{144.42486789, -37.95103342},
+			b:      geo.Point{143.92649554, -37.65282114},
+			result: 54972.271,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if d := Distance(tc.a, tc.b); math.Abs(d-tc.result) > 1e-3 {
+				t.Errorf("incorrect distance: %v != %v", d, tc.result)
+			}
+
+			if d := Distance(tc.b, tc.a); math.Abs(d-tc.result) > 1e-3 {
+				t.Errorf("incorrect reverse distance: %v != %v", d, tc.result)
+			}
+		})
+	}
+
+	if l := Length(geo.LineString{{0, 0}, {1, 0}, {1, 0}, {2, 0}}); math.Abs(l-2*111319.491) > 1e-3 {
+		t.Errorf("incorrect length: %v", l)
+	}
+}
+
+func TestArea(t *testing.T) {
+	// the geodesic area of the square is 12308778361 m²
+	square := geo.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	hole := geo.Ring{{0, 0}, {0, 0.5}, {0.5, 0.5}, {0.5, 0}, {0, 0}}
+
+	cases := []struct {
+		name   string
+		mp     geo.MultiPolygon
+		result float64
+	}{
+		{
+			name:   "square",
+			mp:     geo.MultiPolygon{{square}},
+			result: 12308778361,
+		},
+		{
+			name:   "clockwise",
+			mp:     geo.MultiPolygon{{geo.Ring{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}}},
+			result: 12308778361,
+		},
+		{
+			name:   "with hole",
+			mp:     geo.MultiPolygon{{square, hole}},
+			result: 9231527000,
+		},
+		{
+			name:   "across the antimeridian",
+			mp:     geo.MultiPolygon{{geo.Ring{{179.5, 0}, {-179.5, 0}, {-179.5, 1}, {179.5, 1}, {179.5, 0}}}},
+			result: 12308778361,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if a := Area(tc.mp); math.Abs(a-tc.result) > 1e4 {
+				t.Errorf("incorrect area: %v != %v", a, tc.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package measure implements the geodesic measurements and
+// geometry helpers shared by the measurement methods of the osm
+// and mputil packages and the geometry checks of the osmqa
+// and coastline packages.
+package measure
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/geometries"
+	"github.com/pchchv/geo/planar"
+)
+
+// LineCentroid returns the centroid of the line string,
+// i.e. the average of the segment midpoints weighted by their length
+// using the equirectangular approximation of the distance.
+func LineCentroid(ls geo.LineString) geo.Point {
+	if len(ls) == 0 {
+		return geo.Point{}
+	}
+
+	var dist float64
+	point := geo.Point{}
+	for i := 0; i < len(ls)-1; i++ {
+		d := geometries.Distance(ls[i], ls[i+1])
+		point[0] += (ls[i][0] + ls[i+1][0]) / 2.0 * d
+		point[1] += (ls[i][1] + ls[i+1][1]) / 2.0 * d
+		dist += d
+	}
+
+	if dist == 0 {
+		return ls[0]
+	}
+
+	point[0] /= dist
+	point[1] /= dist
+
+	return point
+}
+
+// LinePointOnSurface returns the point of
+// the line string closest to the centroid.
+func LinePointOnSurface(ls geo.LineString) geo.Point {
+	if len(ls) == 0 {
+		return geo.Point{}
+	}
+
+	var index int
+	centroid := LineCentroid(ls)
+	min := math.MaxFloat64
+	for i, p := range ls {
+		if d := geometries.Distance(centroid, p); d < min {
+			index, min = i, d
+		}
+	}
+
+	return ls[index]
+}
+
+// Centroid returns the area weighted centroid of the polygons.
+// The centroid may be outside of the polygons, e.g. for a U shape.
+func Centroid(mp geo.MultiPolygon) geo.Point {
+	c, area := planar.CentroidArea(mp)
+	if area == 0 && len(mp) > 0 && len(mp[0]) > 0 {
+		return LineCentroid(geo.LineString(mp[0][0]))
+	}
+
+	return c
+}
+
+// PointOnSurface returns a point inside the largest polygon.
+// The polygon is intersected with a horizontal line through the middle
+// of its bound and the point is the center of the widest inside interval,
+// the rings must be closed.
+func PointOnSurface(mp geo.MultiPolygon) geo.Point {
+	var largest geo.Polygon
+	var max float64
+	for _, p := range mp {
+		if len(p) == 0 || len(p[0]) == 0 {
+			continue
+		}
+
+		if a := planar.Area(p); largest == nil || a > max {
+			largest, max = p, a
+		}
+	}
+
+	if largest == nil {
+		return geo.Point{}
+	}
+
+	if max == 0 {
+		return LinePointOnSurface(geo.LineString(largest[0]))
+	}
+
+	b := largest[0].Bound()
+	y := (b.Min[1] + b.Max[1]) / 2
+
+	var xs []float64
+	for _, r := range largest {
+		for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+			pi, pj := r[i], r[j]
+			if (pi[1] > y) != (pj[1] > y) {
+				xs = append(xs, pi[0]+(y-pi[1])*(pj[0]-pi[0])/(pj[1]-pi[1]))
+			}
+		}
+	}
+
+	sort.Float64s(xs)
+
+	result := geo.Point{}
+	width := -1.0
+	for i := 0; i+1 < len(xs); i += 2 {
+		if w := xs[i+1] - xs[i]; w > width {
+			width = w
+			result = geo.Point{(xs[i] + xs[i+1]) / 2, y}
+		}
+	}
+
+	if width < 0 {
+		return largest[0][0]
+	}
+
+	return result
+}
+
+// Crossing returns the point where the segments ab and cd cross.
+// Segments touching, e.g. at a shared end point, or
+// overlapping do not cross.
+func Crossing(a, b, c, d geo.Point) (geo.Point, bool) {
+	d1, d2 := side(c, d, a), side(c, d, b)
+	d3, d4 := side(a, b, c), side(a, b, d)
+	if d1*d2 >= 0 || d3*d4 >= 0 {
+		return geo.Point{}, false
+	}
+
+	// the fraction along ab from the areas of the triangles with cd
+	a1, a2 := cross(c, d, a), cross(c, d, b)
+	f := a1 / (a1 - a2)
+	return geo.Point{a[0] + f*(b[0]-a[0]), a[1] + f*(b[1]-a[1])}, true
+}
+
+// side returns 1 if the point is left of the line through a and b,
+// -1 if right and 0 if on it.
+func side(a, b, p geo.Point) float64 {
+	v := cross(a, b, p)
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+
+	return 0
+}
+
+func cross(a, b, p geo.Point) float64 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+}
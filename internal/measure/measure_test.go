@@ -0,0 +1,149 @@
+package measure
+
+import (
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/planar"
+)
+
+func TestLineCentroid(t *testing.T) {
+	cases := []struct {
+		name   string
+		line   geo.LineString
+		result geo.Point
+	}{
+		{
+			name:   "empty",
+			line:   nil,
+			result: geo.Point{},
+		},
+		{
+			name:   "single point",
+			line:   geo.LineString{{1, 2}},
+			result: geo.Point{1, 2},
+		},
+		{
+			name:   "segment",
+			line:   geo.LineString{{0, 0}, {2, 0}},
+			result: geo.Point{1, 0},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if c := LineCentroid(tc.line); !c.Equal(tc.result) {
+				t.Errorf("incorrect centroid: %v != %v", c, tc.result)
+			}
+		})
+	}
+}
+
+func TestPointOnSurface(t *testing.T) {
+	cases := []struct {
+		name string
+		mp   geo.MultiPolygon
+	}{
+		{
+			name: "square",
+			mp:   geo.MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}},
+		},
+		{
+			name: "u shape",
+			mp: geo.MultiPolygon{{{
+				{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}, {0, 0},
+			}}},
+		},
+		{
+			name: "hole in the middle",
+			mp: geo.MultiPolygon{{
+				{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+				{{1, 1}, {1, 3}, {2.5, 3}, {2.5, 1}, {1, 1}},
+			}},
+		},
+		{
+			name: "largest polygon",
+			mp: geo.MultiPolygon{
+				{{{10, 10}, {11, 10}, {11, 11}, {10, 10}}},
+				{{{0, 0}, {5, 0}, {5, 5}, {0, 5}, {0, 0}}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := PointOnSurface(tc.mp)
+			if !planar.MultiPolygonContains(tc.mp, p) {
+				t.Errorf("point not inside: %v", p)
+			}
+		})
+	}
+
+	// centroid of the u shape is outside
+	u := cases[1].mp
+	if c := Centroid(u); planar.MultiPolygonContains(u, c) {
+		t.Errorf("centroid expected outside: %v", c)
+	}
+
+	// largest polygon is used
+	if p := PointOnSurface(cases[3].mp); p[0] > 5 {
+		t.Errorf("should be in largest polygon: %v", p)
+	}
+}
+
+func TestCrossing(t *testing.T) {
+	cases := []struct {
+		name   string
+		a, b   geo.Point
+		c, d   geo.Point
+		result geo.Point
+		ok     bool
+	}{
+		{
+			name:   "cross",
+			a:      geo.Point{0, 0},
+			b:      geo.Point{2, 2},
+			c:      geo.Point{0, 2},
+			d:      geo.Point{2, 0},
+			result: geo.Point{1, 1},
+			ok:     true,
+		},
+		{
+			name: "shared end point",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{1, 1},
+			c:    geo.Point{1, 1},
+			d:    geo.Point{2, 0},
+		},
+		{
+			name: "end point on segment",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{2, 0},
+			c:    geo.Point{1, 0},
+			d:    geo.Point{1, 1},
+		},
+		{
+			name: "overlap",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{2, 0},
+			c:    geo.Point{1, 0},
+			d:    geo.Point{3, 0},
+		},
+		{
+			name: "apart",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{1, 0},
+			c:    geo.Point{2, -1},
+			d:    geo.Point{2, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := Crossing(tc.a, tc.b, tc.c, tc.d)
+			if ok != tc.ok || !p.Equal(tc.result) {
+				t.Errorf("incorrect crossing: %v %v", p, ok)
+			}
+		})
+	}
+}
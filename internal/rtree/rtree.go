@@ -0,0 +1,317 @@
+package rtree
+
+import (
+	"math"
+
+	"github.com/pchchv/geo"
+)
+
+const (
+	defaultMaxEntries = 16
+	defaultMinEntries = 6
+)
+
+// Tree is a dynamic R-tree indexing int64 values by their bound.
+// Points are indexed as zero area bounds.
+// Nodes are chosen and split based on the bound margin,
+// i.e. half the perimeter, so degenerate bounds are handled well.
+// It is not safe for concurrent modification.
+type Tree struct {
+	root *node
+	size int
+}
+
+type entry struct {
+	bound geo.Bound
+	child *node
+	value int64
+}
+
+type node struct {
+	leaf    bool
+	entries []entry
+}
+
+// New creates a new empty tree.
+func New() *Tree {
+	return &Tree{}
+}
+
+// Len returns the number of values in the tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Bound returns the bound of all the values in the tree.
+// Returns an empty bound if the tree is empty.
+func (t *Tree) Bound() geo.Bound {
+	if t.root == nil || len(t.root.entries) == 0 {
+		return geo.Bound{}
+	}
+
+	return t.root.bound()
+}
+
+// Insert adds the value with the given bound to the tree.
+// The same value can be inserted multiple times.
+func (t *Tree) Insert(b geo.Bound, v int64) {
+	if t.root == nil {
+		t.root = &node{leaf: true}
+	}
+
+	if sibling := t.insert(t.root, entry{bound: b, value: v}); sibling != nil {
+		old := t.root
+		t.root = &node{
+			entries: []entry{
+				{bound: old.bound(), child: old},
+				{bound: sibling.bound(), child: sibling},
+			},
+		}
+	}
+
+	t.size++
+}
+
+// insert adds the leaf entry to the subtree.
+// Returns the new sibling node if the node was split.
+func (t *Tree) insert(n *node, e entry) *node {
+	if n.leaf {
+		n.entries = append(n.entries, e)
+	} else {
+		i := chooseSubtree(n, e.bound)
+		child := &n.entries[i]
+		sibling := t.insert(child.child, e)
+		child.bound = child.child.bound()
+		if sibling != nil {
+			n.entries = append(n.entries, entry{bound: sibling.bound(), child: sibling})
+		}
+	}
+
+	if len(n.entries) > defaultMaxEntries {
+		return split(n)
+	}
+
+	return nil
+}
+
+// Search calls the function for every value
+// whose bound intersects the given bound.
+// The search stops if the function returns false.
+func (t *Tree) Search(b geo.Bound, fn func(v int64) bool) {
+	if t.root == nil {
+		return
+	}
+
+	search(t.root, b, fn)
+}
+
+func search(n *node, b geo.Bound, fn func(v int64) bool) bool {
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !intersects(e.bound, b) {
+			continue
+		}
+
+		if n.leaf {
+			if !fn(e.value) {
+				return false
+			}
+		} else if !search(e.child, b, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Delete removes the value, that was inserted with the given bound, from the tree.
+// Returns false if the value was not found.
+func (t *Tree) Delete(b geo.Bound, v int64) bool {
+	if t.root == nil {
+		return false
+	}
+
+	var orphans []entry
+	if !remove(t.root, b, v, &orphans) {
+		return false
+	}
+
+	t.size--
+	if !t.root.leaf && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+
+	if len(t.root.entries) == 0 {
+		t.root = nil
+	}
+
+	// values from underfull nodes are inserted again
+	t.size -= len(orphans)
+	for _, e := range orphans {
+		t.Insert(e.bound, e.value)
+	}
+
+	return true
+}
+
+func remove(n *node, b geo.Bound, v int64, orphans *[]entry) bool {
+	if n.leaf {
+		for i, e := range n.entries {
+			if e.value == v && e.bound == b {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !contains(e.bound, b) || !remove(e.child, b, v, orphans) {
+			continue
+		}
+
+		if len(e.child.entries) < defaultMinEntries {
+			*orphans = appendLeafEntries(*orphans, e.child)
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			e.bound = e.child.bound()
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func appendLeafEntries(result []entry, n *node) []entry {
+	if n.leaf {
+		return append(result, n.entries...)
+	}
+
+	for _, e := range n.entries {
+		result = appendLeafEntries(result, e.child)
+	}
+
+	return result
+}
+
+func (n *node) bound() geo.Bound {
+	b := n.entries[0].bound
+	for _, e := range n.entries[1:] {
+		b = union(b, e.bound)
+	}
+
+	return b
+}
+
+// chooseSubtree returns the index of the entry needing the
+// least margin enlargement to include the bound.
+func chooseSubtree(n *node, b geo.Bound) int {
+	best := 0
+	bestEnlargement := math.Inf(1)
+	bestMargin := math.Inf(1)
+	for i, e := range n.entries {
+		m := margin(e.bound)
+		enlargement := margin(union(e.bound, b)) - m
+		if enlargement < bestEnlargement || (enlargement == bestEnlargement && m < bestMargin) {
+			best, bestEnlargement, bestMargin = i, enlargement, m
+		}
+	}
+
+	return best
+}
+
+// split divides the entries of the node into two groups
+// using the quadratic split algorithm.
+// The node keeps the first group, the new sibling node is returned.
+func split(n *node) *node {
+	entries := n.entries
+
+	// pick the two entries that would waste the most if grouped together
+	var s1, s2 int
+	worst := math.Inf(-1)
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			d := margin(union(entries[i].bound, entries[j].bound)) - margin(entries[i].bound) - margin(entries[j].bound)
+			if d > worst {
+				s1, s2, worst = i, j, d
+			}
+		}
+	}
+
+	g1 := []entry{entries[s1]}
+	g2 := []entry{entries[s2]}
+	b1, b2 := entries[s1].bound, entries[s2].bound
+
+	remaining := make([]entry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != s1 && i != s2 {
+			remaining = append(remaining, e)
+		}
+	}
+
+	for len(remaining) > 0 {
+		// if one group needs all the rest to have the minimum entries
+		if len(g1)+len(remaining) <= defaultMinEntries {
+			g1 = append(g1, remaining...)
+			break
+		}
+
+		if len(g2)+len(remaining) <= defaultMinEntries {
+			g2 = append(g2, remaining...)
+			break
+		}
+
+		// pick the entry with the largest preference for one group
+		next := 0
+		maxDiff := math.Inf(-1)
+		for i, e := range remaining {
+			d1 := margin(union(b1, e.bound)) - margin(b1)
+			d2 := margin(union(b2, e.bound)) - margin(b2)
+			if d := math.Abs(d1 - d2); d > maxDiff {
+				next, maxDiff = i, d
+			}
+		}
+
+		e := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+
+		d1 := margin(union(b1, e.bound)) - margin(b1)
+		d2 := margin(union(b2, e.bound)) - margin(b2)
+		if d1 < d2 || (d1 == d2 && len(g1) <= len(g2)) {
+			g1 = append(g1, e)
+			b1 = union(b1, e.bound)
+		} else {
+			g2 = append(g2, e)
+			b2 = union(b2, e.bound)
+		}
+	}
+
+	n.entries = g1
+	return &node{leaf: n.leaf, entries: g2}
+}
+
+// union returns the bound containing both bounds.
+// Unlike geo.Bound.Union this handles zero area bounds, e.g. points.
+func union(a, b geo.Bound) geo.Bound {
+	return geo.Bound{
+		Min: geo.Point{math.Min(a.Min[0], b.Min[0]), math.Min(a.Min[1], b.Min[1])},
+		Max: geo.Point{math.Max(a.Max[0], b.Max[0]), math.Max(a.Max[1], b.Max[1])},
+	}
+}
+
+func margin(b geo.Bound) float64 {
+	return (b.Max[0] - b.Min[0]) + (b.Max[1] - b.Min[1])
+}
+
+func intersects(a, b geo.Bound) bool {
+	return a.Min[0] <= b.Max[0] && a.Max[0] >= b.Min[0] &&
+		a.Min[1] <= b.Max[1] && a.Max[1] >= b.Min[1]
+}
+
+func contains(outer, inner geo.Bound) bool {
+	return outer.Min[0] <= inner.Min[0] && outer.Max[0] >= inner.Max[0] &&
+		outer.Min[1] <= inner.Min[1] && outer.Max[1] >= inner.Max[1]
+}
@@ -0,0 +1,109 @@
+package rtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/pchchv/geo"
+)
+
+func TestTree(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	tree := New()
+	bounds := make(map[int64]geo.Bound)
+	for i := int64(0); i < 2000; i++ {
+		p := geo.Point{r.Float64() * 100, r.Float64() * 100}
+		b := geo.Bound{Min: p, Max: p}
+		if i%3 == 0 {
+			b.Max = geo.Point{p[0] + r.Float64()*5, p[1] + r.Float64()*5}
+		}
+
+		bounds[i] = b
+		tree.Insert(b, i)
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+		if tree.Len() != len(bounds) {
+			t.Fatalf("incorrect length: %d != %d", tree.Len(), len(bounds))
+		}
+
+		for i := 0; i < 50; i++ {
+			p := geo.Point{r.Float64() * 100, r.Float64() * 100}
+			q := geo.Bound{Min: p, Max: geo.Point{p[0] + r.Float64()*20, p[1] + r.Float64()*20}}
+
+			var expected []int64
+			for v, b := range bounds {
+				if intersects(b, q) {
+					expected = append(expected, v)
+				}
+			}
+
+			var found []int64
+			tree.Search(q, func(v int64) bool {
+				found = append(found, v)
+				return true
+			})
+
+			sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+			sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+			if len(found) != len(expected) {
+				t.Fatalf("incorrect results: %d != %d", len(found), len(expected))
+			}
+
+			for i := range found {
+				if found[i] != expected[i] {
+					t.Fatalf("incorrect result: %v != %v", found[i], expected[i])
+				}
+			}
+		}
+	}
+
+	check(t)
+
+	for v, b := range bounds {
+		if v%2 == 0 {
+			if !tree.Delete(b, v) {
+				t.Fatalf("value %d not deleted", v)
+			}
+			delete(bounds, v)
+		}
+	}
+
+	check(t)
+
+	if tree.Delete(geo.Bound{}, 1_000_000) {
+		t.Errorf("should not delete missing value")
+	}
+
+	for v, b := range bounds {
+		tree.Delete(b, v)
+	}
+
+	if tree.Len() != 0 {
+		t.Errorf("tree should be empty: %d", tree.Len())
+	}
+
+	if b := tree.Bound(); b != (geo.Bound{}) {
+		t.Errorf("empty tree should have empty bound: %v", b)
+	}
+}
+
+func TestTree_Search_stop(t *testing.T) {
+	tree := New()
+	for i := int64(0); i < 100; i++ {
+		p := geo.Point{float64(i), float64(i)}
+		tree.Insert(geo.Bound{Min: p, Max: p}, i)
+	}
+
+	var count int
+	tree.Search(tree.Bound(), func(v int64) bool {
+		count++
+		return count < 10
+	})
+
+	if count != 10 {
+		t.Errorf("should stop search: %d", count)
+	}
+}
@@ -0,0 +1,103 @@
+package osm
+
+import (
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+// Length returns the geodesic length in meters on the WGS84 ellipsoid
+// of the annotated way nodes. Way nodes without a location are skipped.
+func (wn WayNodes) Length() float64 {
+	var length float64
+	var prev geo.Point
+	var started bool
+	for _, n := range wn {
+		if n.Version == 0 && n.Lon == 0 && n.Lat == 0 {
+			continue
+		}
+
+		p := n.Point()
+		if started {
+			length += measure.Distance(prev, p)
+		}
+		prev, started = p, true
+	}
+
+	return length
+}
+
+// Length returns the geodesic length in meters of the way, see WayNodes.Length.
+// The way nodes must be annotated with their location.
+func (w *Way) Length() float64 {
+	return w.Nodes.Length()
+}
+
+// Area returns the area in square meters on the WGS84 ellipsoid of the way
+// if it is closed, zero otherwise. The way is not checked to be
+// a polygon, see Way.Polygon, and must be annotated.
+func (w *Way) Area() float64 {
+	ring, ok := w.ring()
+	if !ok {
+		return 0
+	}
+
+	return measure.Area(geo.MultiPolygon{{ring}})
+}
+
+// Centroid returns the area weighted centroid of a closed way,
+// or the length weighted centroid of the way nodes of an open way.
+// The centroid of a closed way is not always inside of it,
+// e.g. for a U shape, see Way.PointOnSurface.
+func (w *Way) Centroid() geo.Point {
+	if ring, ok := w.ring(); ok {
+		return measure.Centroid(geo.MultiPolygon{{ring}})
+	}
+
+	return measure.LineCentroid(w.LineString())
+}
+
+// PointOnSurface returns a point guaranteed to be inside a closed way,
+// useful for label placement. For open ways the location of the
+// way node closest to the centroid is returned.
+func (w *Way) PointOnSurface() geo.Point {
+	if ring, ok := w.ring(); ok {
+		return measure.PointOnSurface(geo.MultiPolygon{{ring}})
+	}
+
+	return measure.LinePointOnSurface(w.LineString())
+}
+
+// ring returns the annotated nodes as a ring if the way is closed.
+func (w *Way) ring() (geo.Ring, bool) {
+	if !isClosed(w) {
+		return nil, false
+	}
+
+	ring := geo.Ring(w.LineString())
+	if len(ring) < 4 || !ring.Closed() {
+		return nil, false
+	}
+
+	return ring, true
+}
+
+// Length returns the geodesic length in meters of the way members,
+// e.g. of a route relation. The ways are taken from the map or from the
+// member nodes if the relation is annotated, missing ways are skipped.
+// Ways that are members more than once are counted every time.
+func (r *Relation) Length(ways map[WayID]*Way) float64 {
+	var length float64
+	for _, m := range r.Members {
+		if m.Type != TypeWay {
+			continue
+		}
+
+		if w := ways[WayID(m.Ref)]; w != nil {
+			length += w.Length()
+		} else {
+			length += m.Nodes.Length()
+		}
+	}
+
+	return length
+}
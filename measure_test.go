@@ -0,0 +1,128 @@
+package osm
+
+import (
+	"encoding/xml"
+	"math"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/planar"
+)
+
+func TestWayNodes_Length(t *testing.T) {
+	wn := WayNodes{
+		{ID: 1, Version: 1, Lon: 0, Lat: 0},
+		{ID: 2},
+		{ID: 3, Lon: 1, Lat: 0},
+		{ID: 4, Lon: 1, Lat: 1},
+	}
+
+	// a degree of longitude and of latitude at the equator
+	if l := wn.Length(); math.Abs(l-(111319.491+110574.389)) > 1e-2 {
+		t.Errorf("incorrect length: %v", l)
+	}
+
+	if l := (WayNodes{}).Length(); l != 0 {
+		t.Errorf("empty should be zero: %v", l)
+	}
+}
+
+func TestWay_Area(t *testing.T) {
+	w := &Way{
+		Nodes: WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+			{ID: 2, Lon: 1, Lat: 0},
+			{ID: 3, Lon: 1, Lat: 1},
+			{ID: 4, Lon: 0, Lat: 1},
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+		},
+	}
+
+	if a := w.Area(); math.Abs(a-1.2308778e10) > 1e4 {
+		t.Errorf("incorrect area: %v", a)
+	}
+
+	if l := w.Length(); math.Abs(l-443770.917) > 1e-2 {
+		t.Errorf("incorrect length: %v", l)
+	}
+
+	c := w.Centroid()
+	if math.Abs(c[0]-0.5) > 1e-9 || math.Abs(c[1]-0.5) > 1e-9 {
+		t.Errorf("incorrect centroid: %v", c)
+	}
+
+	// open way has no area
+	open := &Way{Nodes: w.Nodes[:4]}
+	if a := open.Area(); a != 0 {
+		t.Errorf("open way should have no area: %v", a)
+	}
+}
+
+func TestWay_PointOnSurface(t *testing.T) {
+	t.Run("open way", func(t *testing.T) {
+		data := `
+<way id="38238655">
+	<nd lat="41.4176729" lon="-81.8752338"/>
+	<nd lat="41.418435" lon="-81.874286"/>
+	<nd lat="41.418526" lon="-81.873181"/>
+	<nd lat="41.418548" lon="-81.868659"/>
+	<nd lat="41.419093" lon="-81.856178"/>
+	<nd lat="41.419033" lon="-81.85595"/>
+</way>`
+
+		var w *Way
+		if err := xml.Unmarshal([]byte(data), &w); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+
+		sp := w.PointOnSurface()
+		expected := geo.Point{w.Nodes[3].Lon, w.Nodes[3].Lat}
+		if !sp.Equal(expected) {
+			t.Errorf("incorrect point on surface: %v", sp)
+		}
+	})
+
+	t.Run("u shaped area", func(t *testing.T) {
+		w := &Way{
+			Nodes: WayNodes{
+				{ID: 1, Version: 1, Lon: 0, Lat: 0},
+				{ID: 2, Lon: 3, Lat: 0},
+				{ID: 3, Lon: 3, Lat: 3},
+				{ID: 4, Lon: 2, Lat: 3},
+				{ID: 5, Lon: 2, Lat: 1},
+				{ID: 6, Lon: 1, Lat: 1},
+				{ID: 7, Lon: 1, Lat: 3},
+				{ID: 8, Lon: 0, Lat: 3},
+				{ID: 1, Version: 1, Lon: 0, Lat: 0},
+			},
+		}
+
+		ring := geo.Ring(w.LineString())
+		if c := w.Centroid(); planar.RingContains(ring, c) {
+			t.Errorf("centroid expected outside: %v", c)
+		}
+
+		if p := w.PointOnSurface(); !planar.RingContains(ring, p) {
+			t.Errorf("point on surface not inside: %v", p)
+		}
+	})
+}
+
+func TestRelation_Length(t *testing.T) {
+	ways := map[WayID]*Way{
+		1: {ID: 1, Nodes: WayNodes{{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2, Lon: 1, Lat: 0}}},
+	}
+
+	r := &Relation{
+		Members: Members{
+			{Type: TypeWay, Ref: 1},
+			{Type: TypeNode, Ref: 1},
+			{Type: TypeWay, Ref: 2, Nodes: WayNodes{{ID: 2, Lon: 1, Lat: 0}, {ID: 3, Lon: 2, Lat: 0}}},
+			{Type: TypeWay, Ref: 3},
+		},
+	}
+
+	if l := r.Length(ways); math.Abs(l-2*111319.491) > 1e-2 {
+		t.Errorf("incorrect length: %v", l)
+	}
+}
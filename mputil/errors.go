@@ -0,0 +1,66 @@
+package mputil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pchchv/osm"
+)
+
+var (
+	// ErrNotMultiPolygon is returned if the relation
+	// is not of type multipolygon or boundary.
+	ErrNotMultiPolygon = errors.New("mputil: relation is not a multipolygon or boundary")
+	// ErrNoOuterRing is returned if the relation has no outer members.
+	ErrNoOuterRing = errors.New("mputil: relation has no outer ring")
+)
+
+// MissingWayError is returned if an inner or outer
+// member way is not found in the way lookup.
+type MissingWayError struct {
+	RelationID osm.RelationID
+	WayID      osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *MissingWayError) Error() string {
+	return fmt.Sprintf("mputil: way %d of relation %d not found", e.WayID, e.RelationID)
+}
+
+// IncompleteWayError is returned if a member way
+// is missing the location of some of its nodes.
+type IncompleteWayError struct {
+	RelationID osm.RelationID
+	WayID      osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *IncompleteWayError) Error() string {
+	return fmt.Sprintf("mputil: way %d of relation %d is missing node locations", e.WayID, e.RelationID)
+}
+
+// OpenRingError is returned if the member ways of
+// a role can not be joined into closed rings.
+// Ways are the ids of the ways in the open ring.
+type OpenRingError struct {
+	RelationID osm.RelationID
+	Role       string
+	Ways       []osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *OpenRingError) Error() string {
+	return fmt.Sprintf("mputil: open %s ring of relation %d with ways %v", e.Role, e.RelationID, e.Ways)
+}
+
+// OrphanInnerRingError is returned if an inner
+// ring is not inside any of the outer rings.
+type OrphanInnerRingError struct {
+	RelationID osm.RelationID
+	Ways       []osm.WayID
+}
+
+// Error returns a pretty string of the error.
+func (e *OrphanInnerRingError) Error() string {
+	return fmt.Sprintf("mputil: inner ring of relation %d with ways %v is not inside an outer ring", e.RelationID, e.Ways)
+}
@@ -0,0 +1,41 @@
+package mputil
+
+import (
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+// Area returns the area in square meters on the WGS84 ellipsoid of a multipolygon
+// or boundary relation with the inner rings subtracted.
+// See MultiPolygon for how the geometry is built and the errors.
+func Area(r *osm.Relation, ways map[osm.WayID]*osm.Way) (float64, error) {
+	mp, err := MultiPolygon(r, ways)
+	if err != nil {
+		return 0, err
+	}
+
+	return measure.Area(mp), nil
+}
+
+// Centroid returns the area weighted centroid of a multipolygon or
+// boundary relation. It is not always inside the area, see PointOnSurface.
+func Centroid(r *osm.Relation, ways map[osm.WayID]*osm.Way) (geo.Point, error) {
+	mp, err := MultiPolygon(r, ways)
+	if err != nil {
+		return geo.Point{}, err
+	}
+
+	return measure.Centroid(mp), nil
+}
+
+// PointOnSurface returns a point guaranteed to be inside the largest
+// polygon of a multipolygon or boundary relation, and not in its holes.
+func PointOnSurface(r *osm.Relation, ways map[osm.WayID]*osm.Way) (geo.Point, error) {
+	mp, err := MultiPolygon(r, ways)
+	if err != nil {
+		return geo.Point{}, err
+	}
+
+	return measure.PointOnSurface(mp), nil
+}
@@ -0,0 +1,75 @@
+package mputil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/planar"
+	"github.com/pchchv/osm"
+)
+
+func TestArea(t *testing.T) {
+	ways := map[osm.WayID]*osm.Way{
+		1: {ID: 1, Nodes: osm.WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2, Lon: 1, Lat: 0}, {ID: 3, Lon: 1, Lat: 1},
+			{ID: 4, Lon: 0, Lat: 1}, {ID: 1, Version: 1, Lon: 0, Lat: 0},
+		}},
+		2: {ID: 2, Nodes: osm.WayNodes{
+			{ID: 5, Lon: 0.25, Lat: 0.25}, {ID: 6, Lon: 0.25, Lat: 0.75}, {ID: 7, Lon: 0.5, Lat: 0.75},
+			{ID: 8, Lon: 0.5, Lat: 0.25}, {ID: 5, Lon: 0.25, Lat: 0.25},
+		}},
+	}
+
+	r := &osm.Relation{
+		ID:   1,
+		Tags: osm.Tags{{Key: "type", Value: "multipolygon"}},
+		Members: osm.Members{
+			{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+			{Type: osm.TypeWay, Ref: 2, Role: "inner"},
+		},
+	}
+
+	a, err := Area(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// inner is an eighth of the outer
+	outer := ways[1].Area()
+	if math.Abs(a-outer*7/8)/outer > 0.001 {
+		t.Errorf("incorrect area: %v, outer %v", a, outer)
+	}
+
+	mp, _ := MultiPolygon(r, ways)
+	p, err := PointOnSurface(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !planar.MultiPolygonContains(mp, p) {
+		t.Errorf("point on surface not inside: %v", p)
+	}
+
+	c, err := Centroid(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c[0] <= 0.5 || math.Abs(c[1]-0.5) > 1e-9 {
+		t.Errorf("centroid should move away from the hole: %v", c)
+	}
+
+	r.Tags = osm.Tags{{Key: "type", Value: "route"}}
+	if _, err := Area(r, ways); err != ErrNotMultiPolygon {
+		t.Errorf("expected not multipolygon error: %v", err)
+	}
+
+	if _, err := Centroid(r, ways); err != ErrNotMultiPolygon {
+		t.Errorf("expected not multipolygon error: %v", err)
+	}
+
+	if p, err := PointOnSurface(r, ways); err != ErrNotMultiPolygon || p != (geo.Point{}) {
+		t.Errorf("expected not multipolygon error: %v", err)
+	}
+}
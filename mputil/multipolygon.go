@@ -0,0 +1,224 @@
+package mputil
+
+import (
+	"context"
+	"time"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/planar"
+	"github.com/pchchv/osm"
+)
+
+// MultiPolygon builds the geometry of a multipolygon or boundary relation
+// from the outer and inner member ways. Ways not in the map are taken
+// from the member nodes if the relation is annotated.
+// The ways must be annotated, i.e. every way node must have its coordinates,
+// as plain XML or PBF ways only have the node ids and
+// return an IncompleteWayError. The way Updates are ignored.
+// Members with an empty role are outer members, as in legacy tagging.
+// Outer rings are counter-clockwise, inner rings clockwise,
+// and every inner ring is added to the smallest outer ring containing it,
+// e.g. a lake in an island in a lake is added to the island.
+// Broken geometry is reported with the typed errors of this package.
+func MultiPolygon(r *osm.Relation, ways map[osm.WayID]*osm.Way) (geo.MultiPolygon, error) {
+	return MultiPolygonAt(r, ways, time.Time{})
+}
+
+// MultiPolygonAt is like MultiPolygon but builds the geometry at the
+// given time by applying the way Updates up to and including the time.
+// The ways must be annotated as for MultiPolygon.
+// A zero time ignores the Updates, use MultiPolygonFromHistory
+// to build the geometry of ways that are not annotated.
+func MultiPolygonAt(r *osm.Relation, ways map[osm.WayID]*osm.Way, at time.Time) (geo.MultiPolygon, error) {
+	if !r.Polygon() {
+		return nil, ErrNotMultiPolygon
+	}
+
+	lookup := make(map[osm.WayID]*osm.Way, len(r.Members))
+	members := make(osm.Members, len(r.Members))
+	for i, m := range r.Members {
+		if m.Role == "" {
+			m.Role = "outer"
+		}
+		members[i] = m
+
+		if m.Type != osm.TypeWay || (m.Role != "outer" && m.Role != "inner") {
+			continue
+		}
+
+		id := osm.WayID(m.Ref)
+		w := ways[id]
+		if w == nil && len(m.Nodes) != 0 {
+			w = &osm.Way{ID: id, Nodes: m.Nodes}
+		}
+
+		if w == nil {
+			return nil, &MissingWayError{RelationID: r.ID, WayID: id}
+		}
+
+		if len(w.LineStringAt(at)) != len(w.Nodes) {
+			return nil, &IncompleteWayError{RelationID: r.ID, WayID: id}
+		}
+
+		lookup[id] = w
+	}
+
+	outer, inner, _ := Group(members, lookup, at)
+	if len(outer) == 0 {
+		return nil, ErrNoOuterRing
+	}
+
+	mp := make(geo.MultiPolygon, 0, len(outer))
+	for _, ms := range Join(outer) {
+		ring := ms.Ring(geo.CCW)
+		if len(ring) < 4 || !ring.Closed() {
+			return nil, &OpenRingError{RelationID: r.ID, Role: "outer", Ways: segmentWays(r, ms)}
+		}
+
+		mp = append(mp, geo.Polygon{ring})
+	}
+
+	for _, ms := range Join(inner) {
+		ring := ms.Ring(geo.CW)
+		if len(ring) < 4 || !ring.Closed() {
+			return nil, &OpenRingError{RelationID: r.ID, Role: "inner", Ways: segmentWays(r, ms)}
+		}
+
+		best := -1
+		for i := range mp {
+			if ringContains(mp[i][0], ring) && (best < 0 || planar.Area(mp[i][0]) < planar.Area(mp[best][0])) {
+				best = i
+			}
+		}
+
+		if best < 0 {
+			return nil, &OrphanInnerRingError{RelationID: r.ID, Ways: segmentWays(r, ms)}
+		}
+
+		mp[best] = append(mp[best], ring)
+	}
+
+	return mp, nil
+}
+
+// MultiPolygonFromHistory builds the geometry of the relation as it was at
+// the given time using the version of the member ways, and their nodes,
+// visible at that time. The member ways and nodes are loaded from the datasource.
+// A MissingWayError is returned if a way did not exist at the time.
+func MultiPolygonFromHistory(ctx context.Context, r *osm.Relation, ds osm.HistoryDatasourcer, at time.Time) (geo.MultiPolygon, error) {
+	if !r.Polygon() {
+		return nil, ErrNotMultiPolygon
+	}
+
+	ways := make(map[osm.WayID]*osm.Way, len(r.Members))
+	nodes := make(map[osm.NodeID]*osm.Node)
+	for _, m := range r.Members {
+		if m.Type != osm.TypeWay || (m.Role != "" && m.Role != "outer" && m.Role != "inner") {
+			continue
+		}
+
+		id := osm.WayID(m.Ref)
+		if ways[id] != nil {
+			continue
+		}
+
+		history, err := ds.WayHistory(ctx, id)
+		if ds.NotFound(err) {
+			return nil, &MissingWayError{RelationID: r.ID, WayID: id}
+		} else if err != nil {
+			return nil, err
+		}
+
+		var w *osm.Way
+		for _, v := range history {
+			if !v.CommittedAt().After(at) && (w == nil || v.Version > w.Version) {
+				w = v
+			}
+		}
+
+		if w == nil || !w.Visible {
+			return nil, &MissingWayError{RelationID: r.ID, WayID: id}
+		}
+
+		annotated := *w
+		annotated.Updates = nil
+		annotated.Nodes = make(osm.WayNodes, len(w.Nodes))
+		for i, wn := range w.Nodes {
+			n, err := nodeAt(ctx, ds, nodes, wn.ID, at)
+			if err != nil {
+				return nil, err
+			}
+
+			annotated.Nodes[i] = osm.WayNode{ID: wn.ID}
+			if n != nil {
+				annotated.Nodes[i].Version = n.Version
+				annotated.Nodes[i].ChangesetID = n.ChangesetID
+				annotated.Nodes[i].Lat = n.Lat
+				annotated.Nodes[i].Lon = n.Lon
+			}
+		}
+
+		ways[id] = &annotated
+	}
+
+	return MultiPolygonAt(r, ways, at)
+}
+
+// nodeAt returns the visible version of the node at the time,
+// nil if there is none. The result is cached in the map.
+func nodeAt(ctx context.Context, ds osm.HistoryDatasourcer, cache map[osm.NodeID]*osm.Node, id osm.NodeID, at time.Time) (*osm.Node, error) {
+	if n, ok := cache[id]; ok {
+		return n, nil
+	}
+
+	history, err := ds.NodeHistory(ctx, id)
+	if err != nil && !ds.NotFound(err) {
+		return nil, err
+	}
+
+	var n *osm.Node
+	for _, v := range history {
+		if !v.CommittedAt().After(at) && (n == nil || v.Version > n.Version) {
+			n = v
+		}
+	}
+
+	if n != nil && !n.Visible {
+		n = nil
+	}
+
+	cache[id] = n
+	return n, nil
+}
+
+// segmentWays returns the ids of the member ways of the segments.
+func segmentWays(r *osm.Relation, ms MultiSegment) []osm.WayID {
+	ids := make([]osm.WayID, 0, len(ms))
+	for _, s := range ms {
+		ids = append(ids, osm.WayID(r.Members[s.Index].Ref))
+	}
+
+	return ids
+}
+
+// ringContains returns true if any of the points of
+// the ring is inside the outer ring.
+func ringContains(outer, ring geo.Ring) bool {
+	for _, p := range ring {
+		var inside bool
+		x, y := p[0], p[1]
+		for i, j := 0, len(outer)-1; i < len(outer); j, i = i, i+1 {
+			xi, yi := outer[i][0], outer[i][1]
+			xj, yj := outer[j][0], outer[j][1]
+			if ((yi > y) != (yj > y)) && (x < (xj-xi)*(y-yi)/(yj-yi)+xi) {
+				inside = !inside
+			}
+		}
+
+		if inside {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,326 @@
+package mputil
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+func TestMultiPolygon(t *testing.T) {
+	ways := map[osm.WayID]*osm.Way{
+		// outer in two parts
+		1: {ID: 1, Nodes: osm.WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2, Lon: 10, Lat: 0}, {ID: 3, Lon: 10, Lat: 10},
+		}},
+		2: {ID: 2, Nodes: osm.WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 4, Lon: 0, Lat: 10}, {ID: 3, Lon: 10, Lat: 10},
+		}},
+		// inner
+		3: {ID: 3, Nodes: osm.WayNodes{
+			{ID: 5, Lon: 1, Lat: 1}, {ID: 6, Lon: 2, Lat: 1}, {ID: 7, Lon: 2, Lat: 2}, {ID: 5, Lon: 1, Lat: 1},
+		}},
+		// second outer
+		4: {ID: 4, Nodes: osm.WayNodes{
+			{ID: 8, Lon: 20, Lat: 0}, {ID: 9, Lon: 21, Lat: 0}, {ID: 10, Lon: 21, Lat: 1}, {ID: 8, Lon: 20, Lat: 0},
+		}},
+	}
+
+	r := &osm.Relation{
+		ID:   10,
+		Tags: osm.Tags{{Key: "type", Value: "multipolygon"}},
+		Members: osm.Members{
+			{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+			{Type: osm.TypeWay, Ref: 3, Role: "inner"},
+			{Type: osm.TypeWay, Ref: 2, Role: "outer"},
+			{Type: osm.TypeWay, Ref: 4, Role: "outer"},
+			{Type: osm.TypeNode, Ref: 1, Role: "label"},
+		},
+	}
+
+	mp, err := MultiPolygon(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mp) != 2 {
+		t.Fatalf("incorrect number of polygons: %d", len(mp))
+	}
+
+	for _, p := range mp {
+		if p[0].Orientation() != geo.CCW {
+			t.Errorf("outer ring should be ccw")
+		}
+
+		for _, r := range p[1:] {
+			if r.Orientation() != geo.CW {
+				t.Errorf("inner ring should be cw")
+			}
+		}
+	}
+
+	var big, small geo.Polygon
+	if len(mp[0]) == 2 {
+		big, small = mp[0], mp[1]
+	} else {
+		big, small = mp[1], mp[0]
+	}
+
+	if len(big) != 2 || len(big[0]) != 5 || len(big[1]) != 4 {
+		t.Errorf("incorrect polygon with inner: %v", big)
+	}
+
+	if len(small) != 1 || len(small[0]) != 4 {
+		t.Errorf("incorrect second polygon: %v", small)
+	}
+}
+
+func TestMultiPolygon_nested(t *testing.T) {
+	square := func(id osm.WayID, min, max float64) *osm.Way {
+		n := osm.NodeID(id * 10)
+		return &osm.Way{ID: id, Nodes: osm.WayNodes{
+			{ID: n, Lon: min, Lat: min}, {ID: n + 1, Lon: max, Lat: min},
+			{ID: n + 2, Lon: max, Lat: max}, {ID: n + 3, Lon: min, Lat: max},
+			{ID: n, Lon: min, Lat: min},
+		}}
+	}
+
+	// an island with a lake, in the lake an island with a pond
+	ways := map[osm.WayID]*osm.Way{
+		1: square(1, 1, 11),
+		2: square(2, 2, 10),
+		3: square(3, 3, 9),
+		4: square(4, 4, 8),
+	}
+
+	r := &osm.Relation{
+		ID:   10,
+		Tags: osm.Tags{{Key: "type", Value: "multipolygon"}},
+		Members: osm.Members{
+			{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+			{Type: osm.TypeWay, Ref: 4, Role: "inner"},
+			{Type: osm.TypeWay, Ref: 2, Role: "inner"},
+			// legacy tagging without a role is an outer member
+			{Type: osm.TypeWay, Ref: 3, Role: ""},
+		},
+	}
+
+	mp, err := MultiPolygon(r, ways)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mp) != 2 {
+		t.Fatalf("incorrect number of polygons: %d", len(mp))
+	}
+
+	for _, p := range mp {
+		if len(p) != 2 {
+			t.Fatalf("every polygon should have one inner ring: %v", mp)
+		}
+
+		// the inner ring is just inside the outer ring
+		if p[1][0][0]-p[0][0][0] != 1 {
+			t.Errorf("inner ring added to the wrong outer ring: %v", p)
+		}
+	}
+}
+
+func TestMultiPolygon_errors(t *testing.T) {
+	square := osm.WayNodes{
+		{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2, Lon: 1, Lat: 0}, {ID: 3, Lon: 1, Lat: 1}, {ID: 4, Lon: 0, Lat: 1}, {ID: 1, Version: 1, Lon: 0, Lat: 0},
+	}
+	outside := osm.WayNodes{
+		{ID: 5, Lon: 5, Lat: 5}, {ID: 6, Lon: 6, Lat: 5}, {ID: 7, Lon: 6, Lat: 6}, {ID: 5, Lon: 5, Lat: 5},
+	}
+
+	ways := map[osm.WayID]*osm.Way{
+		1: {ID: 1, Nodes: square},
+		2: {ID: 2, Nodes: square[:3]},
+		3: {ID: 3, Nodes: outside},
+		4: {ID: 4, Nodes: osm.WayNodes{{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2}, {ID: 3, Lon: 1, Lat: 1}}},
+	}
+
+	relation := func(typ string, members ...osm.Member) *osm.Relation {
+		return &osm.Relation{ID: 10, Tags: osm.Tags{{Key: "type", Value: typ}}, Members: members}
+	}
+
+	cases := []struct {
+		name     string
+		relation *osm.Relation
+		err      error
+	}{
+		{
+			name:     "not a multipolygon",
+			relation: relation("route", osm.Member{Type: osm.TypeWay, Ref: 1, Role: "outer"}),
+			err:      ErrNotMultiPolygon,
+		},
+		{
+			name:     "no outer",
+			relation: relation("multipolygon", osm.Member{Type: osm.TypeWay, Ref: 3, Role: "inner"}),
+			err:      ErrNoOuterRing,
+		},
+		{
+			name:     "missing way",
+			relation: relation("multipolygon", osm.Member{Type: osm.TypeWay, Ref: 100, Role: "outer"}),
+			err:      &MissingWayError{RelationID: 10, WayID: 100},
+		},
+		{
+			name:     "incomplete way",
+			relation: relation("multipolygon", osm.Member{Type: osm.TypeWay, Ref: 4, Role: "outer"}),
+			err:      &IncompleteWayError{RelationID: 10, WayID: 4},
+		},
+		{
+			name:     "open outer",
+			relation: relation("boundary", osm.Member{Type: osm.TypeWay, Ref: 2, Role: "outer"}),
+			err:      &OpenRingError{RelationID: 10, Role: "outer", Ways: []osm.WayID{2}},
+		},
+		{
+			name: "open inner",
+			relation: relation("multipolygon",
+				osm.Member{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+				osm.Member{Type: osm.TypeWay, Ref: 2, Role: "inner"},
+			),
+			err: &OpenRingError{RelationID: 10, Role: "inner", Ways: []osm.WayID{2}},
+		},
+		{
+			name: "orphan inner",
+			relation: relation("multipolygon",
+				osm.Member{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+				osm.Member{Type: osm.TypeWay, Ref: 3, Role: "inner"},
+			),
+			err: &OrphanInnerRingError{RelationID: 10, Ways: []osm.WayID{3}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MultiPolygon(tc.relation, ways)
+			if !reflect.DeepEqual(err, tc.err) {
+				t.Errorf("incorrect error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMultiPolygon_annotatedMembers(t *testing.T) {
+	r := &osm.Relation{
+		ID:   1,
+		Tags: osm.Tags{{Key: "type", Value: "boundary"}},
+		Members: osm.Members{
+			{
+				Type: osm.TypeWay, Ref: 1, Role: "outer",
+				Nodes: osm.WayNodes{
+					{ID: 1, Version: 1, Lon: 0, Lat: 0}, {ID: 2, Lon: 0, Lat: 1}, {ID: 3, Lon: 1, Lat: 1}, {ID: 1, Version: 1, Lon: 0, Lat: 0},
+				},
+			},
+		},
+	}
+
+	mp, err := MultiPolygon(r, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := geo.MultiPolygon{{{{0, 0}, {1, 1}, {0, 1}, {0, 0}}}}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Errorf("incorrect geometry: %v", mp)
+	}
+}
+
+func TestMultiPolygonAt(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &osm.Way{
+		ID: 1,
+		Nodes: osm.WayNodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+			{ID: 2, Version: 1, Lon: 0, Lat: 1},
+			{ID: 3, Version: 1, Lon: 1, Lat: 1},
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+		},
+		Updates: osm.Updates{{Index: 2, Version: 2, Timestamp: t1, Lon: 2, Lat: 2}},
+	}
+
+	r := &osm.Relation{
+		ID:      1,
+		Tags:    osm.Tags{{Key: "type", Value: "multipolygon"}},
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 1, Role: "outer"}},
+	}
+
+	mp, err := MultiPolygonAt(r, map[osm.WayID]*osm.Way{1: w}, t1.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p := mp[0][0][1]; !p.Equal(geo.Point{1, 1}) {
+		t.Errorf("update should not be applied: %v", mp)
+	}
+
+	mp, err = MultiPolygonAt(r, map[osm.WayID]*osm.Way{1: w}, t1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p := mp[0][0][1]; !p.Equal(geo.Point{2, 2}) {
+		t.Errorf("update should be applied: %v", mp)
+	}
+}
+
+func TestMultiPolygonFromHistory(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	ds := &osm.HistoryDatasource{
+		Nodes: map[osm.NodeID]osm.Nodes{
+			1: {{ID: 1, Version: 1, Visible: true, Timestamp: t1, Lon: 0, Lat: 0}},
+			2: {{ID: 2, Version: 1, Visible: true, Timestamp: t1, Lon: 0, Lat: 1}},
+			3: {
+				{ID: 3, Version: 1, Visible: true, Timestamp: t1, Lon: 1, Lat: 1},
+				{ID: 3, Version: 2, Visible: true, Timestamp: t2, Lon: 2, Lat: 2},
+			},
+		},
+		Ways: map[osm.WayID]osm.Ways{
+			1: {
+				{ID: 1, Version: 1, Visible: true, Timestamp: t1, Nodes: osm.WayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 1}}},
+			},
+		},
+	}
+
+	r := &osm.Relation{
+		ID:      1,
+		Tags:    osm.Tags{{Key: "type", Value: "multipolygon"}},
+		Members: osm.Members{{Type: osm.TypeWay, Ref: 1, Role: "outer"}},
+	}
+
+	ctx := context.Background()
+	mp, err := MultiPolygonFromHistory(ctx, r, ds, t1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := geo.MultiPolygon{{{{0, 0}, {1, 1}, {0, 1}, {0, 0}}}}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Errorf("incorrect geometry at t1: %v", mp)
+	}
+
+	mp, err = MultiPolygonFromHistory(ctx, r, ds, t2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = geo.MultiPolygon{{{{0, 0}, {2, 2}, {0, 1}, {0, 0}}}}
+	if !reflect.DeepEqual(mp, expected) {
+		t.Errorf("incorrect geometry at t2: %v", mp)
+	}
+
+	// before the way existed
+	_, err = MultiPolygonFromHistory(ctx, r, ds, t1.Add(-time.Hour))
+	var mwe *MissingWayError
+	if !errors.As(err, &mwe) || mwe.WayID != 1 {
+		t.Errorf("expected missing way error: %v", err)
+	}
+}
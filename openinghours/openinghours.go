@@ -0,0 +1,332 @@
+package openinghours
+
+import (
+	"time"
+)
+
+// The states of a place.
+const (
+	Open    State = "open"
+	Closed  State = "closed"
+	Unknown State = "unknown"
+)
+
+const (
+	minutesPerDay = 24 * 60
+	// maxSearchDays limits the search of NextChange.
+	maxSearchDays = 5 * 366
+)
+
+// State is a strong type for the state of a place at a time.
+type State string
+
+// HolidayProvider decides which days are public holidays, the PH selector.
+type HolidayProvider interface {
+	IsHoliday(day time.Time) bool
+}
+
+// HolidayFunc is an adapter to use a function as a HolidayProvider.
+type HolidayFunc func(day time.Time) bool
+
+// IsHoliday calls the function.
+func (f HolidayFunc) IsHoliday(day time.Time) bool {
+	return f(day)
+}
+
+// Option is a parameter that can be used for parsing opening hours.
+type Option func(*OpeningHours) error
+
+// Holidays sets the provider of the public holidays. Without one
+// the PH selector never matches, e.g. "PH off" has no effect.
+func Holidays(h HolidayProvider) Option {
+	return func(oh *OpeningHours) error {
+		oh.holidays = h
+		return nil
+	}
+}
+
+// OpeningHours is a parsed opening_hours value. The times are evaluated
+// in the location of the times passed in, i.e. the local time of the place.
+type OpeningHours struct {
+	value    string
+	rules    []*rule
+	holidays HolidayProvider
+}
+
+// Interval is a time range with the same state and comment.
+type Interval struct {
+	Start   time.Time
+	End     time.Time
+	State   State
+	Comment string
+}
+
+// Parse parses an opening_hours value. The supported grammar is
+// 24/7, year, month, day and week selectors, weekday ranges with nth
+// weekdays, PH, time spans including past midnight and open end,
+// the open, closed, off and unknown modifiers, comments and the
+// normal (;), additional (,) and fallback (||) rule separators.
+// A *SyntaxError is returned with the offset of the problem,
+// e.g. for variable times like sunrise that are not supported.
+func Parse(value string, opts ...Option) (*OpeningHours, error) {
+	p := &parser{value: value}
+	rules, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	oh := &OpeningHours{value: value, rules: rules}
+	for _, o := range opts {
+		if err := o(oh); err != nil {
+			return nil, err
+		}
+	}
+
+	return oh, nil
+}
+
+// String returns the value that was parsed.
+func (oh *OpeningHours) String() string {
+	return oh.value
+}
+
+// State returns the state and comment at the time.
+func (oh *OpeningHours) State(t time.Time) (State, string) {
+	date, minute := split(t)
+	for _, s := range oh.day(date) {
+		if minute < s.end {
+			return s.state, s.comment
+		}
+	}
+
+	return Closed, ""
+}
+
+// IsOpen returns true if the state at the time is Open.
+func (oh *OpeningHours) IsOpen(t time.Time) bool {
+	s, _ := oh.State(t)
+	return s == Open
+}
+
+// NextChange returns the next time after t the state or comment changes.
+// Returns false if there is no change within the next five years.
+func (oh *OpeningHours) NextChange(t time.Time) (time.Time, bool) {
+	date, minute := split(t)
+	days := maxSearchDays
+	if p := oh.period(); p > 0 {
+		// the state of a day depends on the day and the previous day,
+		// so if nothing changes within one more period it never will
+		days = p + 1
+	}
+
+	var current cell
+	started := false
+	for i := 0; i <= days; i++ {
+		for _, s := range oh.day(date) {
+			switch {
+			case !started && minute < s.end:
+				current, started = s.cell, true
+			case started && s.cell != current:
+				return at(date, s.start), true
+			}
+		}
+
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, false
+}
+
+// Intervals returns the open and unknown intervals that overlap the time
+// range, clipped to the range. Closed intervals are not included.
+func (oh *OpeningHours) Intervals(from, to time.Time) []Interval {
+	var result []Interval
+	if !from.Before(to) {
+		return nil
+	}
+
+	date, _ := split(from)
+	for date.Before(to) {
+		for _, seg := range oh.day(date) {
+			s, e := at(date, seg.start), at(date, seg.end)
+			if seg.state == Closed || !e.After(from) || !s.Before(to) {
+				continue
+			}
+
+			if s.Before(from) {
+				s = from
+			}
+
+			if e.After(to) {
+				e = to
+			}
+
+			// merge with the interval of the previous day
+			if l := len(result) - 1; l >= 0 && result[l].End.Equal(s) &&
+				result[l].State == seg.state && result[l].Comment == seg.comment {
+				result[l].End = e
+				continue
+			}
+
+			result = append(result, Interval{Start: s, End: e, State: seg.state, Comment: seg.comment})
+		}
+
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return result
+}
+
+// period returns the number of days after which the rules repeat,
+// 1 if every day is the same and 7 if the rules only select weekdays.
+// Returns 0 if the rules depend on the date, e.g. the month or holidays.
+func (oh *OpeningHours) period() int {
+	period := 1
+	for _, r := range oh.rules {
+		if len(r.years) > 0 || len(r.weeks) > 0 || len(r.monthdays) > 0 ||
+			(r.holiday && oh.holidays != nil) {
+			return 0
+		}
+
+		for _, wd := range r.weekdays {
+			if len(wd.nth) > 0 {
+				return 0
+			}
+			period = 7
+		}
+	}
+
+	return period
+}
+
+// cell is the state of a time span.
+type cell struct {
+	state   State
+	comment string
+}
+
+// segment is a cell from the start to the end minute of a day.
+type segment struct {
+	start, end int
+	cell
+}
+
+// day returns the segments covering every minute of the day, in order,
+// including the spans past midnight of the previous day.
+// Adjacent segments have a different state or comment.
+func (oh *OpeningHours) day(date time.Time) []segment {
+	result := []segment{{start: 0, end: minutesPerDay, cell: cell{state: Closed}}}
+	for _, s := range oh.raw(date.AddDate(0, 0, -1)) {
+		if s.end > minutesPerDay {
+			s.start, s.end = max(s.start, minutesPerDay)-minutesPerDay, s.end-minutesPerDay
+			result = paint(result, s)
+		}
+	}
+
+	for _, s := range oh.raw(date) {
+		if s.start < minutesPerDay {
+			s.end = min(s.end, minutesPerDay)
+			result = paint(result, s)
+		}
+	}
+
+	// merge the adjacent segments with the same cell
+	merged := result[:1]
+	for _, s := range result[1:] {
+		if l := len(merged) - 1; merged[l].cell == s.cell {
+			merged[l].end = s.end
+		} else {
+			merged = append(merged, s)
+		}
+	}
+
+	return merged
+}
+
+// raw applies the rules matching the day and returns the segments set
+// by them in order. The segments cover two days for the time spans
+// past midnight, the minutes not set by any rule are not included.
+func (oh *OpeningHours) raw(date time.Time) []segment {
+	var result []segment
+	matched := false
+	for _, r := range oh.rules {
+		if r.kind == fallbackRule && matched {
+			continue
+		}
+
+		if !r.matches(date, oh.holidays) {
+			continue
+		}
+
+		if r.kind == normalRule {
+			result = result[:0]
+		}
+
+		matched = true
+		spans := r.times
+		if len(spans) == 0 {
+			spans = []timeSpan{{start: 0, end: minutesPerDay}}
+		}
+
+		for _, s := range spans {
+			c := cell{state: r.state, comment: r.comment}
+			if s.openEnd && r.state == Open {
+				c.state = Unknown
+			}
+
+			if s.start < s.end {
+				result = paint(result, segment{start: s.start, end: s.end, cell: c})
+			}
+		}
+	}
+
+	return result
+}
+
+// paint sets the cell of the segment over the sorted segments,
+// the parts of the segments it overlaps are replaced.
+func paint(segments []segment, p segment) []segment {
+	result := make([]segment, 0, len(segments)+2)
+	inserted := false
+	for _, s := range segments {
+		if s.end <= p.start || s.start >= p.end {
+			if s.start >= p.end && !inserted {
+				result = append(result, p)
+				inserted = true
+			}
+
+			result = append(result, s)
+			continue
+		}
+
+		if s.start < p.start {
+			result = append(result, segment{start: s.start, end: p.start, cell: s.cell})
+		}
+
+		if !inserted {
+			result = append(result, p)
+			inserted = true
+		}
+
+		if s.end > p.end {
+			result = append(result, segment{start: p.end, end: s.end, cell: s.cell})
+		}
+	}
+
+	if !inserted {
+		result = append(result, p)
+	}
+
+	return result
+}
+
+// split returns the start of the day and the minute of the day of the time.
+func split(t time.Time) (time.Time, int) {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return date, t.Hour()*60 + t.Minute()
+}
+
+// at returns the time of the minute of the day.
+func at(date time.Time, minute int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, minute, 0, 0, date.Location())
+}
@@ -0,0 +1,218 @@
+package openinghours
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 2024-01-01 is a Monday.
+func date(day, hour, minute int) time.Time {
+	return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestOpeningHours_State(t *testing.T) {
+	christmas := HolidayFunc(func(day time.Time) bool {
+		return day.Month() == time.December && day.Day() == 25
+	})
+
+	cases := []struct {
+		name    string
+		value   string
+		time    time.Time
+		state   State
+		comment string
+	}{
+		{name: "24/7", value: "24/7", time: date(3, 3, 0), state: Open},
+		{name: "weekday open", value: "Mo-Fr 08:00-18:00", time: date(3, 8, 0), state: Open},
+		{name: "weekday end", value: "Mo-Fr 08:00-18:00", time: date(3, 18, 0), state: Closed},
+		{name: "weekend", value: "Mo-Fr 08:00-18:00", time: date(6, 12, 0), state: Closed},
+		{name: "weekday wrap", value: "Sa-Mo 10:00-12:00", time: date(1, 11, 0), state: Open},
+		{name: "lunch break", value: "Mo-Fr 08:00-12:00,13:00-17:00", time: date(2, 12, 30), state: Closed},
+		{name: "whole day", value: "Mo", time: date(1, 23, 59), state: Open},
+		{name: "past midnight", value: "Fr 22:00-03:00", time: date(6, 2, 0), state: Open},
+		{name: "past midnight end", value: "Fr 22:00-03:00", time: date(6, 3, 0), state: Closed},
+		{name: "normal overrides day", value: "Mo-Fr 08:00-18:00; We 10:00-12:00", time: date(3, 9, 0), state: Closed},
+		{name: "additional", value: "Mo-Fr 08:00-18:00, We 19:00-20:00", time: date(3, 9, 0), state: Open},
+		{name: "additional off", value: "Mo-Fr 08:00-18:00, We 12:00-13:00 off", time: date(3, 12, 30), state: Closed},
+		{name: "off day", value: "Mo-Sa 10:00-20:00; Tu off", time: date(2, 12, 0), state: Closed},
+		{name: "fallback not used", value: "Mo-Fr 08:00-12:00 || \"call us\"", time: date(2, 14, 0), state: Closed},
+		{name: "fallback", value: "Mo-Fr 08:00-12:00 || \"call us\"", time: date(6, 14, 0), state: Unknown, comment: "call us"},
+		{name: "comment", value: "Mo 10:00-12:00 \"ring\"", time: date(1, 11, 0), state: Open, comment: "ring"},
+		{name: "unknown", value: "Mo unknown", time: date(1, 11, 0), state: Unknown},
+		{name: "open end", value: "Mo 17:00+", time: date(1, 18, 0), state: Unknown},
+		{name: "month", value: "Feb-Mar 10:00-12:00", time: date(1, 11, 0), state: Closed},
+		{name: "month wrap", value: "Nov-Feb 10:00-12:00", time: date(1, 11, 0), state: Open},
+		{name: "month day range", value: "Dec 30-Jan 02 10:00-12:00", time: date(2, 11, 0), state: Open},
+		{name: "month day range end", value: "Dec 30-Jan 02 10:00-12:00", time: date(3, 11, 0), state: Closed},
+		{name: "month days", value: "Jan 01-05 off; 10:00-12:00", time: date(3, 11, 0), state: Open},
+		{name: "week", value: "week 2-53/2 10:00-12:00", time: date(8, 11, 0), state: Open},
+		{name: "week step", value: "week 2-53/2 10:00-12:00", time: date(15, 11, 0), state: Closed},
+		{name: "year", value: "2023 10:00-12:00", time: date(2, 11, 0), state: Closed},
+		{name: "first monday", value: "Mo[1] 10:00-12:00", time: date(1, 11, 0), state: Open},
+		{name: "second monday", value: "Mo[1] 10:00-12:00", time: date(8, 11, 0), state: Closed},
+		{name: "last monday", value: "Mo[-1] 10:00-12:00", time: date(29, 11, 0), state: Open},
+		{name: "holiday", value: "Mo-Fr 08:00-18:00; PH off", time: date(1, 11, 0), state: Open},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			oh, err := Parse(tc.value, Holidays(christmas))
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			state, comment := oh.State(tc.time)
+			if state != tc.state || comment != tc.comment {
+				t.Errorf("incorrect state: %v %q", state, comment)
+			}
+
+			if oh.IsOpen(tc.time) != (tc.state == Open) {
+				t.Errorf("incorrect is open")
+			}
+		})
+	}
+}
+
+func TestOpeningHours_holidays(t *testing.T) {
+	newYear := HolidayFunc(func(day time.Time) bool {
+		return day.Month() == time.January && day.Day() == 1
+	})
+
+	oh, err := Parse("Mo-Fr 08:00-18:00; PH off")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if !oh.IsOpen(date(1, 10, 0)) {
+		t.Errorf("should be open without holiday provider")
+	}
+
+	oh, err = Parse("Mo-Fr 08:00-18:00; PH off", Holidays(newYear))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if oh.IsOpen(date(1, 10, 0)) || !oh.IsOpen(date(2, 10, 0)) {
+		t.Errorf("should be closed on the holiday only")
+	}
+}
+
+func TestOpeningHours_NextChange(t *testing.T) {
+	oh, err := Parse("Mo-Fr 08:00-18:00")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	cases := []struct {
+		time     time.Time
+		expected time.Time
+	}{
+		{time: date(1, 7, 30), expected: date(1, 8, 0)},
+		{time: date(1, 8, 0), expected: date(1, 18, 0)},
+		{time: date(1, 17, 59).Add(30 * time.Second), expected: date(1, 18, 0)},
+		{time: date(5, 18, 0), expected: date(8, 8, 0)},
+	}
+
+	for _, tc := range cases {
+		next, ok := oh.NextChange(tc.time)
+		if !ok || !next.Equal(tc.expected) {
+			t.Errorf("incorrect next change for %v: %v", tc.time, next)
+		}
+	}
+
+	oh, err = Parse("Fr 22:00-03:00")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if next, ok := oh.NextChange(date(5, 23, 0)); !ok || !next.Equal(date(6, 3, 0)) {
+		t.Errorf("incorrect next change: %v", next)
+	}
+
+	oh, err = Parse("24/7")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if _, ok := oh.NextChange(date(1, 0, 0)); ok {
+		t.Errorf("24/7 should never change")
+	}
+
+	// the weekly rules repeat, the change is found within the next week
+	oh, err = Parse("Su 10:00-12:00")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if next, ok := oh.NextChange(date(7, 12, 0)); !ok || !next.Equal(date(14, 10, 0)) {
+		t.Errorf("incorrect next change: %v", next)
+	}
+}
+
+func TestOpeningHours_Intervals(t *testing.T) {
+	oh, err := Parse("Mo-Fr 08:00-12:00; Fr 20:00-02:00; Sa 10:00+")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	intervals := oh.Intervals(date(1, 10, 0), date(6, 12, 0))
+	expected := []Interval{
+		{Start: date(1, 10, 0), End: date(1, 12, 0), State: Open},
+		{Start: date(2, 8, 0), End: date(2, 12, 0), State: Open},
+		{Start: date(3, 8, 0), End: date(3, 12, 0), State: Open},
+		{Start: date(4, 8, 0), End: date(4, 12, 0), State: Open},
+		{Start: date(5, 20, 0), End: date(6, 2, 0), State: Open},
+		{Start: date(6, 10, 0), End: date(6, 12, 0), State: Unknown},
+	}
+
+	if !reflect.DeepEqual(intervals, expected) {
+		t.Errorf("incorrect intervals")
+		for _, i := range intervals {
+			t.Logf("%v", i)
+		}
+	}
+
+	if intervals := oh.Intervals(date(2, 0, 0), date(1, 0, 0)); intervals != nil {
+		t.Errorf("expected no intervals: %v", intervals)
+	}
+}
+
+func BenchmarkOpeningHours_NextChange(b *testing.B) {
+	oh, err := Parse("Mo-Fr 08:00-12:00,13:00-18:00; Sa 10:00-14:00; PH off")
+	if err != nil {
+		b.Fatalf("parse error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		oh.NextChange(date(6, 15, 0))
+	}
+}
+
+func BenchmarkOpeningHours_NextChange_247(b *testing.B) {
+	oh, err := Parse("24/7")
+	if err != nil {
+		b.Fatalf("parse error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		oh.NextChange(date(6, 15, 0))
+	}
+}
+
+func BenchmarkOpeningHours_Intervals(b *testing.B) {
+	oh, err := Parse("Mo-Fr 08:00-12:00,13:00-18:00; Sa 10:00-14:00; PH off")
+	if err != nil {
+		b.Fatalf("parse error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		oh.Intervals(date(1, 0, 0), date(1, 0, 0).AddDate(1, 0, 0))
+	}
+}
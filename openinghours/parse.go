@@ -0,0 +1,561 @@
+package openinghours
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var _ error = &SyntaxError{}
+
+var weekdays = map[string]int{"Su": 0, "Mo": 1, "Tu": 2, "We": 3, "Th": 4, "Fr": 5, "Sa": 6}
+
+var months = map[string]int{
+	"Jan": 1, "Feb": 2, "Mar": 3, "Apr": 4, "May": 5, "Jun": 6,
+	"Jul": 7, "Aug": 8, "Sep": 9, "Oct": 10, "Nov": 11, "Dec": 12,
+}
+
+// SyntaxError is returned by Parse for values that do not follow the
+// opening_hours grammar. Offset is the byte offset of the problem in the value.
+type SyntaxError struct {
+	Value  string
+	Offset int
+	Reason string
+}
+
+// Error returns a pretty string of the error.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("openinghours: %s at offset %d in %q", e.Reason, e.Offset, e.Value)
+}
+
+// parser is a recursive descent parser of the opening_hours grammar.
+type parser struct {
+	value string
+	pos   int
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Value: p.value, Offset: pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.value) && (p.value[p.pos] == ' ' || p.value[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) eof() bool {
+	p.skipSpaces()
+	return p.pos >= len(p.value)
+}
+
+// consume skips the token if it is next.
+func (p *parser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.value[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+
+	return false
+}
+
+// word returns the next run of letters without consuming it.
+func (p *parser) word() string {
+	p.skipSpaces()
+	end := p.pos
+	for end < len(p.value) && isLetter(p.value[end]) {
+		end++
+	}
+
+	return p.value[p.pos:end]
+}
+
+// digits returns the next run of digits without consuming it.
+func (p *parser) digits() string {
+	p.skipSpaces()
+	end := p.pos
+	for end < len(p.value) && isDigit(p.value[end]) {
+		end++
+	}
+
+	return p.value[p.pos:end]
+}
+
+// number consumes an integer with an optional sign.
+func (p *parser) number() (int, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.pos < len(p.value) && (p.value[p.pos] == '-' || p.value[p.pos] == '+') {
+		p.pos++
+	}
+
+	d := p.digits()
+	if d == "" {
+		return 0, p.errorf(start, "expected number")
+	}
+	p.pos += len(d)
+
+	return strconv.Atoi(p.value[start:p.pos])
+}
+
+// isTime returns true if the next token is a time, e.g. 08:00.
+func (p *parser) isTime() bool {
+	d := p.digits()
+	rest := p.value[p.pos+len(d):]
+	return len(d) > 0 && len(d) <= 2 && len(rest) >= 3 &&
+		rest[0] == ':' && isDigit(rest[1]) && isDigit(rest[2])
+}
+
+// isYear returns true if the next token is a year, e.g. 2024.
+func (p *parser) isYear() bool {
+	return len(p.digits()) == 4
+}
+
+func (p *parser) parse() ([]*rule, error) {
+	var rules []*rule
+	kind := normalRule
+	for {
+		r, err := p.rule()
+		if err != nil {
+			return nil, err
+		}
+
+		r.kind = kind
+		rules = append(rules, r)
+
+		if p.eof() {
+			return rules, nil
+		}
+
+		switch {
+		case p.consume("||"):
+			kind = fallbackRule
+		case p.consume(";"):
+			kind = normalRule
+		case p.consume(","):
+			kind = additionalRule
+		default:
+			return nil, p.errorf(p.pos, "unexpected %q", p.value[p.pos:p.pos+1])
+		}
+
+		if p.eof() {
+			return nil, p.errorf(p.pos, "expected rule")
+		}
+	}
+}
+
+func (p *parser) rule() (*rule, error) {
+	r := &rule{state: Open}
+	start := p.pos
+	selected := false
+	if p.consume("24/7") {
+		selected = true
+	} else {
+		var err error
+		if selected, err = p.selectors(r); err != nil {
+			return nil, err
+		}
+	}
+
+	modified := true
+	switch w := p.word(); w {
+	case "open":
+		r.state = Open
+	case "closed", "off":
+		r.state = Closed
+	case "unknown":
+		r.state = Unknown
+	default:
+		modified = false
+	}
+
+	if modified {
+		p.pos += len(p.word())
+	}
+
+	if p.consume(`"`) {
+		end := strings.IndexByte(p.value[p.pos:], '"')
+		if end < 0 {
+			return nil, p.errorf(p.pos-1, "unterminated comment")
+		}
+
+		r.comment = p.value[p.pos : p.pos+end]
+		p.pos += end + 1
+
+		// a rule of only a comment is unknown
+		if !selected && !modified {
+			r.state = Unknown
+		}
+		modified = true
+	}
+
+	if !selected && !modified {
+		p.skipSpaces()
+		if p.pos < len(p.value) {
+			return nil, p.errorf(p.pos, "unexpected %q", p.value[p.pos:p.pos+1])
+		}
+
+		return nil, p.errorf(start, "expected rule")
+	}
+
+	return r, nil
+}
+
+// selectors parses the wide and small range selectors,
+// returns true if there was at least one.
+func (p *parser) selectors(r *rule) (bool, error) {
+	selected := false
+	for {
+		switch {
+		case p.isYear():
+			if err := p.years(r); err != nil {
+				return false, err
+			}
+		case p.word() == "week":
+			p.pos += len("week")
+			if err := p.weeks(r); err != nil {
+				return false, err
+			}
+		case months[p.word()] > 0:
+			if err := p.monthdays(r); err != nil {
+				return false, err
+			}
+		default:
+			if selected {
+				// the optional colon after the wide range selectors
+				p.consume(":")
+			}
+
+			return p.small(r, selected)
+		}
+
+		selected = true
+	}
+}
+
+func (p *parser) small(r *rule, selected bool) (bool, error) {
+	if _, ok := weekdays[p.word()]; ok || p.word() == "PH" {
+		if err := p.weekdays(r); err != nil {
+			return false, err
+		}
+		selected = true
+	} else if w := p.word(); w == "SH" {
+		return false, p.errorf(p.pos, "school holidays are not supported")
+	}
+
+	switch w := p.word(); w {
+	case "sunrise", "sunset", "dawn", "dusk":
+		return false, p.errorf(p.pos, "variable time %s is not supported", w)
+	}
+
+	if p.isTime() {
+		if err := p.times(r); err != nil {
+			return false, err
+		}
+		selected = true
+	}
+
+	return selected, nil
+}
+
+func (p *parser) years(r *rule) error {
+	for {
+		start, err := p.number()
+		if err != nil {
+			return err
+		}
+
+		y := yearRange{start: start, end: start}
+		if p.consume("-") {
+			p.skipSpaces()
+			pos := p.pos
+			if y.end, err = p.number(); err != nil {
+				return err
+			}
+
+			if y.end < y.start {
+				return p.errorf(pos, "year range ends before it starts")
+			}
+		}
+
+		r.years = append(r.years, y)
+		if !p.listContinues(p.isYear) {
+			return nil
+		}
+	}
+}
+
+func (p *parser) weeks(r *rule) error {
+	for {
+		p.skipSpaces()
+		pos := p.pos
+		start, err := p.number()
+		if err != nil {
+			return err
+		}
+
+		w := weekRange{start: start, end: start, step: 1}
+		if p.consume("-") {
+			if w.end, err = p.number(); err != nil {
+				return err
+			}
+		}
+
+		if p.consume("/") {
+			if w.step, err = p.number(); err != nil {
+				return err
+			}
+		}
+
+		if w.start < 1 || w.end > 53 || w.end < w.start || w.step < 1 {
+			return p.errorf(pos, "invalid week range")
+		}
+
+		r.weeks = append(r.weeks, w)
+		if !p.listContinues(func() bool { return p.digits() != "" && !p.isTime() }) {
+			return nil
+		}
+	}
+}
+
+func (p *parser) monthdays(r *rule) error {
+	for {
+		md := monthdayRange{}
+		var err error
+		if md.startMonth, md.startDay, err = p.monthday(); err != nil {
+			return err
+		}
+
+		md.endMonth, md.endDay = md.startMonth, md.startDay
+		if p.consume("-") {
+			if months[p.word()] > 0 {
+				if md.endMonth, md.endDay, err = p.monthday(); err != nil {
+					return err
+				}
+			} else if md.startDay > 0 {
+				p.skipSpaces()
+				pos := p.pos
+				if md.endDay, err = p.number(); err != nil {
+					return err
+				}
+
+				if md.endDay < 1 || md.endDay > 31 {
+					return p.errorf(pos, "invalid day")
+				}
+			} else {
+				return p.errorf(p.pos, "expected month")
+			}
+		}
+
+		if (md.startDay == 0) != (md.endDay == 0) {
+			return p.errorf(p.pos, "month range mixes months and days")
+		}
+
+		r.monthdays = append(r.monthdays, md)
+		if !p.listContinues(func() bool { return months[p.word()] > 0 }) {
+			return nil
+		}
+	}
+}
+
+// monthday parses a month with an optional day, e.g. Dec or Dec 24.
+func (p *parser) monthday() (int, int, error) {
+	w := p.word()
+	m := months[w]
+	if m == 0 {
+		return 0, 0, p.errorf(p.pos, "expected month")
+	}
+	p.pos += len(w)
+
+	if p.digits() == "" || p.isTime() {
+		return m, 0, nil
+	}
+
+	p.skipSpaces()
+	pos := p.pos
+	d, err := p.number()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if d < 1 || d > 31 {
+		return 0, 0, p.errorf(pos, "invalid day")
+	}
+
+	return m, d, nil
+}
+
+func (p *parser) weekdays(r *rule) error {
+	for {
+		w := p.word()
+		if w == "PH" {
+			p.pos += len(w)
+			r.holiday = true
+		} else {
+			start, ok := weekdays[w]
+			if !ok {
+				return p.errorf(p.pos, "expected weekday")
+			}
+			p.pos += len(w)
+
+			wd := weekdayRange{start: start, end: start}
+			if p.consume("-") {
+				end, ok := weekdays[p.word()]
+				if !ok {
+					return p.errorf(p.pos, "expected weekday")
+				}
+				p.pos += len(p.word())
+				wd.end = end
+			}
+
+			if p.consume("[") {
+				if err := p.nths(&wd); err != nil {
+					return err
+				}
+			}
+
+			r.weekdays = append(r.weekdays, wd)
+		}
+
+		if !p.listContinues(func() bool {
+			_, ok := weekdays[p.word()]
+			return ok || p.word() == "PH"
+		}) {
+			return nil
+		}
+	}
+}
+
+// nths parses the nth weekdays of the month, e.g. [1,-1] or [1-2].
+func (p *parser) nths(wd *weekdayRange) error {
+	for {
+		p.skipSpaces()
+		pos := p.pos
+		start, err := p.number()
+		if err != nil {
+			return err
+		}
+
+		end := start
+		if p.consume("-") {
+			if end, err = p.number(); err != nil {
+				return err
+			}
+		}
+
+		if start == 0 || start < -5 || end > 5 || end < start || (start < 0) != (end < 0) {
+			return p.errorf(pos, "invalid nth weekday")
+		}
+
+		for n := start; n <= end; n++ {
+			wd.nth = append(wd.nth, n)
+		}
+
+		if p.consume("]") {
+			return nil
+		}
+
+		if !p.consume(",") {
+			return p.errorf(p.pos, "expected ]")
+		}
+	}
+}
+
+func (p *parser) times(r *rule) error {
+	for {
+		p.skipSpaces()
+		pos := p.pos
+		start, err := p.time()
+		if err != nil {
+			return err
+		}
+
+		span := timeSpan{start: start}
+		switch {
+		case p.consume("+"):
+			span.end, span.openEnd = minutesPerDay, true
+		case p.consume("-"):
+			endPos := p.pos
+			if span.end, err = p.time(); err != nil {
+				return err
+			}
+
+			if span.end == span.start {
+				return p.errorf(endPos, "empty time span")
+			}
+
+			// spans past midnight
+			if span.end < span.start {
+				span.end += minutesPerDay
+			}
+
+			if span.openEnd = p.consume("+"); span.openEnd {
+				return p.errorf(p.pos-1, "open end after a time span is not supported")
+			}
+		default:
+			return p.errorf(p.pos, "expected - or +")
+		}
+
+		if span.start >= minutesPerDay {
+			return p.errorf(pos, "time span starts after midnight")
+		}
+
+		r.times = append(r.times, span)
+		if !p.listContinues(p.isTime) {
+			return nil
+		}
+	}
+}
+
+// time parses a time like 08:30 in minutes, up to 48:00.
+func (p *parser) time() (int, error) {
+	if !p.isTime() {
+		switch w := p.word(); w {
+		case "sunrise", "sunset", "dawn", "dusk":
+			return 0, p.errorf(p.pos, "variable time %s is not supported", w)
+		}
+
+		return 0, p.errorf(p.pos, "expected time")
+	}
+
+	pos := p.pos
+	h, _ := p.number()
+	p.consume(":")
+	m := p.digits()
+	if len(m) != 2 {
+		return 0, p.errorf(p.pos, "expected minutes")
+	}
+	p.pos += 2
+
+	minutes, _ := strconv.Atoi(m)
+	if h > 48 || minutes > 59 || (h == 48 && minutes > 0) {
+		return 0, p.errorf(pos, "invalid time")
+	}
+
+	return h*60 + minutes, nil
+}
+
+// listContinues consumes a comma if it is followed by another item
+// of the list. Otherwise the comma separates rules and is not consumed.
+func (p *parser) listContinues(next func() bool) bool {
+	pos := p.pos
+	if !p.consume(",") {
+		return false
+	}
+
+	if next() {
+		return true
+	}
+
+	p.pos = pos
+	return false
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
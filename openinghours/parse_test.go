@@ -0,0 +1,78 @@
+package openinghours
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	values := []string{
+		"24/7",
+		"Mo-Fr 08:00-18:00",
+		"Mo-Fr 08:00-12:00,13:00-17:30; Sa 10:00-14:00; PH off",
+		"Mo-Sa 10:00-20:00; Su off",
+		"Fr-Sa 22:00-03:00",
+		"Mo 10:00-12:00, We off",
+		"Mo-Fr 08:00-12:00 || \"by appointment\"",
+		"\"call us\"",
+		"Jan-Mar Mo-Fr 10:00-16:00",
+		"Dec 24-26 off",
+		"Dec 24-Jan 06: 10:00-14:00",
+		"week 1-53/2 Mo 10:00-12:00",
+		"2024-2025 Sa 10:00-12:00",
+		"Su[1,-1] 10:00-12:00",
+		"Mo-Fr 17:00+",
+		"Sa unknown \"maybe\"",
+		"Mo-Fr 08:00-18:00 open",
+	}
+
+	for _, v := range values {
+		t.Run(v, func(t *testing.T) {
+			oh, err := Parse(v)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if oh.String() != v {
+				t.Errorf("incorrect string: %v", oh.String())
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	cases := []struct {
+		value  string
+		offset int
+	}{
+		{value: "", offset: 0},
+		{value: "Mo-Fr 08:00-", offset: 12},
+		{value: "Mo-Xy 08:00-12:00", offset: 3},
+		{value: "Mo-Fr 8:0-12:00", offset: 6},
+		{value: "Mo-Fr 08:00-18:00;", offset: 18},
+		{value: "Mo-Fr 25:00-49:00", offset: 12},
+		{value: "Mo-Fr 08:00-08:00", offset: 12},
+		{value: "Mo-Fr sunrise-sunset", offset: 6},
+		{value: "Mo-Fr 08:00-18:00 \"open", offset: 18},
+		{value: "Mo[0] 10:00-12:00", offset: 3},
+		{value: "week 0-10", offset: 5},
+		{value: "Mo-Fr 08:00-18:00 always", offset: 18},
+		{value: "Dec 32", offset: 4},
+		{value: "SH off", offset: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			_, err := Parse(tc.value)
+
+			var se *SyntaxError
+			if !errors.As(err, &se) {
+				t.Fatalf("expected syntax error: %v", err)
+			}
+
+			if se.Offset != tc.offset || se.Value != tc.value {
+				t.Errorf("incorrect offset: %v", err)
+			}
+		})
+	}
+}
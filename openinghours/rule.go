@@ -0,0 +1,151 @@
+package openinghours
+
+import "time"
+
+type ruleKind int
+
+const (
+	// normalRule, separated by ;, overrides the previous rules for the days it matches.
+	normalRule ruleKind = iota
+	// additionalRule, separated by a comma, adds to the previous rules.
+	additionalRule
+	// fallbackRule, separated by ||, applies if no previous rule matches the day.
+	fallbackRule
+)
+
+type rule struct {
+	kind ruleKind
+
+	years     []yearRange
+	weeks     []weekRange
+	monthdays []monthdayRange
+	weekdays  []weekdayRange
+	holiday   bool
+	// times are empty for the whole day
+	times []timeSpan
+
+	state   State
+	comment string
+}
+
+type yearRange struct {
+	start, end int
+}
+
+type weekRange struct {
+	start, end, step int
+}
+
+// monthdayRange is a range of months or days, the days are 0 for whole months.
+type monthdayRange struct {
+	startMonth, startDay int
+	endMonth, endDay     int
+}
+
+// weekdayRange is a range of weekdays, Sunday is 0. nth are the
+// weekdays of the month, e.g. 1 for the first and -1 for the last.
+type weekdayRange struct {
+	start, end int
+	nth        []int
+}
+
+// timeSpan is in minutes since the start of the day,
+// the end is past minutesPerDay for spans past midnight.
+type timeSpan struct {
+	start, end int
+	openEnd    bool
+}
+
+// matches returns true if the selectors of the rule match the day.
+func (r *rule) matches(date time.Time, holidays HolidayProvider) bool {
+	if len(r.years) > 0 && !r.matchesYear(date) {
+		return false
+	}
+
+	if len(r.weeks) > 0 && !r.matchesWeek(date) {
+		return false
+	}
+
+	if len(r.monthdays) > 0 && !r.matchesMonthday(date) {
+		return false
+	}
+
+	if len(r.weekdays) == 0 && !r.holiday {
+		return true
+	}
+
+	if r.holiday && holidays != nil && holidays.IsHoliday(date) {
+		return true
+	}
+
+	for _, wd := range r.weekdays {
+		if wd.matches(date) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *rule) matchesYear(date time.Time) bool {
+	for _, y := range r.years {
+		if date.Year() >= y.start && date.Year() <= y.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *rule) matchesWeek(date time.Time) bool {
+	_, week := date.ISOWeek()
+	for _, w := range r.weeks {
+		if week >= w.start && week <= w.end && (week-w.start)%w.step == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *rule) matchesMonthday(date time.Time) bool {
+	day := int(date.Month())*32 + date.Day()
+	for _, md := range r.monthdays {
+		start := md.startMonth*32 + max(md.startDay, 1)
+		end := md.endMonth*32 + md.endDay
+		if md.endDay == 0 {
+			end += 31
+		}
+
+		// ranges over the end of the year, e.g. Nov-Feb
+		if start <= end && day >= start && day <= end ||
+			start > end && (day >= start || day <= end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (wd weekdayRange) matches(date time.Time) bool {
+	day := int(date.Weekday())
+	if wd.start <= wd.end && (day < wd.start || day > wd.end) ||
+		wd.start > wd.end && day < wd.start && day > wd.end {
+		return false
+	}
+
+	if len(wd.nth) == 0 {
+		return true
+	}
+
+	daysInMonth := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+	n := (date.Day()-1)/7 + 1
+	last := -((daysInMonth-date.Day())/7 + 1)
+	for _, v := range wd.nth {
+		if v == n || v == last {
+			return true
+		}
+	}
+
+	return false
+}
@@ -20,6 +20,7 @@ type context struct {
 	noMeta                 bool
 	noRelationMembership   bool
 	includeInvalidPolygons bool
+	areaRules              *osm.AreaRules
 	osm                    *osm.OSM
 	wayMap                 map[osm.WayID]*osm.Way
 	skippable              map[osm.WayID]struct{}
@@ -168,7 +169,7 @@ func (ctx *context) wayToFeature(w *osm.Way) *geojson.Feature {
 	}
 
 	var f *geojson.Feature
-	if w.Polygon() {
+	if ctx.areaRules.Polygon(w) {
 		p := geo.Polygon{toRing(ls)}
 		reorient(p)
 		f = geojson.NewFeature(p)
@@ -261,6 +262,7 @@ func (ctx *context) buildRouteLineString(relation *osm.Relation) *geojson.Featur
 func Convert(o *osm.OSM, opts ...Option) (*geojson.FeatureCollection, error) {
 	ctx := &context{
 		osm:       o,
+		areaRules: osm.DefaultAreaRules(),
 		skippable: make(map[osm.WayID]struct{}),
 	}
 
@@ -1,5 +1,7 @@
 package osmgeojson
 
+import "github.com/pchchv/osm"
+
 // Option is a setting for creating the geojson.
 type Option func(*context) error
 
@@ -39,3 +41,16 @@ func IncludeInvalidPolygons(yes bool) Option {
 		return nil
 	}
 }
+
+// AreaRules sets the rules used to determine if a closed way is a polygon.
+// The default, also used if nil, is osm.DefaultAreaRules().
+func AreaRules(rules *osm.AreaRules) Option {
+	return func(ctx *context) error {
+		if rules == nil {
+			rules = osm.DefaultAreaRules()
+		}
+
+		ctx.areaRules = rules
+		return nil
+	}
+}
@@ -148,6 +148,43 @@ func TestOptionNoRelationMembership(t *testing.T) {
 	})
 }
 
+func TestOptionAreaRules(t *testing.T) {
+	data := `
+<osm>
+  <node id="1" lat="1" lon="1" />
+  <node id="2" lat="2" lon="1" />
+  <node id="3" lat="2" lon="2" />
+  <way id="10">
+    <nd ref="1" /><nd ref="2" /><nd ref="3" /><nd ref="1" />
+    <tag k="man_made" v="pier" />
+  </way>
+</osm>`
+
+	feature := convertXML(t, data).Features[0]
+	if v := feature.Geometry.GeoJSONType(); v != "Polygon" {
+		t.Errorf("should be polygon by default: %v", v)
+	}
+
+	rules, err := osm.NewAreaRules(osm.AreaRule{
+		Key:       "man_made",
+		Condition: osm.AreaBlacklist,
+		Values:    []string{"pier"},
+	})
+	if err != nil {
+		t.Fatalf("invalid rules: %v", err)
+	}
+
+	feature = convertXML(t, data, AreaRules(rules)).Features[0]
+	if v := feature.Geometry.GeoJSONType(); v != "LineString" {
+		t.Errorf("should be line string with rules: %v", v)
+	}
+
+	feature = convertXML(t, data, AreaRules(nil)).Features[0]
+	if v := feature.Geometry.GeoJSONType(); v != "Polygon" {
+		t.Errorf("should use default rules if nil: %v", v)
+	}
+}
+
 func convertXML(t *testing.T, data string, opts ...Option) *geojson.FeatureCollection {
 	o := &osm.OSM{}
 	err := xml.Unmarshal([]byte(data), &o)
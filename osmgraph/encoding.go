@@ -0,0 +1,156 @@
+package osmgraph
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+const formatVersion = 1
+
+var (
+	_ encoding.BinaryMarshaler   = &Graph{}
+	_ encoding.BinaryUnmarshaler = &Graph{}
+
+	// ErrInvalidData is returned when unmarshalling data
+	// that was not created by MarshalBinary.
+	ErrInvalidData = errors.New("osmgraph: invalid data")
+)
+
+// MarshalBinary encodes the graph into a compact binary format.
+// The node ids are delta encoded, the locations are stored as floats.
+func (g *Graph) MarshalBinary() ([]byte, error) {
+	data := []byte{formatVersion}
+	data = binary.AppendUvarint(data, uint64(len(g.mode)))
+	data = append(data, g.mode...)
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(g.maxSpeed))
+
+	data = binary.AppendUvarint(data, uint64(len(g.edges)))
+	for _, e := range g.edges {
+		data = binary.AppendVarint(data, int64(e.WayID))
+		data = binary.AppendUvarint(data, uint64(len(e.Nodes)))
+
+		var prev int64
+		for i, id := range e.Nodes {
+			data = binary.AppendVarint(data, int64(id)-prev)
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(e.Line[i][0]))
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(e.Line[i][1]))
+			prev = int64(id)
+		}
+
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(e.Length))
+		data = binary.AppendVarint(data, int64(e.Duration))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes data created by MarshalBinary,
+// replacing the current graph.
+func (g *Graph) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return ErrInvalidData
+	}
+
+	d := &decoder{data: data[1:]}
+	mode := Mode(d.bytes(int(d.uvarint())))
+	maxSpeed := d.float()
+	if d.err == nil && !mode.valid() {
+		return ErrInvalidData
+	}
+
+	result := &Graph{
+		mode:     mode,
+		index:    make(map[osm.NodeID]int),
+		interior: make(map[osm.NodeID][]edgePosition),
+	}
+
+	count := d.uvarint()
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		e := &Edge{WayID: osm.WayID(d.varint())}
+		// every node is at least 17 bytes
+		n := d.uvarint()
+		if n < 2 || n > uint64(len(d.data)/17) {
+			return ErrInvalidData
+		}
+
+		e.Nodes = make([]osm.NodeID, n)
+		e.Line = make(geo.LineString, n)
+
+		var prev int64
+		for j := range e.Nodes {
+			prev += d.varint()
+			e.Nodes[j] = osm.NodeID(prev)
+			e.Line[j] = geo.Point{d.float(), d.float()}
+		}
+
+		e.From, e.To = e.Nodes[0], e.Nodes[n-1]
+		e.Length = d.float()
+		e.Duration = time.Duration(d.varint())
+		result.insertEdge(e, 0)
+	}
+
+	if d.err != nil || len(d.data) != 0 {
+		return ErrInvalidData
+	}
+
+	result.maxSpeed = maxSpeed
+	*g = *result
+
+	return nil
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, l := binary.Uvarint(d.data)
+	if l <= 0 {
+		d.err = ErrInvalidData
+		d.data = nil
+		return 0
+	}
+
+	d.data = d.data[l:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	v, l := binary.Varint(d.data)
+	if l <= 0 {
+		d.err = ErrInvalidData
+		d.data = nil
+		return 0
+	}
+
+	d.data = d.data[l:]
+	return v
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if n < 0 || n > len(d.data) {
+		d.err = ErrInvalidData
+		d.data = nil
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) float() float64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
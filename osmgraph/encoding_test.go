@@ -0,0 +1,57 @@
+package osmgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraph_MarshalBinary(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := g.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	result := &Graph{}
+	if err := result.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, g) {
+		t.Errorf("graphs not equal")
+	}
+
+	p1, _ := g.AStar(4, 3, Fastest)
+	p2, _ := result.AStar(4, 3, Fastest)
+	if !reflect.DeepEqual(p1, p2) {
+		t.Errorf("paths not equal: %v %v", p1, p2)
+	}
+}
+
+func TestGraph_UnmarshalBinary_invalid(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Foot, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := g.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	for i := 0; i < len(data); i++ {
+		if err := (&Graph{}).UnmarshalBinary(data[:i]); err != ErrInvalidData {
+			t.Errorf("truncated at %d: expected invalid data, got %v", i, err)
+		}
+	}
+
+	if err := (&Graph{}).UnmarshalBinary(append(data, 0)); err != ErrInvalidData {
+		t.Errorf("expected invalid data for trailing bytes: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package osmgraph
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+var (
+	// ErrUnknownMode is returned by New for modes other than Car, Bicycle and Foot.
+	ErrUnknownMode = errors.New("osmgraph: unknown mode")
+
+	_ error = &MissingNodeError{}
+)
+
+// MissingNodeError is returned by New if the location of
+// a node of a routable way is not known.
+type MissingNodeError struct {
+	WayID  osm.WayID
+	NodeID osm.NodeID
+}
+
+// Error returns a pretty string of the error.
+func (e *MissingNodeError) Error() string {
+	return fmt.Sprintf("osmgraph: node %d of way %d not found", e.NodeID, e.WayID)
+}
+
+// Graph is a directed routing graph for one mode of transport.
+// The vertices are the nodes shared by routable ways and the
+// way endpoints, the edges are the parts of the ways between them.
+// A graph is safe for concurrent routing.
+type Graph struct {
+	mode     Mode
+	vertices []osm.NodeID
+	points   []geo.Point
+	index    map[osm.NodeID]int
+	edges    []*Edge
+	// out are the outgoing edge indexes by vertex
+	out [][]int
+
+	// interior maps the nodes inside of edges to the edges and position
+	interior map[osm.NodeID][]edgePosition
+	// maxSpeed in km/h of all the edges, for the A* heuristic
+	maxSpeed float64
+}
+
+// Edge is a directed part of a way between two vertices.
+// Nodes and Line are in the direction of travel.
+type Edge struct {
+	From     osm.NodeID
+	To       osm.NodeID
+	WayID    osm.WayID
+	Nodes    []osm.NodeID
+	Line     geo.LineString
+	Length   float64 // geodesic length in meters on the WGS84 ellipsoid
+	Duration time.Duration
+}
+
+type edgePosition struct {
+	edge  int
+	index int
+}
+
+// New builds the graph for the mode from the ways, the locations are taken
+// from the nodes or, if not found, from the annotated way nodes.
+// Ways that are not routable by the mode are ignored.
+// A MissingNodeError is returned if the location of a node is not known.
+func New(mode Mode, ways osm.Ways, nodes osm.Nodes) (*Graph, error) {
+	if !mode.valid() {
+		return nil, ErrUnknownMode
+	}
+
+	points := make(map[osm.NodeID]geo.Point, len(nodes))
+	for _, n := range nodes {
+		points[n.ID] = n.Point()
+	}
+
+	type routable struct {
+		way    *osm.Way
+		access access
+		line   geo.LineString
+	}
+
+	var rs []routable
+	usage := make(map[osm.NodeID]int)
+	for _, w := range ways {
+		a := wayAccess(w.Tags, mode)
+		if (!a.forward && !a.backward) || len(w.Nodes) < 2 {
+			continue
+		}
+
+		line := make(geo.LineString, len(w.Nodes))
+		for i, wn := range w.Nodes {
+			p, ok := points[wn.ID]
+			if !ok {
+				if wn.Version == 0 && wn.Lon == 0 && wn.Lat == 0 {
+					return nil, &MissingNodeError{WayID: w.ID, NodeID: wn.ID}
+				}
+				p = wn.Point()
+			}
+
+			line[i] = p
+			usage[wn.ID]++
+		}
+
+		// the endpoints are always vertices
+		usage[w.Nodes[0].ID]++
+		usage[w.Nodes[len(w.Nodes)-1].ID]++
+		rs = append(rs, routable{way: w, access: a, line: line})
+	}
+
+	g := &Graph{
+		mode:     mode,
+		index:    make(map[osm.NodeID]int),
+		interior: make(map[osm.NodeID][]edgePosition),
+	}
+
+	for _, r := range rs {
+		start := 0
+		for i := 1; i < len(r.way.Nodes); i++ {
+			if usage[r.way.Nodes[i].ID] < 2 {
+				continue
+			}
+
+			ids := make([]osm.NodeID, i-start+1)
+			for j := range ids {
+				ids[j] = r.way.Nodes[start+j].ID
+			}
+
+			line := r.line[start : i+1]
+			if r.access.forward {
+				g.addEdge(r.way.ID, ids, line, r.access.forwardSpeed)
+			}
+
+			if r.access.backward {
+				g.addEdge(r.way.ID, reverseIDs(ids), reverseLine(line), r.access.backwardSpeed)
+			}
+
+			start = i
+		}
+	}
+
+	return g, nil
+}
+
+func (g *Graph) addEdge(way osm.WayID, ids []osm.NodeID, line geo.LineString, speed float64) {
+	line = append(geo.LineString(nil), line...)
+	e := &Edge{
+		From:   ids[0],
+		To:     ids[len(ids)-1],
+		WayID:  way,
+		Nodes:  ids,
+		Line:   line,
+		Length: measure.Length(line),
+	}
+
+	d, ok := duration(e.Length, speed)
+	if !ok {
+		return
+	}
+	e.Duration = d
+
+	g.insertEdge(e, speed)
+}
+
+// insertEdge adds the edge to the indexes of the graph.
+func (g *Graph) insertEdge(e *Edge, speed float64) {
+	from := g.vertex(e.From, e.Line[0])
+	g.vertex(e.To, e.Line[len(e.Line)-1])
+
+	g.out[from] = append(g.out[from], len(g.edges))
+	for i := 1; i < len(e.Nodes)-1; i++ {
+		g.interior[e.Nodes[i]] = append(g.interior[e.Nodes[i]], edgePosition{edge: len(g.edges), index: i})
+	}
+
+	g.edges = append(g.edges, e)
+	g.maxSpeed = max(g.maxSpeed, speed)
+}
+
+func (g *Graph) vertex(id osm.NodeID, p geo.Point) int {
+	if i, ok := g.index[id]; ok {
+		return i
+	}
+
+	g.index[id] = len(g.vertices)
+	g.vertices = append(g.vertices, id)
+	g.points = append(g.points, p)
+	g.out = append(g.out, nil)
+
+	return len(g.vertices) - 1
+}
+
+// Mode returns the mode of transport of the graph.
+func (g *Graph) Mode() Mode {
+	return g.mode
+}
+
+// Edges returns all the edges of the graph.
+// The edges must not be modified.
+func (g *Graph) Edges() []*Edge {
+	return g.edges
+}
+
+// Outgoing returns the edges starting at the node,
+// nil if the node is not a vertex of the graph.
+// The edges must not be modified.
+func (g *Graph) Outgoing(id osm.NodeID) []*Edge {
+	v, ok := g.index[id]
+	if !ok {
+		return nil
+	}
+
+	result := make([]*Edge, len(g.out[v]))
+	for i, e := range g.out[v] {
+		result[i] = g.edges[e]
+	}
+
+	return result
+}
+
+// Contains returns true if the node is part of the graph,
+// either as a vertex or inside an edge.
+func (g *Graph) Contains(id osm.NodeID) bool {
+	_, ok := g.index[id]
+	return ok || len(g.interior[id]) > 0
+}
+
+// duration returns the time to travel the length in meters at the speed in km/h.
+// It returns false if the speed is not positive.
+func duration(length, speed float64) (time.Duration, bool) {
+	if speed <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(length / (speed / 3.6) * float64(time.Second)), true
+}
+
+func reverseIDs(ids []osm.NodeID) []osm.NodeID {
+	result := make([]osm.NodeID, len(ids))
+	for i, id := range ids {
+		result[len(ids)-1-i] = id
+	}
+
+	return result
+}
+
+func reverseLine(line geo.LineString) geo.LineString {
+	result := make(geo.LineString, len(line))
+	for i, p := range line {
+		result[len(line)-1-i] = p
+	}
+
+	return result
+}
@@ -0,0 +1,167 @@
+package osmgraph
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pchchv/osm"
+)
+
+// testNetwork returns a small network:
+//
+//	5 ---12--- 4
+//	|          | 11 (oneway up)
+//	1 -2-7-10- 3
+//
+// way 10 is residential, way 12 is primary.
+func testNetwork() (osm.Ways, osm.Nodes) {
+	nodes := osm.Nodes{
+		{ID: 1, Lon: 0, Lat: 0},
+		{ID: 2, Lon: 0.01, Lat: 0},
+		{ID: 7, Lon: 0.015, Lat: 0},
+		{ID: 3, Lon: 0.02, Lat: 0},
+		{ID: 4, Lon: 0.02, Lat: 0.005},
+		{ID: 5, Lon: 0, Lat: 0.01},
+		{ID: 30, Lon: 1, Lat: 1},
+		{ID: 31, Lon: 1.01, Lat: 1},
+	}
+
+	ways := osm.Ways{
+		{
+			ID:    10,
+			Nodes: osm.WayNodes{{ID: 1}, {ID: 2}, {ID: 7}, {ID: 3}},
+			Tags:  osm.Tags{{Key: "highway", Value: "residential"}},
+		},
+		{
+			ID:    11,
+			Nodes: osm.WayNodes{{ID: 3}, {ID: 4}},
+			Tags:  osm.Tags{{Key: "highway", Value: "residential"}, {Key: "oneway", Value: "yes"}},
+		},
+		{
+			ID:    12,
+			Nodes: osm.WayNodes{{ID: 4}, {ID: 5}, {ID: 1}},
+			Tags:  osm.Tags{{Key: "highway", Value: "primary"}},
+		},
+		{
+			ID:    13,
+			Nodes: osm.WayNodes{{ID: 2}, {ID: 5}},
+			Tags:  osm.Tags{{Key: "highway", Value: "footway"}},
+		},
+		{
+			ID:    20,
+			Nodes: osm.WayNodes{{ID: 30}, {ID: 31}},
+			Tags:  osm.Tags{{Key: "highway", Value: "residential"}},
+		},
+		{
+			ID:    21,
+			Nodes: osm.WayNodes{{ID: 1}, {ID: 3}},
+			Tags:  osm.Tags{{Key: "building", Value: "yes"}},
+		},
+	}
+
+	return ways, nodes
+}
+
+func TestNew(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if g.Mode() != Car {
+		t.Errorf("incorrect mode: %v", g.Mode())
+	}
+
+	// 10, 12 and 20 both directions, 11 one way, 21 and the footway are ignored
+	if l := len(g.Edges()); l != 7 {
+		t.Errorf("incorrect number of edges: %d", l)
+	}
+
+	out := g.Outgoing(1)
+	if len(out) != 2 {
+		t.Fatalf("incorrect outgoing edges: %v", out)
+	}
+
+	e := out[0]
+	if e.WayID != 10 || e.From != 1 || e.To != 3 || !reflect.DeepEqual(e.Nodes, []osm.NodeID{1, 2, 7, 3}) {
+		t.Errorf("incorrect edge: %+v", e)
+	}
+
+	if math.Abs(e.Length-0.02*111319) > 10 {
+		t.Errorf("incorrect length: %v", e.Length)
+	}
+
+	if d := e.Duration.Seconds(); math.Abs(d-e.Length/(30/3.6)) > 0.001 {
+		t.Errorf("incorrect duration: %v", d)
+	}
+
+	// the length is geodesic, a degree of latitude is shorter at the equator
+	for _, e := range g.Outgoing(3) {
+		if e.WayID == 11 && math.Abs(e.Length-0.005*110574.389) > 0.01 {
+			t.Errorf("incorrect meridian length: %v", e.Length)
+		}
+	}
+
+	if out := g.Outgoing(4); len(out) != 1 || out[0].WayID != 12 {
+		t.Errorf("oneway should not be outgoing from 4: %v", out)
+	}
+
+	if out := g.Outgoing(2); out != nil {
+		t.Errorf("2 is not a vertex: %v", out)
+	}
+
+	if !g.Contains(2) || g.Contains(99) {
+		t.Errorf("incorrect contains")
+	}
+
+	// with the footway 2 and 5 are vertices
+	g, err = New(Foot, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out := g.Outgoing(2); len(out) != 3 {
+		t.Errorf("incorrect foot edges from 2: %v", out)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	ways, nodes := testNetwork()
+	if _, err := New("boat", ways, nodes); err != ErrUnknownMode {
+		t.Errorf("expected unknown mode: %v", err)
+	}
+
+	_, err := New(Car, ways, nodes[1:])
+	var mne *MissingNodeError
+	if !errors.As(err, &mne) || mne.NodeID != 1 || mne.WayID != 10 {
+		t.Errorf("expected missing node error: %v", err)
+	}
+
+	// annotated way nodes are used
+	for _, w := range ways {
+		for i := range w.Nodes {
+			w.Nodes[i].Lon = 1
+			w.Nodes[i].Lat = 1
+		}
+	}
+
+	if _, err := New(Car, ways, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	if d, ok := duration(1000, 36); !ok || d != 100*time.Second {
+		t.Errorf("incorrect duration: %v %v", d, ok)
+	}
+
+	for _, speed := range []float64{0, -10} {
+		if _, ok := duration(1000, speed); ok {
+			t.Errorf("speed %v should be rejected", speed)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package osmgraph
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// The supported modes of transport.
+const (
+	Car     Mode = "car"
+	Bicycle Mode = "bicycle"
+	Foot    Mode = "foot"
+)
+
+// Default speeds in km/h.
+const (
+	bicycleSpeed = 15
+	footSpeed    = 5
+	walkSpeed    = 5
+	mphToKmh     = 1.609344
+	knotsToKmh   = 1.852
+
+	// carFallbackSpeed is used for highways without a default car speed
+	// that are opened to cars by an access tag, e.g. motor_vehicle=destination.
+	carFallbackSpeed = 10
+)
+
+// carSpeeds are the default car speeds in km/h by highway value,
+// used if the way has no maxspeed. A missing highway is not routable by car.
+var carSpeeds = map[string]float64{
+	"motorway":       110,
+	"motorway_link":  60,
+	"trunk":          90,
+	"trunk_link":     50,
+	"primary":        70,
+	"primary_link":   50,
+	"secondary":      60,
+	"secondary_link": 40,
+	"tertiary":       50,
+	"tertiary_link":  30,
+	"unclassified":   40,
+	"road":           40,
+	"residential":    30,
+	"service":        20,
+	"track":          15,
+	"living_street":  10,
+}
+
+// nonCarHighways are the highways routable by
+// bicycle and foot, but not by car.
+var nonCarHighways = map[string]bool{
+	"pedestrian": true,
+	"footway":    true,
+	"path":       true,
+	"cycleway":   true,
+	"steps":      true,
+	"bridleway":  true,
+}
+
+// unbuilt are the highway values of roads that do not exist (yet),
+// they are never routable whatever the access tags.
+var unbuilt = map[string]bool{
+	"proposed":     true,
+	"construction": true,
+	"abandoned":    true,
+	"razed":        true,
+}
+
+// genericAccessKeys are the access keys shared with other modes,
+// from the least to the most specific. They can only deny access,
+// e.g. access=yes does not open a footway to cars.
+var genericAccessKeys = map[Mode][]string{
+	Car:     {"access", "vehicle"},
+	Bicycle: {"access", "vehicle"},
+	Foot:    {"access"},
+}
+
+// accessKeys are the access keys specific to the modes,
+// from the least to the most specific. They deny or grant access.
+var accessKeys = map[Mode][]string{
+	Car:     {"motor_vehicle", "motorcar"},
+	Bicycle: {"bicycle"},
+	Foot:    {"foot"},
+}
+
+// deniedAccess are the access values that do not allow general routing.
+var deniedAccess = map[string]bool{
+	"no":           true,
+	"private":      true,
+	"agricultural": true,
+	"forestry":     true,
+	"delivery":     true,
+	"use_sidepath": true,
+	"discouraged":  true,
+}
+
+// Mode is a strong type for the mode of transport of a graph.
+type Mode string
+
+func (m Mode) valid() bool {
+	_, ok := accessKeys[m]
+	return ok
+}
+
+// access is the routing information of a way for a mode.
+type access struct {
+	forward, backward bool
+	// speeds in km/h
+	forwardSpeed, backwardSpeed float64
+}
+
+// wayAccess returns the directions the way can be traveled in
+// by the mode and the speeds. Both directions are false if
+// the way is not routable.
+func wayAccess(tags osm.Tags, mode Mode) access {
+	highway := tags.Find("highway")
+	if highway == "" || unbuilt[highway] || tags.Find("area") == "yes" {
+		return access{}
+	}
+
+	allowed := allowedByDefault(highway, mode)
+	for _, k := range genericAccessKeys[mode] {
+		if deniedAccess[tags.Find(k)] {
+			allowed = false
+		}
+	}
+
+	for _, k := range accessKeys[mode] {
+		if v := tags.Find(k); v != "" {
+			allowed = !deniedAccess[v]
+		}
+	}
+
+	if !allowed {
+		return access{}
+	}
+
+	forward, backward := oneway(tags, mode)
+	speed := defaultSpeed(highway, mode)
+	a := access{
+		forward:       forward,
+		backward:      backward,
+		forwardSpeed:  speed,
+		backwardSpeed: speed,
+	}
+
+	if v, ok := parseMaxSpeed(tags.Find("maxspeed")); ok {
+		a.forwardSpeed, a.backwardSpeed = v, v
+	}
+
+	if v, ok := parseMaxSpeed(tags.Find("maxspeed:forward")); ok {
+		a.forwardSpeed = v
+	}
+
+	if v, ok := parseMaxSpeed(tags.Find("maxspeed:backward")); ok {
+		a.backwardSpeed = v
+	}
+
+	if mode != Car {
+		// the maxspeed only limits the speed of bicycles and pedestrians
+		a.forwardSpeed = min(a.forwardSpeed, speed)
+		a.backwardSpeed = min(a.backwardSpeed, speed)
+	}
+
+	return a
+}
+
+func allowedByDefault(highway string, mode Mode) bool {
+	switch mode {
+	case Car:
+		_, ok := carSpeeds[highway]
+		return ok
+	case Bicycle:
+		if highway == "motorway" || highway == "motorway_link" ||
+			highway == "steps" || highway == "footway" || highway == "pedestrian" {
+			return false
+		}
+	case Foot:
+		if highway == "motorway" || highway == "motorway_link" || highway == "cycleway" {
+			return false
+		}
+	}
+
+	_, ok := carSpeeds[highway]
+	return ok || nonCarHighways[highway]
+}
+
+func defaultSpeed(highway string, mode Mode) float64 {
+	switch mode {
+	case Bicycle:
+		return bicycleSpeed
+	case Foot:
+		return footSpeed
+	}
+
+	if v, ok := carSpeeds[highway]; ok {
+		return v
+	}
+
+	return carFallbackSpeed
+}
+
+// oneway returns the allowed directions of travel for the mode.
+// Pedestrians ignore oneway unless oneway:foot is set.
+func oneway(tags osm.Tags, mode Mode) (forward, backward bool) {
+	value := tags.Find("oneway")
+	switch mode {
+	case Foot:
+		value = tags.Find("oneway:foot")
+	case Bicycle:
+		if v := tags.Find("oneway:bicycle"); v != "" {
+			value = v
+		} else if strings.HasPrefix(tags.Find("cycleway"), "opposite") {
+			value = "no"
+		}
+	}
+
+	switch value {
+	case "yes", "true", "1":
+		return true, false
+	case "-1", "reverse":
+		return false, true
+	case "no", "false", "0":
+		return true, true
+	}
+
+	if mode == Foot {
+		return true, true
+	}
+
+	junction := tags.Find("junction")
+	if junction == "roundabout" || junction == "circular" || tags.Find("highway") == "motorway" {
+		return true, false
+	}
+
+	return true, true
+}
+
+// parseMaxSpeed parses a maxspeed value in km/h, mph or knots.
+// Values like "none" and country specific zones are not supported.
+func parseMaxSpeed(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if value == "walk" {
+		return walkSpeed, true
+	}
+
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(value, "mph"):
+		factor = mphToKmh
+		value = strings.TrimSpace(strings.TrimSuffix(value, "mph"))
+	case strings.HasSuffix(value, "knots"):
+		factor = knotsToKmh
+		value = strings.TrimSpace(strings.TrimSuffix(value, "knots"))
+	case strings.HasSuffix(value, "km/h"):
+		value = strings.TrimSpace(strings.TrimSuffix(value, "km/h"))
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+
+	return v * factor, true
+}
@@ -0,0 +1,228 @@
+package osmgraph
+
+import (
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestWayAccess(t *testing.T) {
+	tags := func(kv ...string) osm.Tags {
+		var result osm.Tags
+		for i := 0; i < len(kv); i += 2 {
+			result = append(result, osm.Tag{Key: kv[i], Value: kv[i+1]})
+		}
+		return result
+	}
+
+	cases := []struct {
+		name     string
+		tags     osm.Tags
+		mode     Mode
+		forward  bool
+		backward bool
+		speed    float64
+	}{
+		{
+			name: "not a highway",
+			tags: tags("building", "yes"),
+			mode: Car,
+		},
+		{
+			name:     "primary by car",
+			tags:     tags("highway", "primary"),
+			mode:     Car,
+			forward:  true,
+			backward: true,
+			speed:    70,
+		},
+		{
+			name:    "oneway",
+			tags:    tags("highway", "residential", "oneway", "yes"),
+			mode:    Car,
+			forward: true,
+			speed:   30,
+		},
+		{
+			name:     "reverse oneway",
+			tags:     tags("highway", "residential", "oneway", "-1"),
+			mode:     Car,
+			backward: true,
+			speed:    30,
+		},
+		{
+			name:    "roundabout",
+			tags:    tags("highway", "primary", "junction", "roundabout"),
+			mode:    Car,
+			forward: true,
+			speed:   70,
+		},
+		{
+			name:     "oneway ignored by foot",
+			tags:     tags("highway", "residential", "oneway", "yes"),
+			mode:     Foot,
+			forward:  true,
+			backward: true,
+			speed:    5,
+		},
+		{
+			name:     "oneway bicycle exception",
+			tags:     tags("highway", "residential", "oneway", "yes", "oneway:bicycle", "no"),
+			mode:     Bicycle,
+			forward:  true,
+			backward: true,
+			speed:    15,
+		},
+		{
+			name:     "contraflow cycleway",
+			tags:     tags("highway", "residential", "oneway", "yes", "cycleway", "opposite_lane"),
+			mode:     Bicycle,
+			forward:  true,
+			backward: true,
+			speed:    15,
+		},
+		{
+			name: "footway by car",
+			tags: tags("highway", "footway"),
+			mode: Car,
+		},
+		{
+			name:     "pedestrian opened to cars",
+			tags:     tags("highway", "pedestrian", "motor_vehicle", "destination"),
+			mode:     Car,
+			forward:  true,
+			backward: true,
+			speed:    carFallbackSpeed,
+		},
+		{
+			name: "private",
+			tags: tags("highway", "service", "access", "private"),
+			mode: Car,
+		},
+		{
+			name: "footway with generic access",
+			tags: tags("highway", "footway", "access", "yes"),
+			mode: Car,
+		},
+		{
+			name: "footway with vehicle access",
+			tags: tags("highway", "footway", "vehicle", "yes"),
+			mode: Bicycle,
+		},
+		{
+			name: "proposed with access",
+			tags: tags("highway", "proposed", "proposed", "primary", "access", "yes"),
+			mode: Foot,
+		},
+		{
+			name: "construction with mode access",
+			tags: tags("highway", "construction", "motor_vehicle", "yes"),
+			mode: Car,
+		},
+		{
+			name:     "private but foot allowed",
+			tags:     tags("highway", "service", "access", "private", "foot", "yes"),
+			mode:     Foot,
+			forward:  true,
+			backward: true,
+			speed:    5,
+		},
+		{
+			name: "no motor vehicles",
+			tags: tags("highway", "residential", "motor_vehicle", "no"),
+			mode: Car,
+		},
+		{
+			name:     "no motor vehicles by bicycle",
+			tags:     tags("highway", "residential", "motor_vehicle", "no"),
+			mode:     Bicycle,
+			forward:  true,
+			backward: true,
+			speed:    15,
+		},
+		{
+			name: "motorway by bicycle",
+			tags: tags("highway", "motorway"),
+			mode: Bicycle,
+		},
+		{
+			name:     "maxspeed",
+			tags:     tags("highway", "primary", "maxspeed", "30 mph"),
+			mode:     Car,
+			forward:  true,
+			backward: true,
+			speed:    30 * mphToKmh,
+		},
+		{
+			name:     "maxspeed limits bicycles",
+			tags:     tags("highway", "living_street", "maxspeed", "walk"),
+			mode:     Bicycle,
+			forward:  true,
+			backward: true,
+			speed:    5,
+		},
+		{
+			name:     "area",
+			tags:     tags("highway", "pedestrian", "area", "yes"),
+			mode:     Foot,
+			forward:  false,
+			backward: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := wayAccess(tc.tags, tc.mode)
+			if a.forward != tc.forward || a.backward != tc.backward {
+				t.Errorf("incorrect directions: %v %v", a.forward, a.backward)
+			}
+
+			if a.forward && a.forwardSpeed != tc.speed {
+				t.Errorf("incorrect forward speed: %v", a.forwardSpeed)
+			}
+
+			if a.backward && a.backwardSpeed != tc.speed {
+				t.Errorf("incorrect backward speed: %v", a.backwardSpeed)
+			}
+		})
+	}
+}
+
+func TestWayAccess_directionalMaxSpeed(t *testing.T) {
+	a := wayAccess(osm.Tags{
+		{Key: "highway", Value: "primary"},
+		{Key: "maxspeed", Value: "50"},
+		{Key: "maxspeed:backward", Value: "30"},
+	}, Car)
+
+	if a.forwardSpeed != 50 || a.backwardSpeed != 30 {
+		t.Errorf("incorrect speeds: %v %v", a.forwardSpeed, a.backwardSpeed)
+	}
+}
+
+func TestParseMaxSpeed(t *testing.T) {
+	cases := []struct {
+		value string
+		speed float64
+		ok    bool
+	}{
+		{value: "50", speed: 50, ok: true},
+		{value: "50 km/h", speed: 50, ok: true},
+		{value: "20mph", speed: 20 * mphToKmh, ok: true},
+		{value: "10 knots", speed: 10 * knotsToKmh, ok: true},
+		{value: "walk", speed: walkSpeed, ok: true},
+		{value: "none"},
+		{value: "DE:urban"},
+		{value: "-5"},
+		{value: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			v, ok := parseMaxSpeed(tc.value)
+			if v != tc.speed || ok != tc.ok {
+				t.Errorf("incorrect result: %v %v", v, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package osmgraph
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+)
+
+// The weights to minimize when routing.
+const (
+	// Shortest minimizes the geodesic length of the path.
+	Shortest Weight = "length"
+	// Fastest minimizes the travel time of the path.
+	Fastest Weight = "duration"
+)
+
+var (
+	// ErrNodeNotFound is returned when routing from or to
+	// a node that is not part of the graph.
+	ErrNodeNotFound = errors.New("osmgraph: node not found in graph")
+	// ErrNoPath is returned if the nodes are not connected.
+	ErrNoPath = errors.New("osmgraph: no path found")
+	// ErrUnknownWeight is returned for weights other than Shortest and Fastest.
+	ErrUnknownWeight = errors.New("osmgraph: unknown weight")
+)
+
+// Weight is a strong type for the value minimized when routing.
+type Weight string
+
+// Path is a route through the graph.
+type Path struct {
+	// Nodes are all the nodes passed, including the first and last.
+	Nodes []osm.NodeID
+	// Ways are the ways traveled in order, a way is repeated
+	// only if the path leaves and later returns to it.
+	Ways     []osm.WayID
+	Line     geo.LineString
+	Length   float64 // geodesic length in meters on the WGS84 ellipsoid
+	Duration time.Duration
+}
+
+// ShortestPath finds the path between the nodes minimizing the weight
+// using Dijkstra's algorithm. The nodes can be any node of the
+// routable ways, they do not need to be junctions.
+func (g *Graph) ShortestPath(from, to osm.NodeID, w Weight) (*Path, error) {
+	return g.route(from, to, w, false)
+}
+
+// AStar finds the same path as ShortestPath using the A* algorithm, with
+// the geodesic distance to the destination as heuristic. It usually
+// visits fewer vertices, especially for short routes in large graphs.
+func (g *Graph) AStar(from, to osm.NodeID, w Weight) (*Path, error) {
+	return g.route(from, to, w, true)
+}
+
+// part is the section of an edge between two node indexes.
+// An edge of -1 is an empty part.
+type part struct {
+	edge int
+	i, j int
+}
+
+var noPart = part{edge: -1}
+
+func (g *Graph) partLength(p part) float64 {
+	if p.edge < 0 {
+		return 0
+	}
+
+	e := g.edges[p.edge]
+	if p.i == 0 && p.j == len(e.Nodes)-1 {
+		return e.Length
+	}
+
+	return measure.Length(e.Line[p.i : p.j+1])
+}
+
+func (g *Graph) partDuration(p part, length float64) time.Duration {
+	if p.edge < 0 {
+		return 0
+	}
+
+	e := g.edges[p.edge]
+	if e.Length == 0 {
+		return 0
+	}
+
+	if p.i == 0 && p.j == len(e.Nodes)-1 {
+		return e.Duration
+	}
+
+	return time.Duration(float64(e.Duration) * length / e.Length)
+}
+
+func (g *Graph) partCost(p part, w Weight) float64 {
+	l := g.partLength(p)
+	if w == Fastest {
+		return g.partDuration(p, l).Seconds()
+	}
+
+	return l
+}
+
+// endpoints returns the vertex and the part of the edge to or from it for
+// every edge the node is part of. For vertices the part is empty.
+func (g *Graph) endpoints(id osm.NodeID, start bool) ([]int, []part) {
+	if v, ok := g.index[id]; ok {
+		return []int{v}, []part{noPart}
+	}
+
+	var vertices []int
+	var parts []part
+	for _, pos := range g.interior[id] {
+		e := g.edges[pos.edge]
+		if start {
+			vertices = append(vertices, g.index[e.To])
+			parts = append(parts, part{edge: pos.edge, i: pos.index, j: len(e.Nodes) - 1})
+		} else {
+			vertices = append(vertices, g.index[e.From])
+			parts = append(parts, part{edge: pos.edge, i: 0, j: pos.index})
+		}
+	}
+
+	return vertices, parts
+}
+
+// point returns the location of a node in the graph.
+func (g *Graph) point(id osm.NodeID) geo.Point {
+	if v, ok := g.index[id]; ok {
+		return g.points[v]
+	}
+
+	pos := g.interior[id][0]
+	return g.edges[pos.edge].Line[pos.index]
+}
+
+func (g *Graph) route(from, to osm.NodeID, w Weight, astar bool) (*Path, error) {
+	if w != Shortest && w != Fastest {
+		return nil, ErrUnknownWeight
+	}
+
+	if !g.Contains(from) || !g.Contains(to) {
+		return nil, ErrNodeNotFound
+	}
+
+	if from == to {
+		return &Path{Nodes: []osm.NodeID{from}, Line: geo.LineString{g.point(from)}}, nil
+	}
+
+	// the heuristic is the geodesic distance, or time at max speed,
+	// it is never more than the length of a path of geodesic segments
+	target := g.point(to)
+	h := func(v int) float64 {
+		if !astar {
+			return 0
+		}
+
+		d := measure.Distance(g.points[v], target)
+		if w == Fastest {
+			return d / (g.maxSpeed / 3.6)
+		}
+
+		return d
+	}
+
+	dist := make([]float64, len(g.vertices))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	prev := make([]int, len(g.vertices))
+	startParts := make(map[int]part)
+
+	best := math.Inf(1)
+	bestVertex, bestTarget, direct := -1, noPart, noPart
+
+	// both nodes inside of the same edge
+	for _, fp := range g.interior[from] {
+		for _, tp := range g.interior[to] {
+			if fp.edge == tp.edge && fp.index < tp.index {
+				p := part{edge: fp.edge, i: fp.index, j: tp.index}
+				if c := g.partCost(p, w); c < best {
+					best, direct = c, p
+				}
+			}
+		}
+	}
+
+	targetVertices, targetParts := g.endpoints(to, false)
+	targets := make(map[int][]part, len(targetVertices))
+	for i, v := range targetVertices {
+		targets[v] = append(targets[v], targetParts[i])
+	}
+
+	q := &queue{}
+	startVertices, parts := g.endpoints(from, true)
+	for i, v := range startVertices {
+		c := g.partCost(parts[i], w)
+		if c < dist[v] {
+			dist[v], prev[v], startParts[v] = c, -1, parts[i]
+			heap.Push(q, item{vertex: v, dist: c, priority: c + h(v)})
+		}
+	}
+
+	for q.Len() > 0 {
+		it := heap.Pop(q).(item)
+		if it.dist > dist[it.vertex] {
+			continue
+		}
+
+		if it.priority >= best {
+			break
+		}
+
+		v := it.vertex
+		for _, tp := range targets[v] {
+			if c := dist[v] + g.partCost(tp, w); c < best {
+				best, bestVertex, bestTarget, direct = c, v, tp, noPart
+			}
+		}
+
+		for _, ei := range g.out[v] {
+			e := g.edges[ei]
+			u := g.index[e.To]
+			c := e.Length
+			if w == Fastest {
+				c = e.Duration.Seconds()
+			}
+
+			if d := dist[v] + c; d < dist[u] {
+				dist[u], prev[u] = d, ei
+				heap.Push(q, item{vertex: u, dist: d, priority: d + h(u)})
+			}
+		}
+	}
+
+	if direct.edge >= 0 {
+		return g.path(from, []part{direct}), nil
+	}
+
+	if bestVertex < 0 {
+		return nil, ErrNoPath
+	}
+
+	// walk back from the best vertex to the start
+	v := bestVertex
+	path := []part{bestTarget}
+	for prev[v] >= 0 {
+		e := g.edges[prev[v]]
+		path = append(path, part{edge: prev[v], i: 0, j: len(e.Nodes) - 1})
+		v = g.index[e.From]
+	}
+	path = append(path, startParts[v])
+
+	// reverse, removing the empty parts
+	var result []part
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].edge >= 0 {
+			result = append(result, path[i])
+		}
+	}
+
+	return g.path(from, result), nil
+}
+
+// path builds the path from the edge parts.
+func (g *Graph) path(from osm.NodeID, parts []part) *Path {
+	p := &Path{Nodes: []osm.NodeID{from}}
+	for k, pt := range parts {
+		e := g.edges[pt.edge]
+		if k == 0 {
+			p.Line = append(p.Line, e.Line[pt.i])
+		}
+
+		p.Nodes = append(p.Nodes, e.Nodes[pt.i+1:pt.j+1]...)
+		p.Line = append(p.Line, e.Line[pt.i+1:pt.j+1]...)
+		if len(p.Ways) == 0 || p.Ways[len(p.Ways)-1] != e.WayID {
+			p.Ways = append(p.Ways, e.WayID)
+		}
+
+		l := g.partLength(pt)
+		p.Length += l
+		p.Duration += g.partDuration(pt, l)
+	}
+
+	return p
+}
+
+type item struct {
+	vertex   int
+	dist     float64
+	priority float64
+}
+
+// queue is a min heap of the items by priority.
+type queue []item
+
+func (q queue) Len() int           { return len(q) }
+func (q queue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x any)        { *q = append(*q, x.(item)) }
+func (q *queue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,306 @@
+package osmgraph
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestGraph_ShortestPath(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		from   osm.NodeID
+		to     osm.NodeID
+		weight Weight
+		nodes  []osm.NodeID
+		ways   []osm.WayID
+	}{
+		{
+			name:   "shortest",
+			from:   1,
+			to:     4,
+			weight: Shortest,
+			nodes:  []osm.NodeID{1, 2, 7, 3, 4},
+			ways:   []osm.WayID{10, 11},
+		},
+		{
+			name:   "fastest",
+			from:   1,
+			to:     4,
+			weight: Fastest,
+			nodes:  []osm.NodeID{1, 5, 4},
+			ways:   []osm.WayID{12},
+		},
+		{
+			name:   "against oneway",
+			from:   3,
+			to:     4,
+			weight: Fastest,
+			nodes:  []osm.NodeID{3, 4},
+			ways:   []osm.WayID{11},
+		},
+		{
+			name:   "oneway must go around",
+			from:   4,
+			to:     3,
+			weight: Shortest,
+			nodes:  []osm.NodeID{4, 5, 1, 2, 7, 3},
+			ways:   []osm.WayID{12, 10},
+		},
+		{
+			name:   "interior nodes",
+			from:   2,
+			to:     5,
+			weight: Shortest,
+			nodes:  []osm.NodeID{2, 1, 5},
+			ways:   []osm.WayID{10, 12},
+		},
+		{
+			name:   "same edge",
+			from:   2,
+			to:     7,
+			weight: Shortest,
+			nodes:  []osm.NodeID{2, 7},
+			ways:   []osm.WayID{10},
+		},
+		{
+			name:   "same edge reverse",
+			from:   7,
+			to:     2,
+			weight: Shortest,
+			nodes:  []osm.NodeID{7, 2},
+			ways:   []osm.WayID{10},
+		},
+		{
+			name:   "same node",
+			from:   2,
+			to:     2,
+			weight: Shortest,
+			nodes:  []osm.NodeID{2},
+		},
+	}
+
+	for _, tc := range cases {
+		for _, astar := range []bool{false, true} {
+			t.Run(tc.name, func(t *testing.T) {
+				var p *Path
+				var err error
+				if astar {
+					p, err = g.AStar(tc.from, tc.to, tc.weight)
+				} else {
+					p, err = g.ShortestPath(tc.from, tc.to, tc.weight)
+				}
+
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(p.Nodes, tc.nodes) {
+					t.Errorf("incorrect nodes: %v", p.Nodes)
+				}
+
+				if !reflect.DeepEqual(p.Ways, tc.ways) {
+					t.Errorf("incorrect ways: %v", p.Ways)
+				}
+
+				if len(p.Line) != len(p.Nodes) {
+					t.Errorf("line and nodes should have the same length: %v", p.Line)
+				}
+			})
+		}
+	}
+}
+
+func TestGraph_ShortestPath_lengthAndDuration(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := g.ShortestPath(2, 7, Shortest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if math.Abs(p.Length-0.005*111319) > 1 {
+		t.Errorf("incorrect length: %v", p.Length)
+	}
+
+	if d := p.Duration.Seconds(); math.Abs(d-p.Length/(30/3.6)) > 0.001 {
+		t.Errorf("incorrect duration: %v", d)
+	}
+}
+
+func TestGraph_ShortestPath_noDefaultCarSpeed(t *testing.T) {
+	nodes := osm.Nodes{
+		{ID: 1, Lon: 0, Lat: 0},
+		{ID: 2, Lon: 0.01, Lat: 0},
+		{ID: 3, Lon: 0.02, Lat: 0},
+	}
+	ways := osm.Ways{
+		{
+			ID:    1,
+			Nodes: osm.WayNodes{{ID: 1}, {ID: 2}},
+			Tags:  osm.Tags{{Key: "highway", Value: "pedestrian"}, {Key: "motor_vehicle", Value: "destination"}},
+		},
+		{
+			ID:    2,
+			Nodes: osm.WayNodes{{ID: 2}, {ID: 3}},
+			Tags:  osm.Tags{{Key: "highway", Value: "residential"}},
+		},
+	}
+
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, e := range g.Edges() {
+		if e.Duration <= 0 {
+			t.Errorf("edge of way %d should have a positive duration: %v", e.WayID, e.Duration)
+		}
+	}
+
+	p, err := g.ShortestPath(1, 3, Fastest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(p.Nodes, []osm.NodeID{1, 2, 3}) {
+		t.Errorf("incorrect nodes: %v", p.Nodes)
+	}
+}
+
+func TestGraph_ShortestPath_errors(t *testing.T) {
+	ways, nodes := testNetwork()
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := g.ShortestPath(1, 30, Shortest); err != ErrNoPath {
+		t.Errorf("expected no path: %v", err)
+	}
+
+	if _, err := g.ShortestPath(1, 99, Shortest); err != ErrNodeNotFound {
+		t.Errorf("expected not found: %v", err)
+	}
+
+	if _, err := g.ShortestPath(1, 3, "cost"); err != ErrUnknownWeight {
+		t.Errorf("expected unknown weight: %v", err)
+	}
+}
+
+func TestGraph_AStar_matchesDijkstra(t *testing.T) {
+	ways, nodes := testGrid(15)
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 50; i++ {
+		from := nodes[r.Intn(len(nodes))].ID
+		to := nodes[r.Intn(len(nodes))].ID
+		for _, w := range []Weight{Shortest, Fastest} {
+			p1, err1 := g.ShortestPath(from, to, w)
+			p2, err2 := g.AStar(from, to, w)
+			if err1 != err2 {
+				t.Fatalf("different errors: %v %v", err1, err2)
+			}
+
+			if err1 != nil {
+				continue
+			}
+
+			if math.Abs(p1.Length-p2.Length) > 1e-6 || p1.Duration != p2.Duration {
+				t.Errorf("%d -> %d %s: different results: %v %v", from, to, w, p1.Length, p2.Length)
+			}
+		}
+	}
+}
+
+// testGrid returns a grid of n by n nodes connected by ways
+// with a random highway type and some oneways.
+func testGrid(n int) (osm.Ways, osm.Nodes) {
+	r := rand.New(rand.NewSource(1))
+	highways := []string{"primary", "secondary", "residential", "service"}
+
+	var nodes osm.Nodes
+	id := func(x, y int) osm.NodeID { return osm.NodeID(y*n + x + 1) }
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			nodes = append(nodes, &osm.Node{
+				ID:  id(x, y),
+				Lon: float64(x)*0.001 + r.Float64()*0.0003,
+				Lat: float64(y)*0.001 + r.Float64()*0.0003,
+			})
+		}
+	}
+
+	var ways osm.Ways
+	addWay := func(a, b osm.NodeID) {
+		tags := osm.Tags{{Key: "highway", Value: highways[r.Intn(len(highways))]}}
+		if r.Intn(5) == 0 {
+			tags = append(tags, osm.Tag{Key: "oneway", Value: "yes"})
+		}
+
+		ways = append(ways, &osm.Way{
+			ID:    osm.WayID(len(ways) + 1),
+			Nodes: osm.WayNodes{{ID: a}, {ID: b}},
+			Tags:  tags,
+		})
+	}
+
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if x+1 < n {
+				addWay(id(x, y), id(x+1, y))
+			}
+
+			if y+1 < n {
+				addWay(id(x, y), id(x, y+1))
+			}
+		}
+	}
+
+	return ways, nodes
+}
+
+func BenchmarkGraph_ShortestPath(b *testing.B) {
+	ways, nodes := testGrid(100)
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		g.ShortestPath(1, osm.NodeID(len(nodes)), Fastest)
+	}
+}
+
+func BenchmarkGraph_AStar(b *testing.B) {
+	ways, nodes := testGrid(100)
+	g, err := New(Car, ways, nodes)
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		g.AStar(1, osm.NodeID(len(nodes)), Fastest)
+	}
+}
@@ -51,12 +51,6 @@ type OSMFileTest struct {
 
 func (ft *OSMFileTest) downloadTestOSMFile() {
 	if _, err := os.Stat(ft.FileName); os.IsNotExist(err) {
-		out, err := os.Create(ft.FileName)
-		if err != nil {
-			ft.Fatal(err)
-		}
-		defer out.Close()
-
 		resp, err := http.Get(ft.FileURL)
 		if err != nil {
 			ft.Fatal(err)
@@ -67,7 +61,20 @@ func (ft *OSMFileTest) downloadTestOSMFile() {
 			ft.Fatalf("test status code invalid: %v", resp.StatusCode)
 		}
 
-		if _, err := io.Copy(out, resp.Body); err != nil {
+		// the file is only created after a successful request and removed
+		// if the download fails, so a broken file is never reused
+		out, err := os.Create(ft.FileName)
+		if err != nil {
+			ft.Fatal(err)
+		}
+
+		_, err = io.Copy(out, resp.Body)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			os.Remove(ft.FileName)
 			ft.Fatal(err)
 		}
 	} else if err != nil {
@@ -0,0 +1,461 @@
+package osmqa
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/internal/measure"
+	"github.com/pchchv/osm/internal/rtree"
+	"github.com/pchchv/osm/tagvalue"
+)
+
+var (
+	_ Check = &unclosedAreas{}
+	_ Check = &selfIntersections{}
+	_ Check = &highwayCrossings{}
+	_ Check = &duplicateNodes{}
+	_ Check = &orphanNodes{}
+	_ Check = &singleNodeWays{}
+	_ Check = &missingMembers{}
+)
+
+// UnclosedAreas returns a check for ways with tags describing an area
+// that are not closed. The rules are used to decide if the tags describe
+// an area, osm.DefaultAreaRules() if nil, see Way.Polygon.
+func UnclosedAreas(rules *osm.AreaRules) Check {
+	if rules == nil {
+		rules = osm.DefaultAreaRules()
+	}
+
+	return &unclosedAreas{rules: rules}
+}
+
+type unclosedAreas struct {
+	rules *osm.AreaRules
+}
+
+func (c *unclosedAreas) Check(e osm.Element) Issues {
+	w, ok := e.(*osm.Way)
+	if !ok || len(w.Nodes) < 2 || w.Nodes[0].ID == w.Nodes[len(w.Nodes)-1].ID || !c.rules.Area(w.Tags) {
+		return nil
+	}
+
+	i := &Issue{Kind: KindUnclosedArea, ID: w.FeatureID(), Message: "area is not closed"}
+	if ls, ok := line(w); ok {
+		i.Geometry = ls
+	}
+
+	return Issues{i}
+}
+
+func (c *unclosedAreas) Done() Issues {
+	return nil
+}
+
+// SelfIntersections returns a check for ways crossing themselves.
+// An issue is reported for every crossing. Ways without
+// the locations of all the nodes are not checked.
+func SelfIntersections() Check {
+	return &selfIntersections{}
+}
+
+type selfIntersections struct{}
+
+func (c *selfIntersections) Check(e osm.Element) Issues {
+	w, ok := e.(*osm.Way)
+	if !ok {
+		return nil
+	}
+
+	ls, ok := line(w)
+	if !ok {
+		return nil
+	}
+
+	var result Issues
+	for i := 1; i < len(ls); i++ {
+		b := segmentBound(ls[i-1], ls[i])
+		for j := i + 2; j < len(ls); j++ {
+			if !b.Intersects(segmentBound(ls[j-1], ls[j])) {
+				continue
+			}
+
+			if p, ok := measure.Crossing(ls[i-1], ls[i], ls[j-1], ls[j]); ok {
+				result = append(result, &Issue{
+					Kind:     KindSelfIntersection,
+					ID:       w.FeatureID(),
+					Message:  "way crosses itself",
+					Geometry: p,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+func (c *selfIntersections) Done() Issues {
+	return nil
+}
+
+// HighwayCrossings returns a check for highways crossing without a shared
+// node. Crossings are fine if one of the ways is a bridge or tunnel or the
+// ways are on different layers. Highway areas and highways not yet built,
+// e.g. highway=proposed, are not checked. The highways are kept in memory.
+func HighwayCrossings() Check {
+	return &highwayCrossings{}
+}
+
+type highwayCrossings struct {
+	ways  []*osm.Way
+	lines []geo.LineString
+}
+
+// unbuilt are the highway values of roads that do not exist (yet).
+var unbuilt = map[string]bool{
+	"proposed":     true,
+	"construction": true,
+	"abandoned":    true,
+	"razed":        true,
+}
+
+func (c *highwayCrossings) Check(e osm.Element) Issues {
+	w, ok := e.(*osm.Way)
+	if !ok {
+		return nil
+	}
+
+	if v := w.Tags.Find("highway"); v == "" || unbuilt[v] || w.Tags.Find("area") == "yes" {
+		return nil
+	}
+
+	if ls, ok := line(w); ok {
+		c.ways = append(c.ways, w)
+		c.lines = append(c.lines, ls)
+	}
+
+	return nil
+}
+
+func (c *highwayCrossings) Done() Issues {
+	tree := rtree.New()
+	for i, ls := range c.lines {
+		for j := 1; j < len(ls); j++ {
+			tree.Insert(segmentBound(ls[j-1], ls[j]), key(i, j))
+		}
+	}
+
+	var result Issues
+	for i, ls := range c.lines {
+		for j := 1; j < len(ls); j++ {
+			tree.Search(segmentBound(ls[j-1], ls[j]), func(v int64) bool {
+				k, l := int(v>>32), int(v&0xffffffff)
+				if k <= i || separated(c.ways[i], c.ways[k]) {
+					return true
+				}
+
+				other := c.lines[k]
+				if p, ok := measure.Crossing(ls[j-1], ls[j], other[l-1], other[l]); ok {
+					result = append(result, &Issue{
+						Kind:     KindHighwayCrossing,
+						ID:       c.ways[i].FeatureID(),
+						Related:  osm.FeatureIDs{c.ways[k].FeatureID()},
+						Message:  fmt.Sprintf("crosses %v without a shared node", c.ways[k].FeatureID()),
+						Geometry: p,
+					})
+				}
+
+				return true
+			})
+		}
+	}
+
+	return result
+}
+
+// separated returns true if the ways can cross without a shared node.
+func separated(a, b *osm.Way) bool {
+	if structure(a.Tags) || structure(b.Tags) {
+		return true
+	}
+
+	la, _ := tagvalue.Layer(a.Tags)
+	lb, _ := tagvalue.Layer(b.Tags)
+	return la != lb
+}
+
+func structure(tags osm.Tags) bool {
+	for _, k := range []string{"bridge", "tunnel"} {
+		if v := tags.Find(k); v != "" && v != "no" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DuplicateNodes returns a check for nodes at identical coordinates.
+// An issue is reported for the node with the lowest id with the other
+// nodes as related. Only the last version of every node is used, e.g. of
+// a history file, and deleted nodes, i.e. not visible without a location,
+// are skipped. The node locations are kept in memory.
+func DuplicateNodes() Check {
+	return &duplicateNodes{nodes: make(map[osm.NodeID]geo.Point)}
+}
+
+type duplicateNodes struct {
+	nodes map[osm.NodeID]geo.Point
+}
+
+func (c *duplicateNodes) Check(e osm.Element) Issues {
+	n, ok := e.(*osm.Node)
+	if !ok {
+		return nil
+	}
+
+	if deleted(n) {
+		delete(c.nodes, n.ID)
+		return nil
+	}
+
+	c.nodes[n.ID] = n.Point()
+	return nil
+}
+
+func (c *duplicateNodes) Done() Issues {
+	points := make(map[geo.Point][]osm.NodeID)
+	for id, p := range c.nodes {
+		points[p] = append(points[p], id)
+	}
+
+	var result Issues
+	for p, ids := range points {
+		if len(ids) < 2 {
+			continue
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		i := &Issue{
+			Kind:     KindDuplicateNode,
+			ID:       ids[0].FeatureID(),
+			Message:  fmt.Sprintf("%d nodes at the same location", len(ids)),
+			Geometry: p,
+		}
+
+		for _, id := range ids[1:] {
+			i.Related = append(i.Related, id.FeatureID())
+		}
+
+		result = append(result, i)
+	}
+
+	return result
+}
+
+// OrphanNodes returns a check for nodes without tags
+// that are not used by any way or relation. Only the last version of
+// every element is used, e.g. of a history file, and deleted elements
+// are skipped, see DuplicateNodes. The node ids of the ways and
+// relations are kept in memory.
+func OrphanNodes() Check {
+	return &orphanNodes{
+		untagged:  make(map[osm.NodeID]geo.Point),
+		ways:      make(map[osm.WayID][]osm.NodeID),
+		relations: make(map[osm.RelationID][]osm.NodeID),
+	}
+}
+
+type orphanNodes struct {
+	untagged  map[osm.NodeID]geo.Point
+	ways      map[osm.WayID][]osm.NodeID
+	relations map[osm.RelationID][]osm.NodeID
+}
+
+func (c *orphanNodes) Check(e osm.Element) Issues {
+	switch e := e.(type) {
+	case *osm.Node:
+		delete(c.untagged, e.ID)
+		if len(e.Tags) == 0 && !deleted(e) {
+			c.untagged[e.ID] = e.Point()
+		}
+	case *osm.Way:
+		delete(c.ways, e.ID)
+		if !deleted(e) {
+			c.ways[e.ID] = e.Nodes.NodeIDs()
+		}
+	case *osm.Relation:
+		delete(c.relations, e.ID)
+		if deleted(e) {
+			break
+		}
+
+		var ids []osm.NodeID
+		for _, m := range e.Members {
+			if m.Type == osm.TypeNode {
+				ids = append(ids, osm.NodeID(m.Ref))
+			}
+		}
+
+		if len(ids) > 0 {
+			c.relations[e.ID] = ids
+		}
+	}
+
+	return nil
+}
+
+func (c *orphanNodes) Done() Issues {
+	used := make(map[osm.NodeID]struct{})
+	for _, ids := range c.ways {
+		for _, id := range ids {
+			used[id] = struct{}{}
+		}
+	}
+
+	for _, ids := range c.relations {
+		for _, id := range ids {
+			used[id] = struct{}{}
+		}
+	}
+
+	var result Issues
+	for id, p := range c.untagged {
+		if _, ok := used[id]; ok {
+			continue
+		}
+
+		result = append(result, &Issue{
+			Kind:     KindOrphanNode,
+			ID:       id.FeatureID(),
+			Message:  "untagged node is not used",
+			Geometry: p,
+		})
+	}
+
+	return result
+}
+
+// SingleNodeWays returns a check for ways with fewer than two different nodes.
+func SingleNodeWays() Check {
+	return &singleNodeWays{}
+}
+
+type singleNodeWays struct{}
+
+func (c *singleNodeWays) Check(e osm.Element) Issues {
+	w, ok := e.(*osm.Way)
+	if !ok {
+		return nil
+	}
+
+	for _, wn := range w.Nodes {
+		if wn.ID != w.Nodes[0].ID {
+			return nil
+		}
+	}
+
+	i := &Issue{Kind: KindSingleNodeWay, ID: w.FeatureID(), Message: "way has a single node"}
+	if len(w.Nodes) == 0 {
+		i.Message = "way has no nodes"
+	} else if located(w.Nodes[0]) {
+		i.Geometry = w.Nodes[0].Point()
+	}
+
+	return Issues{i}
+}
+
+func (c *singleNodeWays) Done() Issues {
+	return nil
+}
+
+// MissingMembers returns a check for relations with members that are not in
+// the data. For extracts these are usually members outside of the extract.
+// Only the last version of every element is used, e.g. of a history file,
+// and deleted elements are missing, see DuplicateNodes.
+// The ids of all the elements are kept in memory.
+func MissingMembers() Check {
+	return &missingMembers{
+		seen:      make(map[osm.FeatureID]struct{}),
+		relations: make(map[osm.RelationID]*osm.Relation),
+	}
+}
+
+type missingMembers struct {
+	seen      map[osm.FeatureID]struct{}
+	relations map[osm.RelationID]*osm.Relation
+	// order are the relation ids in the order first seen
+	order []osm.RelationID
+}
+
+func (c *missingMembers) Check(e osm.Element) Issues {
+	id := e.FeatureID()
+	if deleted(e) {
+		delete(c.seen, id)
+	} else {
+		c.seen[id] = struct{}{}
+	}
+
+	if r, ok := e.(*osm.Relation); ok {
+		if _, ok := c.relations[r.ID]; !ok {
+			c.order = append(c.order, r.ID)
+		}
+
+		c.relations[r.ID] = r
+	}
+
+	return nil
+}
+
+func (c *missingMembers) Done() Issues {
+	var result Issues
+	for _, id := range c.order {
+		r := c.relations[id]
+		if deleted(r) || len(r.Members) == 0 {
+			continue
+		}
+
+		var missing osm.FeatureIDs
+		for _, m := range r.Members {
+			if _, ok := c.seen[m.FeatureID()]; !ok {
+				missing = append(missing, m.FeatureID())
+			}
+		}
+
+		if len(missing) > 0 {
+			result = append(result, &Issue{
+				Kind:    KindMissingMember,
+				ID:      r.FeatureID(),
+				Related: missing,
+				Message: fmt.Sprintf("%d of %d members missing", len(missing), len(r.Members)),
+			})
+		}
+	}
+
+	return result
+}
+
+// deleted returns true for the deleted versions of a history, i.e. not
+// visible without a location, nodes or members. The elements of files
+// without the visible attribute are not visible but have their data.
+func deleted(e osm.Element) bool {
+	switch e := e.(type) {
+	case *osm.Node:
+		return !e.Visible && e.Lon == 0 && e.Lat == 0
+	case *osm.Way:
+		return !e.Visible && len(e.Nodes) == 0
+	case *osm.Relation:
+		return !e.Visible && len(e.Members) == 0
+	}
+
+	return false
+}
+
+func segmentBound(a, b geo.Point) geo.Bound {
+	return geo.Bound{Min: a, Max: a}.Extend(b)
+}
+
+func key(line, index int) int64 {
+	return int64(line)<<32 | int64(index)
+}
@@ -0,0 +1,231 @@
+package osmqa
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func annotatedWay(id osm.WayID, tags osm.Tags, points ...[2]float64) *osm.Way {
+	w := &osm.Way{ID: id, Tags: tags}
+	for i, p := range points {
+		w.Nodes = append(w.Nodes, osm.WayNode{ID: osm.NodeID(int64(id)*100 + int64(i)), Version: 1, Lon: p[0], Lat: p[1]})
+	}
+
+	return w
+}
+
+func TestUnclosedAreas(t *testing.T) {
+	cases := []struct {
+		name   string
+		rules  *osm.AreaRules
+		tags   osm.Tags
+		issues int
+	}{
+		{
+			name:   "area tag",
+			tags:   osm.Tags{{Key: "area", Value: "yes"}},
+			issues: 1,
+		},
+		{
+			name: "area no",
+			tags: osm.Tags{{Key: "building", Value: "yes"}, {Key: "area", Value: "no"}},
+		},
+		{
+			name: "linear",
+			tags: osm.Tags{{Key: "highway", Value: "primary"}},
+		},
+		{
+			name: "custom rules",
+			rules: func() *osm.AreaRules {
+				ar, _ := osm.NewAreaRules()
+				return ar
+			}(),
+			tags: osm.Tags{{Key: "building", Value: "yes"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := annotatedWay(1, tc.tags, [2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1})
+			if issues := UnclosedAreas(tc.rules).Check(w); len(issues) != tc.issues {
+				t.Errorf("incorrect issues: %v", issues)
+			}
+		})
+	}
+}
+
+func TestHighwayCrossings(t *testing.T) {
+	primary := osm.Tags{{Key: "highway", Value: "primary"}}
+	cases := []struct {
+		name   string
+		tags   osm.Tags
+		issues int
+	}{
+		{
+			name:   "crossing",
+			tags:   osm.Tags{{Key: "highway", Value: "residential"}},
+			issues: 1,
+		},
+		{
+			name: "tunnel",
+			tags: osm.Tags{{Key: "highway", Value: "residential"}, {Key: "tunnel", Value: "culvert"}},
+		},
+		{
+			name:   "bridge no",
+			tags:   osm.Tags{{Key: "highway", Value: "residential"}, {Key: "bridge", Value: "no"}},
+			issues: 1,
+		},
+		{
+			name: "layer",
+			tags: osm.Tags{{Key: "highway", Value: "residential"}, {Key: "layer", Value: "-1"}},
+		},
+		{
+			name: "area",
+			tags: osm.Tags{{Key: "highway", Value: "pedestrian"}, {Key: "area", Value: "yes"}},
+		},
+		{
+			name: "not a highway",
+			tags: osm.Tags{{Key: "waterway", Value: "river"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := HighwayCrossings()
+			c.Check(annotatedWay(1, primary, [2]float64{0, 0}, [2]float64{2, 0}, [2]float64{4, 0}))
+			c.Check(annotatedWay(2, tc.tags, [2]float64{3, -1}, [2]float64{3, 1}))
+			if issues := c.Done(); len(issues) != tc.issues {
+				t.Errorf("incorrect issues: %v", issues)
+			}
+		})
+	}
+}
+
+func TestSelfIntersections(t *testing.T) {
+	c := SelfIntersections()
+
+	// closed ring touching at the first and last node
+	ring := annotatedWay(1, nil, [2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1}, [2]float64{0, 0})
+	if issues := c.Check(ring); len(issues) != 0 {
+		t.Errorf("incorrect issues: %v", issues)
+	}
+
+	// not annotated
+	w := &osm.Way{ID: 2, Nodes: osm.WayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}}
+	if issues := c.Check(w); len(issues) != 0 {
+		t.Errorf("incorrect issues: %v", issues)
+	}
+}
+
+func TestSingleNodeWays(t *testing.T) {
+	c := SingleNodeWays()
+
+	issues := c.Check(&osm.Way{ID: 1})
+	if len(issues) != 1 || issues[0].Geometry != nil || issues[0].Message != "way has no nodes" {
+		t.Errorf("incorrect issues: %v", issues)
+	}
+
+	if issues := c.Check(annotatedWay(2, nil, [2]float64{0, 0}, [2]float64{1, 1})); len(issues) != 0 {
+		t.Errorf("incorrect issues: %v", issues)
+	}
+}
+
+func TestDuplicateNodes_history(t *testing.T) {
+	c := DuplicateNodes()
+	for _, n := range []*osm.Node{
+		// versions of the same node
+		{ID: 1, Version: 1, Visible: true, Lon: 1, Lat: 1},
+		{ID: 1, Version: 2, Visible: true, Lon: 1, Lat: 1},
+		// moved onto node 1
+		{ID: 2, Version: 1, Visible: true, Lon: 2, Lat: 2},
+		{ID: 2, Version: 2, Visible: true, Lon: 1, Lat: 1},
+		// deleted nodes
+		{ID: 3, Version: 1, Visible: true, Lon: 3, Lat: 3},
+		{ID: 3, Version: 2, Visible: false},
+		{ID: 4, Version: 1, Visible: true, Lon: 1, Lat: 1},
+		{ID: 4, Version: 2, Visible: false},
+		{ID: 5, Version: 1, Visible: true, Lon: 3, Lat: 3},
+		{ID: 5, Version: 2, Visible: false},
+	} {
+		c.Check(n)
+	}
+
+	issues := c.Done()
+	if len(issues) != 1 {
+		t.Fatalf("incorrect issues: %v", issues)
+	}
+
+	i := issues[0]
+	if i.ID != osm.NodeID(1).FeatureID() || len(i.Related) != 1 || i.Related[0] != osm.NodeID(2).FeatureID() {
+		t.Errorf("incorrect issue: %v", i)
+	}
+}
+
+func TestOrphanNodes_history(t *testing.T) {
+	c := OrphanNodes()
+	for _, e := range []osm.Element{
+		// tagged in the last version
+		&osm.Node{ID: 1, Version: 1, Visible: true, Lon: 1, Lat: 1},
+		&osm.Node{ID: 1, Version: 2, Visible: true, Lon: 1, Lat: 1, Tags: osm.Tags{{Key: "a", Value: "b"}}},
+		// untagged in the last version
+		&osm.Node{ID: 2, Version: 1, Visible: true, Lon: 2, Lat: 2, Tags: osm.Tags{{Key: "a", Value: "b"}}},
+		&osm.Node{ID: 2, Version: 2, Visible: true, Lon: 2, Lat: 2},
+		// deleted
+		&osm.Node{ID: 3, Version: 1, Visible: true, Lon: 3, Lat: 3},
+		&osm.Node{ID: 3, Version: 2, Visible: false},
+		&osm.Node{ID: 4, Version: 1, Visible: true, Lon: 4, Lat: 4},
+		&osm.Node{ID: 5, Version: 1, Visible: true, Lon: 5, Lat: 5},
+		// node 4 is no longer used by the way
+		&osm.Way{ID: 1, Version: 1, Visible: true, Nodes: osm.WayNodes{{ID: 4}, {ID: 5}}},
+		&osm.Way{ID: 1, Version: 2, Visible: true, Nodes: osm.WayNodes{{ID: 5}, {ID: 3}}},
+		// node 2 is used by a deleted relation
+		&osm.Relation{ID: 1, Version: 1, Visible: true, Members: osm.Members{{Type: osm.TypeNode, Ref: 2}}},
+		&osm.Relation{ID: 1, Version: 2, Visible: false},
+	} {
+		c.Check(e)
+	}
+
+	issues := c.Done()
+	sort.Slice(issues, func(i, j int) bool { return issues[i].ID < issues[j].ID })
+
+	var ids osm.FeatureIDs
+	for _, i := range issues {
+		ids = append(ids, i.ID)
+	}
+
+	if !reflect.DeepEqual(ids, osm.FeatureIDs{osm.NodeID(2).FeatureID(), osm.NodeID(4).FeatureID()}) {
+		t.Errorf("incorrect orphan nodes: %v", ids)
+	}
+}
+
+func TestMissingMembers_history(t *testing.T) {
+	c := MissingMembers()
+	for _, e := range []osm.Element{
+		&osm.Node{ID: 1, Version: 1, Visible: true, Lon: 1, Lat: 1},
+		&osm.Node{ID: 1, Version: 2, Visible: false},
+		&osm.Node{ID: 2, Version: 1, Visible: true, Lon: 2, Lat: 2},
+		// the first version has a missing member
+		&osm.Relation{ID: 1, Version: 1, Visible: true, Members: osm.Members{{Type: osm.TypeNode, Ref: 9}}},
+		&osm.Relation{ID: 1, Version: 2, Visible: true, Members: osm.Members{{Type: osm.TypeNode, Ref: 2}}},
+		// the member is deleted
+		&osm.Relation{ID: 2, Version: 1, Visible: true, Members: osm.Members{{Type: osm.TypeNode, Ref: 1}}},
+		// deleted relation
+		&osm.Relation{ID: 3, Version: 1, Visible: true, Members: osm.Members{{Type: osm.TypeNode, Ref: 9}}},
+		&osm.Relation{ID: 3, Version: 2, Visible: false},
+	} {
+		c.Check(e)
+	}
+
+	issues := c.Done()
+	if len(issues) != 1 {
+		t.Fatalf("incorrect issues: %v", issues)
+	}
+
+	i := issues[0]
+	if i.ID != osm.RelationID(2).FeatureID() || !reflect.DeepEqual(i.Related, osm.FeatureIDs{osm.NodeID(1).FeatureID()}) {
+		t.Errorf("incorrect issue: %v", i)
+	}
+}
@@ -0,0 +1,137 @@
+package osmqa
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/geo/geojson"
+	"github.com/pchchv/osm"
+)
+
+// Kind is a strong type for the kind of an issue.
+// Custom checks can define their own kinds.
+type Kind string
+
+// The kinds of the issues found by the built-in checks.
+const (
+	KindUnclosedArea     Kind = "unclosed_area"
+	KindSelfIntersection Kind = "self_intersection"
+	KindHighwayCrossing  Kind = "highway_crossing"
+	KindDuplicateNode    Kind = "duplicate_node"
+	KindOrphanNode       Kind = "orphan_node"
+	KindSingleNodeWay    Kind = "single_node_way"
+	KindMissingMember    Kind = "missing_member"
+)
+
+// Issue is a data quality problem of an element.
+type Issue struct {
+	Kind Kind
+	ID   osm.FeatureID
+	// Related are the other elements involved, e.g. the crossing
+	// way for highway crossings or the missing members.
+	Related osm.FeatureIDs
+	Message string
+	// Geometry is the location of the issue, e.g. the point of a crossing
+	// or the line of an unclosed way, nil if the location is not known.
+	Geometry geo.Geometry
+}
+
+type issueJSON struct {
+	Kind     Kind              `json:"kind"`
+	ID       string            `json:"id"`
+	Related  []string          `json:"related,omitempty"`
+	Message  string            `json:"message"`
+	Geometry *geojson.Geometry `json:"geometry"`
+}
+
+// MarshalJSON returns the issue with the ids as "type/ref" strings
+// and the geometry as a geojson geometry, null if not known.
+func (i *Issue) MarshalJSON() ([]byte, error) {
+	doc := issueJSON{
+		Kind:    i.Kind,
+		ID:      i.ID.String(),
+		Message: i.Message,
+	}
+
+	for _, id := range i.Related {
+		doc.Related = append(doc.Related, id.String())
+	}
+
+	if i.Geometry != nil {
+		doc.Geometry = geojson.NewGeometry(i.Geometry)
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON parses an issue in the format of MarshalJSON.
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	var doc issueJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	id, err := osm.ParseFeatureID(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	*i = Issue{Kind: doc.Kind, ID: id, Message: doc.Message}
+	for _, r := range doc.Related {
+		fid, err := osm.ParseFeatureID(r)
+		if err != nil {
+			return err
+		}
+
+		i.Related = append(i.Related, fid)
+	}
+
+	if doc.Geometry != nil {
+		i.Geometry = doc.Geometry.Geometry()
+	}
+
+	return nil
+}
+
+// Issues is a list of issues, it marshals to a json list.
+type Issues []*Issue
+
+// Sort orders the issues by element, i.e. type and id, then kind.
+func (is Issues) Sort() {
+	sort.SliceStable(is, func(i, j int) bool {
+		if is[i].ID != is[j].ID {
+			return is[i].ID < is[j].ID
+		}
+
+		return is[i].Kind < is[j].Kind
+	})
+}
+
+// FeatureCollection returns the issues with a location as geojson features.
+// The properties are the kind, id, related ids and message of the issue.
+func (is Issues) FeatureCollection() *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, i := range is {
+		if i.Geometry == nil {
+			continue
+		}
+
+		f := geojson.NewFeature(i.Geometry)
+		f.Properties["kind"] = string(i.Kind)
+		f.Properties["id"] = i.ID.String()
+		f.Properties["message"] = i.Message
+		if len(i.Related) > 0 {
+			related := make([]string, len(i.Related))
+			for j, id := range i.Related {
+				related[j] = id.String()
+			}
+
+			f.Properties["related"] = related
+		}
+
+		fc.Append(f)
+	}
+
+	return fc
+}
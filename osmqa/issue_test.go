@@ -0,0 +1,72 @@
+package osmqa
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+func TestIssue_MarshalJSON(t *testing.T) {
+	issues := Issues{
+		{
+			Kind:     KindHighwayCrossing,
+			ID:       osm.WayID(1).FeatureID(),
+			Related:  osm.FeatureIDs{osm.WayID(2).FeatureID()},
+			Message:  "crosses way/2 without a shared node",
+			Geometry: geo.Point{1, 2},
+		},
+		{
+			Kind:    KindMissingMember,
+			ID:      osm.RelationID(3).FeatureID(),
+			Message: "1 of 1 members missing",
+		},
+	}
+
+	data, err := json.Marshal(issues)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	expected := `[{"kind":"highway_crossing","id":"way/1","related":["way/2"],"message":"crosses way/2 without a shared node","geometry":{"type":"Point","coordinates":[1,2]}},` +
+		`{"kind":"missing_member","id":"relation/3","message":"1 of 1 members missing","geometry":null}]`
+	if string(data) != expected {
+		t.Errorf("incorrect json: %s", data)
+	}
+
+	var result Issues
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, issues) {
+		t.Errorf("incorrect issues: %v", result)
+	}
+
+	var i Issue
+	if err := json.Unmarshal([]byte(`{"kind":"x","id":"way"}`), &i); err == nil {
+		t.Errorf("should return error for invalid id")
+	}
+}
+
+func TestIssues_FeatureCollection(t *testing.T) {
+	o := testData()
+	issues := RunStore(o.Store())
+
+	fc := issues.FeatureCollection()
+	if len(fc.Features) != len(issues)-1 {
+		t.Fatalf("issues without location should be skipped: %d", len(fc.Features))
+	}
+
+	f := fc.Features[0]
+	if f.Properties["kind"] != "duplicate_node" || f.Properties["id"] != "node/30" ||
+		!reflect.DeepEqual(f.Properties["related"], []string{"node/31"}) {
+		t.Errorf("incorrect properties: %v", f.Properties)
+	}
+
+	if _, ok := fc.Features[1].Properties["related"]; ok {
+		t.Errorf("should not set related if empty")
+	}
+}
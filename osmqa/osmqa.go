@@ -0,0 +1,132 @@
+package osmqa
+
+import (
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+// Check is a data quality check. Checks keep the state needed
+// to find their issues, a new check must be used for every run.
+type Check interface {
+	// Check is called for every node, way and relation in the order
+	// of the data and returns the issues of the element itself.
+	// The ways are annotated with the locations of the nodes
+	// seen before, if known.
+	Check(e osm.Element) Issues
+	// Done is called after the last element and returns the
+	// issues that need all the data, e.g. orphan nodes.
+	Done() Issues
+}
+
+// DefaultChecks returns new instances of all the built-in checks.
+func DefaultChecks() []Check {
+	return []Check{
+		UnclosedAreas(nil),
+		SelfIntersections(),
+		HighwayCrossings(),
+		DuplicateNodes(),
+		OrphanNodes(),
+		SingleNodeWays(),
+		MissingMembers(),
+	}
+}
+
+// Run runs the checks, or the default checks if none are given, over
+// the nodes, ways and relations of the scanner. Other objects, e.g.
+// changesets, are skipped. The scanner is not closed. The node locations
+// are kept in memory to annotate the ways, so the nodes should come first
+// as they do in osm files. The issues are sorted, see Issues.Sort.
+func Run(s osm.Scanner, checks ...Check) (Issues, error) {
+	r := newRunner(checks)
+	for s.Scan() {
+		if e, ok := s.Object().(osm.Element); ok {
+			r.check(e)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.done(), nil
+}
+
+// RunStore runs the checks, or the default checks
+// if none are given, over all the elements of the store.
+func RunStore(s *osm.Store, checks ...Check) Issues {
+	r := newRunner(checks)
+	for _, e := range s.Elements() {
+		r.check(e)
+	}
+
+	return r.done()
+}
+
+type runner struct {
+	checks []Check
+	// nodes are the way nodes annotated with the node locations
+	nodes  map[osm.NodeID]osm.WayNode
+	issues Issues
+}
+
+func newRunner(checks []Check) *runner {
+	if len(checks) == 0 {
+		checks = DefaultChecks()
+	}
+
+	return &runner{
+		checks: checks,
+		nodes:  make(map[osm.NodeID]osm.WayNode),
+	}
+}
+
+func (r *runner) check(e osm.Element) {
+	switch v := e.(type) {
+	case *osm.Node:
+		r.nodes[v.ID] = osm.WayNode{ID: v.ID, Version: v.Version, ChangesetID: v.ChangesetID, Lat: v.Lat, Lon: v.Lon}
+	case *osm.Way:
+		e = r.annotate(v)
+	}
+
+	for _, c := range r.checks {
+		r.issues = append(r.issues, c.Check(e)...)
+	}
+}
+
+func (r *runner) done() Issues {
+	for _, c := range r.checks {
+		r.issues = append(r.issues, c.Done()...)
+	}
+
+	r.issues.Sort()
+	return r.issues
+}
+
+// annotate returns a copy of the way with the locations of the nodes
+// seen, the way itself if it is already annotated.
+func (r *runner) annotate(w *osm.Way) *osm.Way {
+	if _, ok := line(w); ok {
+		return w
+	}
+
+	c := *w
+	c.Nodes = make(osm.WayNodes, len(w.Nodes))
+	for i, wn := range w.Nodes {
+		c.Nodes[i] = wn
+		if n, ok := r.nodes[wn.ID]; ok && !located(wn) {
+			c.Nodes[i] = n
+		}
+	}
+
+	return &c
+}
+
+// line returns the line of the way if all the nodes are annotated.
+func line(w *osm.Way) (geo.LineString, bool) {
+	ls := w.LineString()
+	return ls, len(w.Nodes) > 0 && len(ls) == len(w.Nodes)
+}
+
+func located(wn osm.WayNode) bool {
+	return wn.Version != 0 || wn.Lat != 0 || wn.Lon != 0
+}
@@ -0,0 +1,154 @@
+package osmqa
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+	"github.com/pchchv/osm/osmtest"
+)
+
+// testData returns elements with one issue of every kind,
+// the ways are not annotated with the node locations.
+func testData() *osm.OSM {
+	o := &osm.OSM{}
+	node := func(id osm.NodeID, lon, lat float64, tags ...osm.Tag) {
+		o.Nodes = append(o.Nodes, &osm.Node{ID: id, Version: 1, Lon: lon, Lat: lat, Tags: tags})
+	}
+	way := func(id osm.WayID, nodes []osm.NodeID, tags ...osm.Tag) {
+		w := &osm.Way{ID: id, Version: 1, Tags: tags}
+		for _, n := range nodes {
+			w.Nodes = append(w.Nodes, osm.WayNode{ID: n})
+		}
+		o.Ways = append(o.Ways, w)
+	}
+
+	// unclosed and closed building
+	node(1, 0, 0)
+	node(2, 1, 0)
+	node(3, 1, 1)
+	way(10, []osm.NodeID{1, 2, 3}, osm.Tag{Key: "building", Value: "yes"})
+	node(4, 2, 0)
+	node(5, 3, 0)
+	node(6, 3, 1)
+	node(7, 2, 1)
+	way(11, []osm.NodeID{4, 5, 6, 7, 4}, osm.Tag{Key: "building", Value: "yes"})
+
+	// figure eight
+	node(8, 10, 0)
+	node(9, 12, 2)
+	node(13, 12, 0)
+	node(14, 10, 2)
+	way(12, []osm.NodeID{8, 9, 13, 14})
+
+	// highways crossing at 21,0, the bridge, the other layer
+	// and the proposed highway are fine
+	node(21, 20, 0)
+	node(22, 22, 0)
+	node(23, 21, -1)
+	node(24, 21, 1)
+	node(25, 20.5, -1)
+	node(26, 20.5, 1)
+	node(27, 21.5, -1)
+	node(28, 21.5, 1)
+	node(29, 21.2, -1)
+	node(32, 21.2, 1)
+	way(20, []osm.NodeID{21, 22}, osm.Tag{Key: "highway", Value: "residential"})
+	way(21, []osm.NodeID{23, 24}, osm.Tag{Key: "highway", Value: "primary"})
+	way(22, []osm.NodeID{25, 26}, osm.Tag{Key: "highway", Value: "service"}, osm.Tag{Key: "bridge", Value: "yes"})
+	way(23, []osm.NodeID{27, 28}, osm.Tag{Key: "highway", Value: "footway"}, osm.Tag{Key: "layer", Value: "1"})
+	way(24, []osm.NodeID{29, 32}, osm.Tag{Key: "highway", Value: "proposed"})
+
+	// duplicate tagged nodes
+	node(30, 30, 30, osm.Tag{Key: "amenity", Value: "bench"})
+	node(31, 30, 30, osm.Tag{Key: "amenity", Value: "bench"})
+
+	// orphan node, node used by a relation, single node way
+	node(40, 40, 40)
+	node(41, 41, 41)
+	node(42, 42, 42)
+	way(30, []osm.NodeID{42, 42})
+
+	o.Relations = osm.Relations{
+		{ID: 50, Version: 1, Members: osm.Members{
+			{Type: osm.TypeNode, Ref: 41},
+			{Type: osm.TypeWay, Ref: 999},
+		}},
+	}
+
+	return o
+}
+
+type expectedIssue struct {
+	Kind     Kind
+	ID       osm.FeatureID
+	Related  osm.FeatureIDs
+	Geometry geo.Geometry
+}
+
+var expected = []expectedIssue{
+	{KindDuplicateNode, osm.NodeID(30).FeatureID(), osm.FeatureIDs{osm.NodeID(31).FeatureID()}, geo.Point{30, 30}},
+	{KindOrphanNode, osm.NodeID(40).FeatureID(), nil, geo.Point{40, 40}},
+	{KindUnclosedArea, osm.WayID(10).FeatureID(), nil, geo.LineString{{0, 0}, {1, 0}, {1, 1}}},
+	{KindSelfIntersection, osm.WayID(12).FeatureID(), nil, geo.Point{11, 1}},
+	{KindHighwayCrossing, osm.WayID(20).FeatureID(), osm.FeatureIDs{osm.WayID(21).FeatureID()}, geo.Point{21, 0}},
+	{KindSingleNodeWay, osm.WayID(30).FeatureID(), nil, geo.Point{42, 42}},
+	{KindMissingMember, osm.RelationID(50).FeatureID(), osm.FeatureIDs{osm.WayID(999).FeatureID()}, nil},
+}
+
+func compareIssues(t testing.TB, issues Issues) {
+	t.Helper()
+
+	var result []expectedIssue
+	for _, i := range issues {
+		result = append(result, expectedIssue{i.Kind, i.ID, i.Related, i.Geometry})
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("incorrect issues:")
+		for _, i := range issues {
+			t.Logf("%v %v %v %v %v", i.Kind, i.ID, i.Related, i.Geometry, i.Message)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	o := testData()
+	issues, err := Run(osmtest.NewScanner(o.Objects()))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	compareIssues(t, issues)
+
+	// input is not modified
+	if o.Ways[0].Nodes[0].Lon != 0 || o.Ways[0].Nodes[1].Lon != 0 {
+		t.Errorf("should not annotate the input ways")
+	}
+}
+
+func TestRun_checks(t *testing.T) {
+	issues, err := Run(osmtest.NewScanner(testData().Objects()), SingleNodeWays(), OrphanNodes())
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	if len(issues) != 2 || issues[0].Kind != KindOrphanNode || issues[1].Kind != KindSingleNodeWay {
+		t.Errorf("incorrect issues: %v", issues)
+	}
+}
+
+func TestRun_error(t *testing.T) {
+	s := osmtest.NewScanner(testData().Objects())
+	s.ScanError = errors.New("scan error")
+
+	if _, err := Run(s); err != s.ScanError {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestRunStore(t *testing.T) {
+	compareIssues(t, RunStore(testData().Store()))
+}
@@ -2,14 +2,27 @@ package osm
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 )
 
+// The conditions of an area rule.
+const (
+	// AreaAll matches every value of the key except "no".
+	AreaAll AreaCondition = "all"
+	// AreaWhitelist matches only the listed values.
+	AreaWhitelist AreaCondition = "whitelist"
+	// AreaBlacklist matches every value except the listed ones and "no".
+	AreaBlacklist AreaCondition = "blacklist"
+)
+
+// areaKey is the default key of the tag overriding the area rules.
+const areaKey = "area"
+
 var (
-	polyConditions     []polyCondition
-	conditionAll       conditionType = "all"
-	conditionBlacklist conditionType = "blacklist"
-	conditionWhitelist conditionType = "whitelist"
+	// defaultAreaRules are the rules used by Way.Polygon. They are
+	// the rules of osmtogeojson and overpass turbo, see polygonJSON.
+	defaultAreaRules *AreaRules
 	// polygonJSON holds advanced conditions for when an osm way is a polygon.
 	// Sourced from: https://wiki.openstreetmap.org/wiki/Overpass_turbo/Polygon_Features
 	polygonJSON = []byte(`
@@ -168,44 +181,134 @@ var (
 ]`)
 )
 
-// Polygon returns true if the way should be considered a closed polygon area.
-// OpenStreetMap doesn't have an intrinsic area data type.
-// The algorithm used here considers a set of heuristics to determine what is most likely an area.
-// The heuristics can be found here,
+// AreaCondition is a strong type for the conditions of an area rule.
+type AreaCondition string
+
+// AreaRule is a condition on the value of a tag key for a closed
+// way to be an area. The json format is the one of overpass turbo,
 // https://wiki.openstreetmap.org/wiki/Overpass_turbo/Polygon_Features
-// and are used by osmtogeojson and overpass turbo.
-func (w *Way) Polygon() bool {
-	if len(w.Nodes) <= 3 {
-		// need more than 3 nodes to be a polygon since first/last is repeated.
-		return false
+type AreaRule struct {
+	Key       string        `json:"key"`
+	Condition AreaCondition `json:"polygon"`
+	Values    []string      `json:"values,omitempty"`
+}
+
+// AreaRules are a set of heuristics to determine if a closed way is an
+// area, OpenStreetMap doesn't have an intrinsic area data type.
+// A way is an area if any rule matches, the value of the override tag,
+// area by default, overrides the rules, "no" is not an area and
+// any other non-empty value is an area.
+// The zero value has no rules and no override tag.
+type AreaRules struct {
+	// OverrideKey is the key of the tag overriding the rules,
+	// "area" for new and parsed rules. Empty disables the override.
+	OverrideKey string
+	rules       []AreaRule
+}
+
+// DefaultAreaRules returns a copy of the rules used by Way.Polygon.
+// They are the rules of osmtogeojson and overpass turbo,
+// https://wiki.openstreetmap.org/wiki/Overpass_turbo/Polygon_Features
+func DefaultAreaRules() *AreaRules {
+	return &AreaRules{
+		OverrideKey: defaultAreaRules.OverrideKey,
+		rules:       defaultAreaRules.Rules(),
 	}
+}
 
-	if w.Nodes[0].ID != w.Nodes[len(w.Nodes)-1].ID {
-		// must be closed
-		return false
+// NewAreaRules creates area rules, the rules are copied.
+// The override key is "area".
+// An error is returned for unknown conditions.
+func NewAreaRules(rules ...AreaRule) (*AreaRules, error) {
+	ar := &AreaRules{
+		OverrideKey: areaKey,
+		rules:       make([]AreaRule, 0, len(rules)),
 	}
+	for _, r := range rules {
+		switch r.Condition {
+		case AreaAll, AreaWhitelist, AreaBlacklist:
+		default:
+			return nil, fmt.Errorf("osm: unknown area condition %q for key %q", r.Condition, r.Key)
+		}
 
-	if area := w.Tags.Find("area"); area == "no" {
-		return false
-	} else if area != "" {
-		return true
+		r.Values = append([]string(nil), r.Values...)
+		sort.Strings(r.Values)
+		ar.rules = append(ar.rules, r)
+	}
+
+	return ar, nil
+}
+
+// ParseAreaRules parses area rules from a json list
+// in the overpass turbo format, e.g.
+//
+//	[{"key": "building", "polygon": "all"},
+//	 {"key": "natural", "polygon": "blacklist", "values": ["coastline"]}]
+func ParseAreaRules(data []byte) (*AreaRules, error) {
+	var rules []AreaRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	return NewAreaRules(rules...)
+}
+
+// Rules returns a copy of the rules.
+func (ar *AreaRules) Rules() []AreaRule {
+	result := make([]AreaRule, len(ar.rules))
+	for i, r := range ar.rules {
+		r.Values = append([]string(nil), r.Values...)
+		result[i] = r
 	}
 
-	for _, c := range polyConditions {
-		v := w.Tags.Find(c.Key)
-		if v != "" && v != "no" {
-			if c.Condition == conditionAll {
+	return result
+}
+
+// MarshalJSON returns the rules in the overpass turbo format.
+// The override key is not part of the format.
+func (ar *AreaRules) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ar.rules)
+}
+
+// UnmarshalJSON parses the rules in the overpass turbo format,
+// the override key is set to "area".
+func (ar *AreaRules) UnmarshalJSON(data []byte) error {
+	rules, err := ParseAreaRules(data)
+	if err != nil {
+		return err
+	}
+
+	*ar = *rules
+	return nil
+}
+
+// Area returns true if the tags describe an area, i.e. the override
+// tag is set but not "no", or one of the rules matches.
+func (ar *AreaRules) Area(tags Tags) bool {
+	if ar.OverrideKey != "" {
+		if area := tags.Find(ar.OverrideKey); area == "no" {
+			return false
+		} else if area != "" {
+			return true
+		}
+	}
+
+	for _, r := range ar.rules {
+		v := tags.Find(r.Key)
+		if v == "" || v == "no" {
+			continue
+		}
+
+		switch r.Condition {
+		case AreaAll:
+			return true
+		case AreaWhitelist:
+			if containsSorted(r.Values, v) {
+				return true
+			}
+		case AreaBlacklist:
+			if !containsSorted(r.Values, v) {
 				return true
-			} else if c.Condition == conditionWhitelist {
-				index := sort.SearchStrings(c.Values, v)
-				if index != len(c.Values) && c.Values[index] == v {
-					return true
-				}
-			} else if c.Condition == conditionBlacklist {
-				index := sort.SearchStrings(c.Values, v)
-				if index == len(c.Values) || c.Values[index] != v {
-					return true
-				}
 			}
 		}
 	}
@@ -213,27 +316,48 @@ func (w *Way) Polygon() bool {
 	return false
 }
 
+// Polygon returns true if the way is closed, has more than 3 nodes
+// and the tags describe an area.
+func (ar *AreaRules) Polygon(w *Way) bool {
+	if len(w.Nodes) <= 3 {
+		// need more than 3 nodes to be a polygon since first/last is repeated.
+		return false
+	}
+
+	if w.Nodes[0].ID != w.Nodes[len(w.Nodes)-1].ID {
+		// must be closed
+		return false
+	}
+
+	return ar.Area(w.Tags)
+}
+
+// Polygon returns true if the way should be considered a closed polygon area.
+// OpenStreetMap doesn't have an intrinsic area data type.
+// The algorithm used here considers a set of heuristics to determine what is most likely an area.
+// The heuristics can be found here,
+// https://wiki.openstreetmap.org/wiki/Overpass_turbo/Polygon_Features
+// and are used by osmtogeojson and overpass turbo.
+// Use AreaRules.Polygon for different rules, see DefaultAreaRules.
+func (w *Way) Polygon() bool {
+	return defaultAreaRules.Polygon(w)
+}
+
 // Polygon returns true if the relation is of type multipolygon or boundary.
 func (r *Relation) Polygon() bool {
 	t := r.Tags.Find("type")
 	return t == "multipolygon" || t == "boundary"
 }
 
-type conditionType string
-
-type polyCondition struct {
-	Key       string        `json:"key"`
-	Condition conditionType `json:"polygon"`
-	Values    []string      `json:"values"`
+func containsSorted(values []string, v string) bool {
+	i := sort.SearchStrings(values, v)
+	return i != len(values) && values[i] == v
 }
 
 func init() {
-	if err := json.Unmarshal(polygonJSON, &polyConditions); err != nil {
+	var err error
+	if defaultAreaRules, err = ParseAreaRules(polygonJSON); err != nil {
 		// must be valid json
 		panic(err)
 	}
-
-	for _, p := range polyConditions {
-		sort.StringSlice(p.Values).Sort()
-	}
 }
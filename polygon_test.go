@@ -1,6 +1,7 @@
 package osm
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 )
@@ -20,7 +21,7 @@ func TestWay_Polygon(t *testing.T) {
 		t.Errorf("first and last node must have same id")
 	}
 
-	c := polyConditions[1].Values
+	c := defaultAreaRules.rules[1].Values
 	if !reflect.DeepEqual(c, []string{"elevator", "escape", "rest_area", "services"}) {
 		t.Errorf("values not sorted")
 	}
@@ -107,3 +108,145 @@ func TestWay_Polygon(t *testing.T) {
 		})
 	}
 }
+
+func TestAreaRules(t *testing.T) {
+	rules, err := ParseAreaRules([]byte(`[
+		{"key": "man_made", "polygon": "whitelist", "values": ["pier", "works"]},
+		{"key": "highway", "polygon": "blacklist", "values": ["primary", "footway"]}
+	]`))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		tags  Tags
+		value bool
+	}{
+		{
+			name:  "whitelist",
+			tags:  Tags{{Key: "man_made", Value: "pier"}},
+			value: true,
+		},
+		{
+			name:  "not in whitelist",
+			tags:  Tags{{Key: "man_made", Value: "tower"}},
+			value: false,
+		},
+		{
+			name:  "not in blacklist",
+			tags:  Tags{{Key: "highway", Value: "pedestrian"}},
+			value: true,
+		},
+		{
+			name:  "in blacklist",
+			tags:  Tags{{Key: "highway", Value: "footway"}},
+			value: false,
+		},
+		{
+			name:  "area yes override",
+			tags:  Tags{{Key: "highway", Value: "footway"}, {Key: "area", Value: "yes"}},
+			value: true,
+		},
+		{
+			name:  "area no override",
+			tags:  Tags{{Key: "man_made", Value: "pier"}, {Key: "area", Value: "no"}},
+			value: false,
+		},
+		{
+			name:  "not in rules",
+			tags:  Tags{{Key: "building", Value: "yes"}},
+			value: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Way{
+				Nodes: WayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 1}},
+				Tags:  tc.tags,
+			}
+
+			if v := rules.Polygon(w); v != tc.value {
+				t.Errorf("not correctly detected, %v != %v", v, tc.value)
+			}
+		})
+	}
+
+	// values are sorted
+	if v := rules.Rules()[1].Values; !reflect.DeepEqual(v, []string{"footway", "primary"}) {
+		t.Errorf("values not sorted: %v", v)
+	}
+}
+
+func TestAreaRules_json(t *testing.T) {
+	data, err := json.Marshal(defaultAreaRules)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	rules := &AreaRules{}
+	if err := json.Unmarshal(data, rules); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(rules, defaultAreaRules) {
+		t.Errorf("rules not equal after round trip")
+	}
+
+	_, err = NewAreaRules(AreaRule{Key: "building", Condition: "some"})
+	if err == nil {
+		t.Errorf("expected error for unknown condition")
+	}
+}
+
+func TestNewAreaRules(t *testing.T) {
+	values := []string{"b", "a"}
+	rules, err := NewAreaRules(AreaRule{Key: "k", Condition: AreaWhitelist, Values: values})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if values[0] != "b" {
+		t.Errorf("input values should not be modified")
+	}
+
+	if !rules.Area(Tags{{Key: "k", Value: "a"}}) {
+		t.Errorf("should be area")
+	}
+
+	empty := &AreaRules{}
+	if empty.Area(Tags{{Key: "building", Value: "yes"}}) {
+		t.Errorf("empty rules should not match")
+	}
+}
+
+func TestAreaRules_OverrideKey(t *testing.T) {
+	rules := DefaultAreaRules()
+	if rules.OverrideKey != "area" {
+		t.Errorf("incorrect default override key: %v", rules.OverrideKey)
+	}
+
+	footway := Tags{{Key: "highway", Value: "footway"}, {Key: "area", Value: "yes"}}
+	building := Tags{{Key: "building", Value: "yes"}, {Key: "area", Value: "no"}}
+
+	rules.OverrideKey = ""
+	if rules.Area(footway) {
+		t.Errorf("area=yes should not override without a key")
+	}
+
+	if !rules.Area(building) {
+		t.Errorf("area=no should not override without a key")
+	}
+
+	rules.OverrideKey = "area:custom"
+	if !rules.Area(Tags{{Key: "highway", Value: "footway"}, {Key: "area:custom", Value: "yes"}}) {
+		t.Errorf("custom key should override")
+	}
+
+	// the copy does not change the rules of Way.Polygon
+	w := &Way{Nodes: WayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 1}}, Tags: footway}
+	if !w.Polygon() {
+		t.Errorf("default rules should not be modified")
+	}
+}
@@ -0,0 +1,92 @@
+package ptroute
+
+import "github.com/pchchv/osm"
+
+// Master is a route_master relation grouping the routes,
+// usually the directions and variants, of a line.
+type Master struct {
+	RelationID osm.RelationID
+	// Mode is the value of the route_master tag, e.g. bus.
+	Mode     string
+	Ref      string
+	Name     string
+	Network  string
+	Operator string
+	// Routes are the route relations in member order.
+	Routes []osm.RelationID
+}
+
+// ParseMaster parses a route_master relation. Only the relation
+// members are included in the routes. An *Error is returned
+// wrapping ErrNotRouteMaster if the relation is not a route master.
+func ParseMaster(r *osm.Relation) (*Master, error) {
+	if r.Tags.Find("type") != "route_master" {
+		return nil, &Error{RelationID: r.ID, Member: -1, Err: ErrNotRouteMaster}
+	}
+
+	result := &Master{
+		RelationID: r.ID,
+		Mode:       r.Tags.Find("route_master"),
+		Ref:        r.Tags.Find("ref"),
+		Name:       r.Tags.Find("name"),
+		Network:    r.Tags.Find("network"),
+		Operator:   r.Tags.Find("operator"),
+	}
+
+	for _, m := range r.Members {
+		if m.Type == osm.TypeRelation {
+			result.Routes = append(result.Routes, osm.RelationID(m.Ref))
+		}
+	}
+
+	return result, nil
+}
+
+// ParseAll parses all the route masters and public transport routes of
+// the relations, other relations are skipped. The Master of the routes
+// is set to the route master they are a member of.
+// The errors are those of Parse, the routes are not validated.
+func ParseAll(relations osm.Relations, ways map[osm.WayID]*osm.Way) ([]*Master, []*Route, []error) {
+	var masters []*Master
+	var routes []*Route
+	var errs []error
+	for _, r := range relations {
+		switch r.Tags.Find("type") {
+		case "route_master":
+			m, err := ParseMaster(r)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			masters = append(masters, m)
+		case "route":
+			if !modes[r.Tags.Find("route")] {
+				continue
+			}
+
+			route, err := Parse(r, ways)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			routes = append(routes, route)
+		}
+	}
+
+	byID := make(map[osm.RelationID]*Route, len(routes))
+	for _, r := range routes {
+		byID[r.RelationID] = r
+	}
+
+	for _, m := range masters {
+		for _, id := range m.Routes {
+			if r := byID[id]; r != nil {
+				r.Master = m.RelationID
+			}
+		}
+	}
+
+	return masters, routes, errs
+}
@@ -0,0 +1,335 @@
+package ptroute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+// modes are the route values of public transport routes.
+var modes = map[string]bool{
+	"bus":        true,
+	"trolleybus": true,
+	"minibus":    true,
+	"share_taxi": true,
+	"coach":      true,
+	"tram":       true,
+	"train":      true,
+	"light_rail": true,
+	"subway":     true,
+	"monorail":   true,
+	"ferry":      true,
+	"funicular":  true,
+	"aerialway":  true,
+}
+
+var (
+	// ErrNotRoute is returned if the relation is not a
+	// public transport route, e.g. route=bus or route=train.
+	ErrNotRoute = errors.New("ptroute: relation is not a public transport route")
+	// ErrNotRouteMaster is returned if the relation is not of type route_master.
+	ErrNotRouteMaster = errors.New("ptroute: relation is not a route master")
+	// ErrWayNotFound is returned by Parse if a way of the path is not found.
+	ErrWayNotFound = errors.New("ptroute: member way not found")
+
+	_ error = &Error{}
+)
+
+// Error is returned for broken route relations. Err is one of the errors
+// of this package, Member is the index of the member with the problem,
+// -1 if not applicable.
+type Error struct {
+	RelationID osm.RelationID
+	Member     int
+	Err        error
+}
+
+// Error returns a pretty string of the error.
+func (e *Error) Error() string {
+	if e.Member >= 0 {
+		return fmt.Sprintf("relation %d: member %d: %v", e.RelationID, e.Member, e.Err)
+	}
+
+	return fmt.Sprintf("relation %d: %v", e.RelationID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Route is a public transport route parsed from a type=route relation
+// following the PTv2 schema.
+type Route struct {
+	RelationID osm.RelationID
+	// Mode is the value of the route tag, e.g. bus, tram or train.
+	Mode     string
+	Ref      string
+	Name     string
+	From     string
+	To       string
+	Network  string
+	Operator string
+
+	// Master is the route_master relation of the route,
+	// only set by ParseAll.
+	Master osm.RelationID
+
+	// Stops are the stop positions in member order,
+	// Platforms the platforms where passengers wait.
+	Stops     []Stop
+	Platforms []Stop
+	// Path are the ways traveled in order.
+	Path []PathWay
+}
+
+// Stop is a stop or platform member of a route.
+type Stop struct {
+	// Index is the index of the member in the relation.
+	Index int
+	Type  osm.Type
+	Ref   int64
+	// Role is the member role, e.g. stop, stop_entry_only or platform.
+	Role string
+}
+
+// FeatureID returns the feature id of the stop.
+func (s Stop) FeatureID() osm.FeatureID {
+	return osm.Member{Type: s.Type, Ref: s.Ref}.FeatureID()
+}
+
+// EntryOnly returns true if passengers can only board at the stop.
+func (s Stop) EntryOnly() bool {
+	return strings.HasSuffix(s.Role, "_entry_only")
+}
+
+// ExitOnly returns true if passengers can only alight at the stop.
+func (s Stop) ExitOnly() bool {
+	return strings.HasSuffix(s.Role, "_exit_only")
+}
+
+// PathWay is a way of the route path in the direction of travel.
+type PathWay struct {
+	// Index is the index of the member in the relation.
+	Index int
+	WayID osm.WayID
+	// Reversed is true if the way is traveled against its direction.
+	Reversed bool
+	// Connected is false if the way does not start where the
+	// previous way ended, i.e. there is a gap. Always true for the first way.
+	Connected bool
+	// Nodes and Line are in the direction of travel. For closed ways,
+	// e.g. roundabouts, only the part traveled is included.
+	Nodes []osm.NodeID
+	Line  geo.LineString
+	// Untraveled are the other nodes of a closed way. They are still
+	// on the route, e.g. a loop can be traveled the other way around.
+	Untraveled []osm.NodeID
+}
+
+// Parse parses a public transport route relation. The ways are needed to
+// orient the path, they should be annotated with the node locations for
+// the lines of the path. Members with roles other than the stop, platform
+// and empty path roles are ignored. The PTv2 rules are not checked,
+// see Route.Validate. An *Error is returned wrapping ErrNotRoute or
+// ErrWayNotFound.
+func Parse(r *osm.Relation, ways map[osm.WayID]*osm.Way) (*Route, error) {
+	mode := r.Tags.Find("route")
+	if r.Tags.Find("type") != "route" || !modes[mode] {
+		return nil, &Error{RelationID: r.ID, Member: -1, Err: ErrNotRoute}
+	}
+
+	result := &Route{
+		RelationID: r.ID,
+		Mode:       mode,
+		Ref:        r.Tags.Find("ref"),
+		Name:       r.Tags.Find("name"),
+		From:       r.Tags.Find("from"),
+		To:         r.Tags.Find("to"),
+		Network:    r.Tags.Find("network"),
+		Operator:   r.Tags.Find("operator"),
+	}
+
+	var path []*osm.Way
+	var indexes []int
+	for i, m := range r.Members {
+		switch {
+		case isStop(m.Role):
+			result.Stops = append(result.Stops, Stop{Index: i, Type: m.Type, Ref: m.Ref, Role: m.Role})
+		case isPlatform(m.Role):
+			result.Platforms = append(result.Platforms, Stop{Index: i, Type: m.Type, Ref: m.Ref, Role: m.Role})
+		case m.Type == osm.TypeWay && isPath(m.Role):
+			w := ways[osm.WayID(m.Ref)]
+			if w == nil || len(w.Nodes) < 2 {
+				return nil, &Error{RelationID: r.ID, Member: i, Err: ErrWayNotFound}
+			}
+
+			path = append(path, w)
+			indexes = append(indexes, i)
+		}
+	}
+
+	result.Path = orient(path)
+	for i := range result.Path {
+		result.Path[i].Index = indexes[i]
+	}
+
+	return result, nil
+}
+
+// Nodes returns the nodes of the path in the direction of travel.
+// The nodes connecting two ways are only included once.
+func (r *Route) Nodes() []osm.NodeID {
+	var result []osm.NodeID
+	for _, pw := range r.Path {
+		nodes := pw.Nodes
+		if pw.Connected && len(result) > 0 {
+			nodes = nodes[1:]
+		}
+
+		result = append(result, nodes...)
+	}
+
+	return result
+}
+
+// LineStrings returns the lines of the path, one for every continuous
+// section, i.e. there is more than one line if the path has gaps.
+func (r *Route) LineStrings() geo.MultiLineString {
+	var result geo.MultiLineString
+	for _, pw := range r.Path {
+		if !pw.Connected || len(result) == 0 {
+			result = append(result, append(geo.LineString(nil), pw.Line...))
+			continue
+		}
+
+		last := len(result) - 1
+		result[last] = append(result[last], pw.Line[1:]...)
+	}
+
+	return result
+}
+
+// orient returns the ways of the path in the direction of travel.
+// A way that is not connected to the previous one
+// is oriented towards the next way.
+func orient(ways []*osm.Way) []PathWay {
+	result := make([]PathWay, 0, len(ways))
+	var end osm.NodeID
+	for i, w := range ways {
+		var next *osm.Way
+		if i+1 < len(ways) {
+			next = ways[i+1]
+		}
+
+		pw := PathWay{WayID: w.ID}
+		first, last := w.Nodes[0].ID, w.Nodes[len(w.Nodes)-1].ID
+		var nodes osm.WayNodes
+		switch {
+		case closed(w) && i > 0 && index(w.Nodes, end) >= 0:
+			pw.Connected = true
+			nodes = ringPart(w.Nodes, index(w.Nodes, end), next)
+		case closed(w):
+			nodes = ringPart(w.Nodes, 0, nil)
+		case i > 0 && first == end:
+			pw.Connected = true
+			nodes = w.Nodes
+		case i > 0 && last == end:
+			pw.Connected, pw.Reversed = true, true
+			nodes = reverse(w.Nodes)
+		case next != nil && !touches(next, last) && touches(next, first):
+			pw.Reversed = true
+			nodes = reverse(w.Nodes)
+		default:
+			nodes = w.Nodes
+		}
+
+		pw.Connected = pw.Connected || i == 0
+		pw.Nodes = nodes.NodeIDs()
+		if closed(w) {
+			for _, wn := range w.Nodes[:len(w.Nodes)-1] {
+				if index(nodes, wn.ID) < 0 {
+					pw.Untraveled = append(pw.Untraveled, wn.ID)
+				}
+			}
+		}
+
+		pw.Line = make(geo.LineString, len(nodes))
+		for j, wn := range nodes {
+			pw.Line[j] = wn.Point()
+		}
+
+		end = pw.Nodes[len(pw.Nodes)-1]
+		result = append(result, pw)
+	}
+
+	return result
+}
+
+// ringPart returns the nodes of the closed way from the start index,
+// in the direction of the way, to the first node touching the next way.
+// The full ring is returned if the next way does not touch it.
+func ringPart(nodes osm.WayNodes, start int, next *osm.Way) osm.WayNodes {
+	// the last node of a closed way is the first
+	ring := nodes[:len(nodes)-1]
+	result := osm.WayNodes{ring[start]}
+	for i := 1; i <= len(ring); i++ {
+		wn := ring[(start+i)%len(ring)]
+		result = append(result, wn)
+		if next != nil && touches(next, wn.ID) {
+			return result
+		}
+	}
+
+	return result
+}
+
+// touches returns true if the way can be entered at the node,
+// i.e. it is an endpoint or any node of a closed way.
+func touches(w *osm.Way, id osm.NodeID) bool {
+	if closed(w) {
+		return index(w.Nodes, id) >= 0
+	}
+
+	return w.Nodes[0].ID == id || w.Nodes[len(w.Nodes)-1].ID == id
+}
+
+func closed(w *osm.Way) bool {
+	return len(w.Nodes) > 2 && w.Nodes[0].ID == w.Nodes[len(w.Nodes)-1].ID
+}
+
+func index(nodes osm.WayNodes, id osm.NodeID) int {
+	for i, wn := range nodes {
+		if wn.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func reverse(nodes osm.WayNodes) osm.WayNodes {
+	result := make(osm.WayNodes, len(nodes))
+	for i, wn := range nodes {
+		result[len(nodes)-1-i] = wn
+	}
+
+	return result
+}
+
+func isStop(role string) bool {
+	return role == "stop" || role == "stop_entry_only" || role == "stop_exit_only"
+}
+
+func isPlatform(role string) bool {
+	return role == "platform" || role == "platform_entry_only" || role == "platform_exit_only"
+}
+
+// isPath returns true for the roles of the ways traveled, forward and
+// backward are from the older schema but still common.
+func isPath(role string) bool {
+	return role == "" || role == "forward" || role == "backward"
+}
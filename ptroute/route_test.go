@@ -0,0 +1,214 @@
+package ptroute
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm"
+)
+
+func testWay(id osm.WayID, nodes ...osm.NodeID) *osm.Way {
+	w := &osm.Way{ID: id}
+	for _, n := range nodes {
+		w.Nodes = append(w.Nodes, osm.WayNode{ID: n, Version: 1, Lon: float64(n), Lat: 1})
+	}
+
+	return w
+}
+
+// testWays is a path 1-2-3, 5-4-3 traveled backwards,
+// a roundabout 5-6-7-8-5 entered at 5 and left at 7, and 7-9.
+// The loop 3-10-11-12-3 can be entered at 3 and left at 11 by 11-13.
+func testWays() map[osm.WayID]*osm.Way {
+	return map[osm.WayID]*osm.Way{
+		1: testWay(1, 1, 2, 3),
+		2: testWay(2, 5, 4, 3),
+		3: testWay(3, 5, 6, 7, 8, 5),
+		4: testWay(4, 7, 9),
+		5: testWay(5, 3, 2, 1),
+		6: testWay(6, 20, 21),
+		7: testWay(7, 3, 10, 11, 12, 3),
+		8: testWay(8, 11, 13),
+	}
+}
+
+func testRoute(ways ...osm.WayID) *osm.Relation {
+	r := &osm.Relation{
+		ID: 100,
+		Tags: osm.Tags{
+			{Key: "type", Value: "route"},
+			{Key: "route", Value: "bus"},
+			{Key: "ref", Value: "42"},
+			{Key: "name", Value: "Bus 42"},
+		},
+		Members: osm.Members{
+			{Type: osm.TypeNode, Ref: 2, Role: "stop_entry_only"},
+			{Type: osm.TypeWay, Ref: 50, Role: "platform"},
+			{Type: osm.TypeNode, Ref: 6, Role: "stop"},
+			{Type: osm.TypeNode, Ref: 51, Role: "platform"},
+			{Type: osm.TypeNode, Ref: 9, Role: "stop_exit_only"},
+		},
+	}
+
+	for _, id := range ways {
+		r.Members = append(r.Members, osm.Member{Type: osm.TypeWay, Ref: int64(id)})
+	}
+
+	return r
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse(testRoute(1, 2, 3, 4), testWays())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.Mode != "bus" || r.Ref != "42" || r.Name != "Bus 42" {
+		t.Errorf("incorrect tags: %+v", r)
+	}
+
+	if len(r.Stops) != 3 || len(r.Platforms) != 2 {
+		t.Fatalf("incorrect stops: %v %v", r.Stops, r.Platforms)
+	}
+
+	if !r.Stops[0].EntryOnly() || r.Stops[1].EntryOnly() || !r.Stops[2].ExitOnly() {
+		t.Errorf("incorrect stop roles: %v", r.Stops)
+	}
+
+	if id := r.Platforms[0].FeatureID(); id != osm.WayID(50).FeatureID() {
+		t.Errorf("incorrect platform: %v", id)
+	}
+
+	expected := []PathWay{
+		{Index: 5, WayID: 1, Connected: true, Nodes: []osm.NodeID{1, 2, 3}},
+		{Index: 6, WayID: 2, Connected: true, Reversed: true, Nodes: []osm.NodeID{3, 4, 5}},
+		{Index: 7, WayID: 3, Connected: true, Nodes: []osm.NodeID{5, 6, 7}, Untraveled: []osm.NodeID{8}},
+		{Index: 8, WayID: 4, Connected: true, Nodes: []osm.NodeID{7, 9}},
+	}
+
+	for i := range r.Path {
+		r.Path[i].Line = nil
+	}
+
+	if !reflect.DeepEqual(r.Path, expected) {
+		t.Errorf("incorrect path")
+		t.Logf("%v", r.Path)
+		t.Logf("%v", expected)
+	}
+
+	if nodes := r.Nodes(); !reflect.DeepEqual(nodes, []osm.NodeID{1, 2, 3, 4, 5, 6, 7, 9}) {
+		t.Errorf("incorrect nodes: %v", nodes)
+	}
+}
+
+func TestParse_reversedFirst(t *testing.T) {
+	r, err := Parse(testRoute(5, 2), testWays())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !r.Path[0].Reversed || !r.Path[1].Reversed || !r.Path[1].Connected {
+		t.Errorf("incorrect path: %v", r.Path)
+	}
+
+	if nodes := r.Nodes(); !reflect.DeepEqual(nodes, []osm.NodeID{1, 2, 3, 4, 5}) {
+		t.Errorf("incorrect nodes: %v", nodes)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	_, err := Parse(&osm.Relation{ID: 1, Tags: osm.Tags{{Key: "type", Value: "route"}, {Key: "route", Value: "hiking"}}}, nil)
+	if !errors.Is(err, ErrNotRoute) {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	_, err = Parse(testRoute(1, 10), testWays())
+	var e *Error
+	if !errors.Is(err, ErrWayNotFound) || !errors.As(err, &e) || e.Member != 6 {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestRoute_LineStrings(t *testing.T) {
+	r, err := Parse(testRoute(1, 6, 4), testWays())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := geo.MultiLineString{
+		{{1, 1}, {2, 1}, {3, 1}},
+		{{20, 1}, {21, 1}},
+		{{7, 1}, {9, 1}},
+	}
+
+	if ls := r.LineStrings(); !reflect.DeepEqual(ls, expected) {
+		t.Errorf("incorrect lines: %v", ls)
+	}
+
+	r, err = Parse(testRoute(1, 2, 3, 4), testWays())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ls := r.LineStrings(); len(ls) != 1 || len(ls[0]) != 8 {
+		t.Errorf("incorrect lines: %v", ls)
+	}
+}
+
+func TestParseMaster(t *testing.T) {
+	m, err := ParseMaster(&osm.Relation{
+		ID: 1,
+		Tags: osm.Tags{
+			{Key: "type", Value: "route_master"},
+			{Key: "route_master", Value: "bus"},
+			{Key: "ref", Value: "42"},
+		},
+		Members: osm.Members{
+			{Type: osm.TypeRelation, Ref: 100},
+			{Type: osm.TypeRelation, Ref: 101},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.Mode != "bus" || m.Ref != "42" || !reflect.DeepEqual(m.Routes, []osm.RelationID{100, 101}) {
+		t.Errorf("incorrect master: %+v", m)
+	}
+
+	_, err = ParseMaster(&osm.Relation{ID: 1})
+	if !errors.Is(err, ErrNotRouteMaster) {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	broken := testRoute(10)
+	broken.ID = 101
+
+	relations := osm.Relations{
+		testRoute(1, 2, 3, 4),
+		broken,
+		{
+			ID:      1,
+			Tags:    osm.Tags{{Key: "type", Value: "route_master"}, {Key: "route_master", Value: "bus"}},
+			Members: osm.Members{{Type: osm.TypeRelation, Ref: 100}, {Type: osm.TypeRelation, Ref: 101}},
+		},
+		{ID: 2, Tags: osm.Tags{{Key: "type", Value: "route"}, {Key: "route", Value: "hiking"}}},
+	}
+
+	masters, routes, errs := ParseAll(relations, testWays())
+	if len(masters) != 1 || len(routes) != 1 || len(errs) != 1 {
+		t.Fatalf("incorrect results: %v %v %v", masters, routes, errs)
+	}
+
+	if routes[0].Master != 1 {
+		t.Errorf("incorrect master: %v", routes[0].Master)
+	}
+
+	if !errors.Is(errs[0], ErrWayNotFound) {
+		t.Errorf("incorrect error: %v", errs[0])
+	}
+}
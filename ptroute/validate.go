@@ -0,0 +1,134 @@
+package ptroute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+var (
+	// ErrMemberOrder is returned by Validate for stop or
+	// platform members after the first way of the path.
+	ErrMemberOrder = errors.New("ptroute: stop or platform after the ways")
+	// ErrEmptyPath is returned by Validate if the route has no ways.
+	ErrEmptyPath = errors.New("ptroute: route has no ways")
+	// ErrGap is returned by Validate for a way that is
+	// not connected to the previous way of the path.
+	ErrGap = errors.New("ptroute: gap in path")
+	// ErrInvalidStop is returned by Validate for a stop member that is not a node.
+	ErrInvalidStop = errors.New("ptroute: stop is not a node")
+	// ErrStopNotOnPath is returned by Validate for a stop that is not a node of the path.
+	ErrStopNotOnPath = errors.New("ptroute: stop not on path")
+	// ErrStopOrder is returned by Validate for a stop that is
+	// on the path but before the previous stop.
+	ErrStopOrder = errors.New("ptroute: stop out of order")
+
+	_ error = Errors{}
+)
+
+// Errors is returned by Validate with all the problems found.
+type Errors []*Error
+
+// Error returns a pretty string of the errors.
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e))
+	for _, v := range e {
+		msgs = append(msgs, v.Error())
+	}
+
+	return fmt.Sprintf("ptroute: %d errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors so they can be checked with errors.Is.
+func (e Errors) Unwrap() []error {
+	result := make([]error, len(e))
+	for i, v := range e {
+		result[i] = v
+	}
+
+	return result
+}
+
+// Validate checks the route against the PTv2 schema, i.e. the stops
+// and platforms come before the ways, the path has no gaps and the
+// stops are nodes of the path in the order traveled. Stops on the
+// untraveled part of a closed way are on the path, their order is not checked.
+// Returns nil or Errors with all the problems found.
+func (r *Route) Validate() error {
+	var errs Errors
+	add := func(member int, err error) {
+		errs = append(errs, &Error{RelationID: r.RelationID, Member: member, Err: err})
+	}
+
+	if len(r.Path) == 0 {
+		add(-1, ErrEmptyPath)
+	} else {
+		firstWay := r.Path[0].Index
+		for _, s := range r.Stops {
+			if s.Index > firstWay {
+				add(s.Index, ErrMemberOrder)
+			}
+		}
+
+		for _, s := range r.Platforms {
+			if s.Index > firstWay {
+				add(s.Index, ErrMemberOrder)
+			}
+		}
+	}
+
+	for _, pw := range r.Path {
+		if !pw.Connected {
+			add(pw.Index, ErrGap)
+		}
+	}
+
+	// the stops must be found in order, a node can be
+	// on the path more than once if the route loops
+	nodes := r.Nodes()
+	untraveled := make(map[osm.NodeID]bool)
+	for _, pw := range r.Path {
+		for _, id := range pw.Untraveled {
+			untraveled[id] = true
+		}
+	}
+
+	position := 0
+	for _, s := range r.Stops {
+		if s.Type != osm.TypeNode {
+			add(s.Index, ErrInvalidStop)
+			continue
+		}
+
+		id := osm.NodeID(s.Ref)
+		if i := indexFrom(nodes, id, position); i >= 0 {
+			position = i
+		} else if indexFrom(nodes, id, 0) >= 0 {
+			add(s.Index, ErrStopOrder)
+		} else if len(nodes) > 0 && !untraveled[id] {
+			add(s.Index, ErrStopNotOnPath)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func indexFrom(nodes []osm.NodeID, id osm.NodeID, start int) int {
+	for i := start; i < len(nodes); i++ {
+		if nodes[i] == id {
+			return i
+		}
+	}
+
+	return -1
+}
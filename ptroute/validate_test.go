@@ -0,0 +1,116 @@
+package ptroute
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestRoute_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		route  func() *osm.Relation
+		errs   []error
+		member []int
+	}{
+		{
+			name:  "valid",
+			route: func() *osm.Relation { return testRoute(1, 2, 3, 4) },
+		},
+		{
+			name:   "gap",
+			route:  func() *osm.Relation { return testRoute(1, 6, 4) },
+			errs:   []error{ErrGap, ErrGap, ErrStopNotOnPath},
+			member: []int{6, 7, 2},
+		},
+		{
+			name: "stop on the untraveled part of a closed way",
+			route: func() *osm.Relation {
+				r := testRoute(1, 7, 8)
+				r.Members[2].Ref = 12
+				r.Members[4].Ref = 13
+				return r
+			},
+		},
+		{
+			name: "stop off a closed way",
+			route: func() *osm.Relation {
+				r := testRoute(1, 7, 8)
+				r.Members[2].Ref = 20
+				r.Members[4].Ref = 13
+				return r
+			},
+			errs:   []error{ErrStopNotOnPath},
+			member: []int{2},
+		},
+		{
+			name:   "empty path",
+			route:  func() *osm.Relation { return testRoute() },
+			errs:   []error{ErrEmptyPath},
+			member: []int{-1},
+		},
+		{
+			name: "member order",
+			route: func() *osm.Relation {
+				r := testRoute(1, 2, 3, 4)
+				r.Members = append(r.Members, osm.Member{Type: osm.TypeNode, Ref: 52, Role: "platform"})
+				return r
+			},
+			errs:   []error{ErrMemberOrder},
+			member: []int{9},
+		},
+		{
+			name: "stop order",
+			route: func() *osm.Relation {
+				r := testRoute(1, 2, 3, 4)
+				r.Members[0], r.Members[2] = r.Members[2], r.Members[0]
+				return r
+			},
+			errs:   []error{ErrStopOrder},
+			member: []int{2},
+		},
+		{
+			name: "stop not a node",
+			route: func() *osm.Relation {
+				r := testRoute(1, 2, 3, 4)
+				r.Members[0].Type = osm.TypeWay
+				return r
+			},
+			errs:   []error{ErrInvalidStop},
+			member: []int{0},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Parse(tc.route(), testWays())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = r.Validate()
+			if tc.errs == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) != len(tc.errs) {
+				t.Fatalf("incorrect errors: %v", err)
+			}
+
+			for i, e := range errs {
+				if e.Err != tc.errs[i] || e.Member != tc.member[i] || e.RelationID != 100 {
+					t.Errorf("incorrect error %d: %v", i, e)
+				}
+
+				if !errors.Is(err, tc.errs[i]) {
+					t.Errorf("errors.Is should find %v", tc.errs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package osm
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// IDAllocator hands out negative placeholder ids for new objects,
+// as used by editors before the objects are uploaded.
+// Ids are allocated separately for nodes, ways and relations starting at -1.
+// It is safe for concurrent use.
+type IDAllocator struct {
+	mu       sync.Mutex
+	node     int64
+	way      int64
+	relation int64
+}
+
+// NewIDAllocator creates a new allocator with the next id of every type being -1.
+func NewIDAllocator() *IDAllocator {
+	return &IDAllocator{}
+}
+
+// NodeID returns the next new node id.
+func (a *IDAllocator) NodeID() NodeID {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.node--
+	return NodeID(a.node)
+}
+
+// WayID returns the next new way id.
+func (a *IDAllocator) WayID() WayID {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.way--
+	return WayID(a.way)
+}
+
+// RelationID returns the next new relation id.
+func (a *IDAllocator) RelationID() RelationID {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.relation--
+	return RelationID(a.relation)
+}
+
+// Reserve makes sure the allocator will not return any of the negative
+// ids already used by the elements and their references in the data.
+// Useful when adding new objects to data that already contains some.
+func (a *IDAllocator) Reserve(o *OSM) {
+	if o == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, n := range o.Nodes {
+		a.node = min(a.node, int64(n.ID))
+	}
+
+	for _, w := range o.Ways {
+		a.way = min(a.way, int64(w.ID))
+		for _, wn := range w.Nodes {
+			a.node = min(a.node, int64(wn.ID))
+		}
+	}
+
+	for _, r := range o.Relations {
+		a.relation = min(a.relation, int64(r.ID))
+		for _, m := range r.Members {
+			switch m.Type {
+			case TypeNode:
+				a.node = min(a.node, m.Ref)
+			case TypeWay:
+				a.way = min(a.way, m.Ref)
+			case TypeRelation:
+				a.relation = min(a.relation, m.Ref)
+			}
+		}
+	}
+}
+
+// IDMapping is the new id and version of an object after an upload.
+// NewID is 0 if the object was deleted.
+type IDMapping struct {
+	Type       Type
+	OldID      int64
+	NewID      int64
+	NewVersion int
+}
+
+// IDRemapper rewrites the ids and versions of elements and all the
+// references to them, e.g. to replace placeholder ids with the
+// ones assigned by the server after an upload.
+type IDRemapper struct {
+	mappings map[idMappingKey]IDMapping
+}
+
+type idMappingKey struct {
+	Type Type
+	ID   int64
+}
+
+// NewIDRemapper creates a new remapper with the given mappings.
+// Mappings for deleted objects, i.e. with a NewID of 0, are ignored.
+func NewIDRemapper(mappings ...IDMapping) *IDRemapper {
+	r := &IDRemapper{mappings: make(map[idMappingKey]IDMapping, len(mappings))}
+	for _, m := range mappings {
+		r.Add(m)
+	}
+
+	return r
+}
+
+// Add adds a mapping to the remapper.
+// Mappings for deleted objects, i.e. with a NewID of 0, are ignored.
+func (r *IDRemapper) Add(m IDMapping) {
+	if m.NewID == 0 {
+		return
+	}
+
+	r.mappings[idMappingKey{Type: m.Type, ID: m.OldID}] = m
+}
+
+// Len returns the number of mappings.
+func (r *IDRemapper) Len() int {
+	return len(r.mappings)
+}
+
+func (r *IDRemapper) lookup(t Type, id int64) (IDMapping, bool) {
+	m, ok := r.mappings[idMappingKey{Type: t, ID: id}]
+	return m, ok
+}
+
+// ElementID returns the new element id for the given element id.
+// The id is returned unchanged if there is no mapping.
+// Element ids can only hold positive ids,
+// so this is useful to remap ids of existing objects.
+func (r *IDRemapper) ElementID(id ElementID) ElementID {
+	m, ok := r.lookup(id.Type(), id.Ref())
+	if !ok {
+		return id
+	}
+
+	fid, err := m.Type.FeatureID(m.NewID)
+	if err != nil {
+		return id
+	}
+
+	return fid.ElementID(m.NewVersion)
+}
+
+// ApplyOSM rewrites the ids and versions of the elements in the data
+// as well as the way node refs and relation member refs.
+func (r *IDRemapper) ApplyOSM(o *OSM) {
+	if o == nil {
+		return
+	}
+
+	for _, n := range o.Nodes {
+		if m, ok := r.lookup(TypeNode, int64(n.ID)); ok {
+			n.ID = NodeID(m.NewID)
+			n.Version = m.NewVersion
+		}
+	}
+
+	for _, w := range o.Ways {
+		if m, ok := r.lookup(TypeWay, int64(w.ID)); ok {
+			w.ID = WayID(m.NewID)
+			w.Version = m.NewVersion
+		}
+
+		for i, wn := range w.Nodes {
+			if m, ok := r.lookup(TypeNode, int64(wn.ID)); ok {
+				w.Nodes[i].ID = NodeID(m.NewID)
+				if wn.Version != 0 {
+					w.Nodes[i].Version = m.NewVersion
+				}
+			}
+		}
+	}
+
+	for _, rel := range o.Relations {
+		if m, ok := r.lookup(TypeRelation, int64(rel.ID)); ok {
+			rel.ID = RelationID(m.NewID)
+			rel.Version = m.NewVersion
+		}
+
+		for i, mem := range rel.Members {
+			if m, ok := r.lookup(mem.Type, mem.Ref); ok {
+				rel.Members[i].Ref = m.NewID
+				if mem.Version != 0 {
+					rel.Members[i].Version = m.NewVersion
+				}
+			}
+
+			for j, wn := range mem.Nodes {
+				if m, ok := r.lookup(TypeNode, int64(wn.ID)); ok {
+					rel.Members[i].Nodes[j].ID = NodeID(m.NewID)
+				}
+			}
+		}
+	}
+}
+
+// ApplyChange rewrites the ids in the create, modify and delete sections of the change.
+func (r *IDRemapper) ApplyChange(c *Change) {
+	if c == nil {
+		return
+	}
+
+	r.ApplyOSM(c.Create)
+	r.ApplyOSM(c.Modify)
+	r.ApplyOSM(c.Delete)
+}
+
+// ApplyDiff rewrites the ids in all the actions of the diff.
+func (r *IDRemapper) ApplyDiff(d *Diff) {
+	if d == nil {
+		return
+	}
+
+	for _, a := range d.Actions {
+		r.ApplyOSM(a.OSM)
+		r.ApplyOSM(a.Old)
+		r.ApplyOSM(a.New)
+	}
+}
+
+// DiffResult is the response of the OSM API after uploading a change.
+// It maps the ids in the change to the new ids and versions.
+type DiffResult struct {
+	XMLName   xml.Name          `xml:"diffResult"`
+	Version   string            `xml:"version,attr,omitempty"`
+	Generator string            `xml:"generator,attr,omitempty"`
+	Nodes     []DiffResultEntry `xml:"node"`
+	Ways      []DiffResultEntry `xml:"way"`
+	Relations []DiffResultEntry `xml:"relation"`
+}
+
+// DiffResultEntry is the result of uploading a single element.
+// NewID and NewVersion are 0 if the element was deleted.
+type DiffResultEntry struct {
+	OldID      int64 `xml:"old_id,attr"`
+	NewID      int64 `xml:"new_id,attr,omitempty"`
+	NewVersion int   `xml:"new_version,attr,omitempty"`
+}
+
+// Mappings returns the id mappings of the result.
+func (dr *DiffResult) Mappings() []IDMapping {
+	result := make([]IDMapping, 0, len(dr.Nodes)+len(dr.Ways)+len(dr.Relations))
+	result = appendIDMappings(result, TypeNode, dr.Nodes)
+	result = appendIDMappings(result, TypeWay, dr.Ways)
+	result = appendIDMappings(result, TypeRelation, dr.Relations)
+	return result
+}
+
+// IDRemapper returns a remapper with the id mappings of the result.
+func (dr *DiffResult) IDRemapper() *IDRemapper {
+	return NewIDRemapper(dr.Mappings()...)
+}
+
+func appendIDMappings(result []IDMapping, t Type, entries []DiffResultEntry) []IDMapping {
+	for _, e := range entries {
+		result = append(result, IDMapping{
+			Type:       t,
+			OldID:      e.OldID,
+			NewID:      e.NewID,
+			NewVersion: e.NewVersion,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,204 @@
+package osm
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestIDAllocator(t *testing.T) {
+	a := NewIDAllocator()
+	if id := a.NodeID(); id != -1 {
+		t.Errorf("incorrect node id: %v", id)
+	}
+
+	if id := a.NodeID(); id != -2 {
+		t.Errorf("incorrect node id: %v", id)
+	}
+
+	if id := a.WayID(); id != -1 {
+		t.Errorf("incorrect way id: %v", id)
+	}
+
+	if id := a.RelationID(); id != -1 {
+		t.Errorf("incorrect relation id: %v", id)
+	}
+}
+
+func TestIDAllocator_Reserve(t *testing.T) {
+	a := NewIDAllocator()
+	a.Reserve(&OSM{
+		Nodes: Nodes{{ID: -3}, {ID: 10}},
+		Ways: Ways{
+			{ID: -2, Nodes: WayNodes{{ID: -5}, {ID: 4}}},
+		},
+		Relations: Relations{
+			{ID: 1, Members: Members{
+				{Type: TypeWay, Ref: -7},
+				{Type: TypeRelation, Ref: -4},
+			}},
+		},
+	})
+
+	if id := a.NodeID(); id != -6 {
+		t.Errorf("incorrect node id: %v", id)
+	}
+
+	if id := a.WayID(); id != -8 {
+		t.Errorf("incorrect way id: %v", id)
+	}
+
+	if id := a.RelationID(); id != -5 {
+		t.Errorf("incorrect relation id: %v", id)
+	}
+
+	// nil is a noop
+	a.Reserve(nil)
+}
+
+func TestIDRemapper_ApplyOSM(t *testing.T) {
+	r := NewIDRemapper(
+		IDMapping{Type: TypeNode, OldID: -1, NewID: 100, NewVersion: 1},
+		IDMapping{Type: TypeNode, OldID: 5, NewID: 5, NewVersion: 3},
+		IDMapping{Type: TypeWay, OldID: -1, NewID: 200, NewVersion: 1},
+		IDMapping{Type: TypeRelation, OldID: -1, NewID: 300, NewVersion: 1},
+		IDMapping{Type: TypeNode, OldID: 6}, // deleted
+	)
+
+	if l := r.Len(); l != 4 {
+		t.Errorf("incorrect length: %v", l)
+	}
+
+	o := &OSM{
+		Nodes: Nodes{{ID: -1}, {ID: 5, Version: 2}, {ID: 6, Version: 1}},
+		Ways: Ways{
+			{ID: -1, Nodes: WayNodes{{ID: -1}, {ID: 5, Version: 2}, {ID: 7}}},
+		},
+		Relations: Relations{
+			{ID: -1, Members: Members{
+				{Type: TypeNode, Ref: -1},
+				{Type: TypeWay, Ref: -1, Nodes: WayNodes{{ID: -1}}},
+				{Type: TypeRelation, Ref: -1},
+				{Type: TypeRelation, Ref: 9},
+			}},
+		},
+	}
+	r.ApplyOSM(o)
+
+	expected := &OSM{
+		Nodes: Nodes{{ID: 100, Version: 1}, {ID: 5, Version: 3}, {ID: 6, Version: 1}},
+		Ways: Ways{
+			{ID: 200, Version: 1, Nodes: WayNodes{{ID: 100}, {ID: 5, Version: 3}, {ID: 7}}},
+		},
+		Relations: Relations{
+			{ID: 300, Version: 1, Members: Members{
+				{Type: TypeNode, Ref: 100},
+				{Type: TypeWay, Ref: 200, Nodes: WayNodes{{ID: 100}}},
+				{Type: TypeRelation, Ref: 300},
+				{Type: TypeRelation, Ref: 9},
+			}},
+		},
+	}
+
+	if !reflect.DeepEqual(o, expected) {
+		t.Errorf("incorrect remap")
+		t.Logf("%+v", o)
+		t.Logf("%+v", expected)
+	}
+
+	// nil is a noop
+	r.ApplyOSM(nil)
+	r.ApplyChange(nil)
+	r.ApplyDiff(nil)
+}
+
+func TestIDRemapper_ApplyChange(t *testing.T) {
+	a := NewIDAllocator()
+	n := &Node{ID: a.NodeID()}
+	w := &Way{ID: 1, Version: 1, Nodes: WayNodes{{ID: n.ID}, {ID: 2}}}
+	c := &Change{
+		Create: &OSM{Nodes: Nodes{n}},
+		Modify: &OSM{Ways: Ways{w}},
+		Delete: &OSM{Nodes: Nodes{{ID: 3, Version: 1}}},
+	}
+
+	dr := &DiffResult{
+		Nodes: []DiffResultEntry{{OldID: -1, NewID: 10, NewVersion: 1}, {OldID: 3}},
+		Ways:  []DiffResultEntry{{OldID: 1, NewID: 1, NewVersion: 2}},
+	}
+	dr.IDRemapper().ApplyChange(c)
+
+	if n.ID != 10 || n.Version != 1 {
+		t.Errorf("incorrect node: %+v", n)
+	}
+
+	if w.Version != 2 || w.Nodes[0].ID != 10 {
+		t.Errorf("incorrect way: %+v", w)
+	}
+
+	if v := c.Delete.Nodes[0].ID; v != 3 {
+		t.Errorf("deleted node should not change: %v", v)
+	}
+}
+
+func TestIDRemapper_ApplyDiff(t *testing.T) {
+	d := &Diff{
+		Actions: Actions{
+			{Type: ActionCreate, OSM: &OSM{Nodes: Nodes{{ID: 1, Version: 1}}}},
+			{
+				Type: ActionModify,
+				Old:  &OSM{Ways: Ways{{ID: 2, Nodes: WayNodes{{ID: 1}}}}},
+				New:  &OSM{Ways: Ways{{ID: 2, Nodes: WayNodes{{ID: 1}}}}},
+			},
+		},
+	}
+
+	NewIDRemapper(IDMapping{Type: TypeNode, OldID: 1, NewID: 11, NewVersion: 1}).ApplyDiff(d)
+	if v := d.Actions[0].OSM.Nodes[0].ID; v != 11 {
+		t.Errorf("incorrect node id: %v", v)
+	}
+
+	if v := d.Actions[1].Old.Ways[0].Nodes[0].ID; v != 11 {
+		t.Errorf("incorrect old way node id: %v", v)
+	}
+
+	if v := d.Actions[1].New.Ways[0].Nodes[0].ID; v != 11 {
+		t.Errorf("incorrect new way node id: %v", v)
+	}
+}
+
+func TestIDRemapper_ElementID(t *testing.T) {
+	r := NewIDRemapper(IDMapping{Type: TypeWay, OldID: 1, NewID: 2, NewVersion: 5})
+
+	if id := r.ElementID(WayID(1).ElementID(3)); id != WayID(2).ElementID(5) {
+		t.Errorf("incorrect element id: %v", id)
+	}
+
+	if id := r.ElementID(NodeID(1).ElementID(3)); id != NodeID(1).ElementID(3) {
+		t.Errorf("should not change: %v", id)
+	}
+}
+
+func TestDiffResult_UnmarshalXML(t *testing.T) {
+	data := []byte(`<diffResult version="0.6" generator="OpenStreetMap server">
+  <node old_id="-1" new_id="1234" new_version="1"/>
+  <node old_id="10" new_id="10" new_version="3"/>
+  <way old_id="-2" new_id="5678" new_version="1"/>
+  <relation old_id="20"/>
+</diffResult>`)
+
+	dr := &DiffResult{}
+	if err := xml.Unmarshal(data, dr); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	expected := []IDMapping{
+		{Type: TypeNode, OldID: -1, NewID: 1234, NewVersion: 1},
+		{Type: TypeNode, OldID: 10, NewID: 10, NewVersion: 3},
+		{Type: TypeWay, OldID: -2, NewID: 5678, NewVersion: 1},
+		{Type: TypeRelation, OldID: 20},
+	}
+	if m := dr.Mappings(); !reflect.DeepEqual(m, expected) {
+		t.Errorf("incorrect mappings: %+v", m)
+	}
+}
@@ -0,0 +1,347 @@
+package restriction
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// The common restriction kinds.
+const (
+	NoLeftTurn     Kind = "no_left_turn"
+	NoRightTurn    Kind = "no_right_turn"
+	NoStraightOn   Kind = "no_straight_on"
+	NoUTurn        Kind = "no_u_turn"
+	NoEntry        Kind = "no_entry"
+	NoExit         Kind = "no_exit"
+	OnlyLeftTurn   Kind = "only_left_turn"
+	OnlyRightTurn  Kind = "only_right_turn"
+	OnlyStraightOn Kind = "only_straight_on"
+	OnlyUTurn      Kind = "only_u_turn"
+)
+
+var (
+	// ErrNotRestriction is returned if the relation is not of type restriction.
+	ErrNotRestriction = errors.New("restriction: relation is not a restriction")
+	// ErrMissingKind is returned if the relation has no restriction tag.
+	ErrMissingKind = errors.New("restriction: missing restriction tag")
+	// ErrUnknownKind is returned if the restriction does not start with no_ or only_.
+	ErrUnknownKind = errors.New("restriction: unknown restriction kind")
+	// ErrConflictingKinds is returned if the vehicle specific
+	// restriction tags have different values.
+	ErrConflictingKinds = errors.New("restriction: conflicting restriction kinds")
+	// ErrMissingFrom is returned if the relation has no from way.
+	ErrMissingFrom = errors.New("restriction: missing from member")
+	// ErrMissingVia is returned if the relation has no via node or way.
+	ErrMissingVia = errors.New("restriction: missing via member")
+	// ErrMissingTo is returned if the relation has no to way.
+	ErrMissingTo = errors.New("restriction: missing to member")
+	// ErrMultipleFrom is returned for more than one from way,
+	// only allowed for no_entry restrictions.
+	ErrMultipleFrom = errors.New("restriction: multiple from members")
+	// ErrMultipleTo is returned for more than one to way,
+	// only allowed for no_exit restrictions.
+	ErrMultipleTo = errors.New("restriction: multiple to members")
+	// ErrMultipleVia is returned for more than one via node
+	// or a mix of via nodes and ways.
+	ErrMultipleVia = errors.New("restriction: multiple via nodes or mixed via members")
+	// ErrInvalidMember is returned if a from or to member is not a
+	// way or a via member not a node or way.
+	ErrInvalidMember = errors.New("restriction: invalid member type")
+	// ErrWayNotFound is returned by Validate if a member way is not found.
+	ErrWayNotFound = errors.New("restriction: member way not found")
+	// ErrNotConnected is returned by Validate if a member
+	// way is not connected to the via node or ways.
+	ErrNotConnected = errors.New("restriction: member way not connected to via")
+
+	_ error = &Error{}
+)
+
+// Error is returned for broken restriction relations.
+// Err is one of the errors of this package, WayID is
+// the member way with the problem, if known.
+type Error struct {
+	RelationID osm.RelationID
+	WayID      osm.WayID
+	Err        error
+}
+
+// Error returns a pretty string of the error.
+func (e *Error) Error() string {
+	if e.WayID != 0 {
+		return fmt.Sprintf("relation %d: way %d: %v", e.RelationID, e.WayID, e.Err)
+	}
+
+	return fmt.Sprintf("relation %d: %v", e.RelationID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Kind is a strong type for the value of the restriction tag, e.g. no_left_turn.
+type Kind string
+
+// Only returns true for mandatory, only_*, restrictions.
+func (k Kind) Only() bool {
+	return strings.HasPrefix(string(k), "only_")
+}
+
+// No returns true for prohibitory, no_*, restrictions.
+func (k Kind) No() bool {
+	return strings.HasPrefix(string(k), "no_")
+}
+
+// Restriction is a turn restriction parsed from a type=restriction relation.
+type Restriction struct {
+	RelationID osm.RelationID
+
+	// Kind is empty if the restriction only applies under conditions.
+	Kind Kind
+
+	// From has more than one way only for no_entry,
+	// To only for no_exit restrictions.
+	From []osm.WayID
+	// Either the via node or the via ways are set.
+	ViaNode osm.NodeID
+	ViaWays []osm.WayID
+	To      []osm.WayID
+
+	// Vehicles are the vehicle classes of restriction:<vehicle> tags,
+	// nil if the restriction applies to all vehicles.
+	Vehicles []string
+	// Except are the vehicle classes of the except tag.
+	Except []string
+	// Conditions are from the restriction:conditional tags.
+	Conditions []Condition
+}
+
+// Condition is a restriction that only applies under a condition,
+// e.g. no_right_turn @ (Mo-Fr 07:00-09:00).
+type Condition struct {
+	Kind      Kind
+	Condition string
+	// Vehicles is set for restriction:<vehicle>:conditional tags.
+	Vehicles []string
+}
+
+// Parse parses a restriction relation. The restriction kind is taken from
+// the restriction tag or the restriction:<vehicle> tags that must then all
+// have the same value. The members must be from and to ways and a via node
+// or one or more via ways. Members with other roles are ignored.
+// The topology is not checked, see Restriction.Validate.
+// If the restriction tag is set the restriction:<vehicle> tags are ignored.
+// Broken relations return an *Error wrapping an error of this package.
+func Parse(r *osm.Relation) (*Restriction, error) {
+	if r.Tags.Find("type") != "restriction" {
+		return nil, &Error{RelationID: r.ID, Err: ErrNotRestriction}
+	}
+
+	result := &Restriction{RelationID: r.ID}
+	if err := result.parseTags(r.Tags); err != nil {
+		return nil, &Error{RelationID: r.ID, Err: err}
+	}
+
+	if err := result.parseMembers(r.Members); err != nil {
+		return nil, &Error{RelationID: r.ID, Err: err}
+	}
+
+	return result, nil
+}
+
+func (r *Restriction) parseTags(tags osm.Tags) error {
+	r.Kind = Kind(tags.Find("restriction"))
+	for _, t := range tags {
+		if !strings.HasPrefix(t.Key, "restriction:") {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(t.Key, "restriction:")
+		switch {
+		case suffix == "conditional":
+			r.Conditions = append(r.Conditions, parseConditions(t.Value, nil)...)
+		case strings.HasSuffix(suffix, ":conditional"):
+			vehicle := strings.TrimSuffix(suffix, ":conditional")
+			r.Conditions = append(r.Conditions, parseConditions(t.Value, []string{vehicle})...)
+		case tags.Find("restriction") == "":
+			// vehicle specific restriction
+			if r.Kind != "" && r.Kind != Kind(t.Value) {
+				return ErrConflictingKinds
+			}
+
+			r.Kind = Kind(t.Value)
+			r.Vehicles = append(r.Vehicles, suffix)
+		}
+	}
+
+	if v := tags.Find("except"); v != "" {
+		r.Except = splitList(v)
+	}
+
+	sort.Strings(r.Vehicles)
+	if r.Kind == "" && len(r.Conditions) == 0 {
+		return ErrMissingKind
+	}
+
+	if r.Kind != "" && !r.Kind.No() && !r.Kind.Only() {
+		return ErrUnknownKind
+	}
+
+	for _, c := range r.Conditions {
+		if !c.Kind.No() && !c.Kind.Only() {
+			return ErrUnknownKind
+		}
+	}
+
+	return nil
+}
+
+func (r *Restriction) parseMembers(members osm.Members) error {
+	for _, m := range members {
+		switch m.Role {
+		case "from":
+			if m.Type != osm.TypeWay {
+				return ErrInvalidMember
+			}
+			r.From = append(r.From, osm.WayID(m.Ref))
+		case "to":
+			if m.Type != osm.TypeWay {
+				return ErrInvalidMember
+			}
+			r.To = append(r.To, osm.WayID(m.Ref))
+		case "via":
+			switch m.Type {
+			case osm.TypeNode:
+				if r.ViaNode != 0 || len(r.ViaWays) > 0 {
+					return ErrMultipleVia
+				}
+				r.ViaNode = osm.NodeID(m.Ref)
+			case osm.TypeWay:
+				if r.ViaNode != 0 {
+					return ErrMultipleVia
+				}
+				r.ViaWays = append(r.ViaWays, osm.WayID(m.Ref))
+			default:
+				return ErrInvalidMember
+			}
+		}
+	}
+
+	switch {
+	case len(r.From) == 0:
+		return ErrMissingFrom
+	case len(r.To) == 0:
+		return ErrMissingTo
+	case r.ViaNode == 0 && len(r.ViaWays) == 0:
+		return ErrMissingVia
+	case len(r.From) > 1 && !r.allKinds(NoEntry):
+		return ErrMultipleFrom
+	case len(r.To) > 1 && !r.allKinds(NoExit):
+		return ErrMultipleTo
+	}
+
+	return nil
+}
+
+// allKinds returns true if the kind and the kind of all conditions is k.
+func (r *Restriction) allKinds(k Kind) bool {
+	if r.Kind != "" && r.Kind != k {
+		return false
+	}
+
+	for _, c := range r.Conditions {
+		if c.Kind != k {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AppliesTo returns true if the restriction applies to the vehicle class,
+// ignoring the conditions. An empty vehicle matches all restrictions
+// that are not for specific vehicles.
+func (r *Restriction) AppliesTo(vehicle string) bool {
+	if r.Kind == "" {
+		return false
+	}
+
+	for _, e := range r.Except {
+		if e == vehicle {
+			return false
+		}
+	}
+
+	if r.Vehicles == nil {
+		return true
+	}
+
+	for _, v := range r.Vehicles {
+		if v == vehicle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseConditions parses a conditional value like
+// "no_right_turn @ (Mo-Fr 07:00-09:00); no_left_turn @ wet".
+func parseConditions(value string, vehicles []string) []Condition {
+	var result []Condition
+	for _, part := range splitConditional(value) {
+		kind, condition, ok := strings.Cut(part, "@")
+		if !ok {
+			continue
+		}
+
+		condition = strings.TrimSpace(condition)
+		if strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+			condition = strings.TrimSpace(condition[1 : len(condition)-1])
+		}
+
+		result = append(result, Condition{
+			Kind:      Kind(strings.TrimSpace(kind)),
+			Condition: condition,
+			Vehicles:  vehicles,
+		})
+	}
+
+	return result
+}
+
+// splitConditional splits on the semicolons outside of parentheses.
+func splitConditional(value string) []string {
+	var result []string
+	var depth, start int
+	for i, c := range value {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ';':
+			if depth == 0 {
+				result = append(result, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(result, value[start:])
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, v := range strings.Split(value, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,234 @@
+package restriction
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestParse(t *testing.T) {
+	members := osm.Members{
+		{Type: osm.TypeWay, Ref: 1, Role: "from"},
+		{Type: osm.TypeNode, Ref: 10, Role: "via"},
+		{Type: osm.TypeWay, Ref: 2, Role: "to"},
+		{Type: osm.TypeNode, Ref: 11, Role: "location_hint"},
+	}
+
+	cases := []struct {
+		name     string
+		tags     osm.Tags
+		members  osm.Members
+		expected *Restriction
+	}{
+		{
+			name:    "simple",
+			tags:    osm.Tags{{Key: "type", Value: "restriction"}, {Key: "restriction", Value: "no_left_turn"}},
+			members: members,
+			expected: &Restriction{
+				RelationID: 100,
+				Kind:       NoLeftTurn,
+				From:       []osm.WayID{1},
+				ViaNode:    10,
+				To:         []osm.WayID{2},
+			},
+		},
+		{
+			name: "vehicle specific with except",
+			tags: osm.Tags{
+				{Key: "type", Value: "restriction"},
+				{Key: "restriction:hgv", Value: "only_straight_on"},
+				{Key: "restriction:bus", Value: "only_straight_on"},
+				{Key: "except", Value: "emergency; psv"},
+			},
+			members: osm.Members{
+				{Type: osm.TypeWay, Ref: 1, Role: "from"},
+				{Type: osm.TypeWay, Ref: 3, Role: "via"},
+				{Type: osm.TypeWay, Ref: 4, Role: "via"},
+				{Type: osm.TypeWay, Ref: 2, Role: "to"},
+			},
+			expected: &Restriction{
+				RelationID: 100,
+				Kind:       OnlyStraightOn,
+				From:       []osm.WayID{1},
+				ViaWays:    []osm.WayID{3, 4},
+				To:         []osm.WayID{2},
+				Vehicles:   []string{"bus", "hgv"},
+				Except:     []string{"emergency", "psv"},
+			},
+		},
+		{
+			name: "conditional",
+			tags: osm.Tags{
+				{Key: "type", Value: "restriction"},
+				{Key: "restriction:conditional", Value: "no_right_turn @ (Mo-Fr 07:00-09:00; Sa 10:00-12:00); no_left_turn @ wet"},
+				{Key: "restriction:hgv:conditional", Value: "no_u_turn @ (weight>7.5)"},
+			},
+			members: members,
+			expected: &Restriction{
+				RelationID: 100,
+				From:       []osm.WayID{1},
+				ViaNode:    10,
+				To:         []osm.WayID{2},
+				Conditions: []Condition{
+					{Kind: NoRightTurn, Condition: "Mo-Fr 07:00-09:00; Sa 10:00-12:00"},
+					{Kind: NoLeftTurn, Condition: "wet"},
+					{Kind: NoUTurn, Condition: "weight>7.5", Vehicles: []string{"hgv"}},
+				},
+			},
+		},
+		{
+			name: "no entry with multiple from",
+			tags: osm.Tags{{Key: "type", Value: "restriction"}, {Key: "restriction", Value: "no_entry"}},
+			members: osm.Members{
+				{Type: osm.TypeWay, Ref: 1, Role: "from"},
+				{Type: osm.TypeWay, Ref: 3, Role: "from"},
+				{Type: osm.TypeNode, Ref: 10, Role: "via"},
+				{Type: osm.TypeWay, Ref: 2, Role: "to"},
+			},
+			expected: &Restriction{
+				RelationID: 100,
+				Kind:       NoEntry,
+				From:       []osm.WayID{1, 3},
+				ViaNode:    10,
+				To:         []osm.WayID{2},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Parse(&osm.Relation{ID: 100, Tags: tc.tags, Members: tc.members})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(r, tc.expected) {
+				t.Errorf("incorrect restriction")
+				t.Logf("%+v", r)
+				t.Logf("%+v", tc.expected)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tags := osm.Tags{{Key: "type", Value: "restriction"}, {Key: "restriction", Value: "no_left_turn"}}
+	from := osm.Member{Type: osm.TypeWay, Ref: 1, Role: "from"}
+	via := osm.Member{Type: osm.TypeNode, Ref: 10, Role: "via"}
+	to := osm.Member{Type: osm.TypeWay, Ref: 2, Role: "to"}
+
+	cases := []struct {
+		name    string
+		tags    osm.Tags
+		members osm.Members
+		err     error
+	}{
+		{
+			name:    "not a restriction",
+			tags:    osm.Tags{{Key: "type", Value: "multipolygon"}},
+			members: osm.Members{from, via, to},
+			err:     ErrNotRestriction,
+		},
+		{
+			name:    "missing kind",
+			tags:    osm.Tags{{Key: "type", Value: "restriction"}},
+			members: osm.Members{from, via, to},
+			err:     ErrMissingKind,
+		},
+		{
+			name:    "unknown kind",
+			tags:    osm.Tags{{Key: "type", Value: "restriction"}, {Key: "restriction", Value: "left_turn"}},
+			members: osm.Members{from, via, to},
+			err:     ErrUnknownKind,
+		},
+		{
+			name: "conflicting kinds",
+			tags: osm.Tags{
+				{Key: "type", Value: "restriction"},
+				{Key: "restriction:hgv", Value: "no_left_turn"},
+				{Key: "restriction:bus", Value: "no_right_turn"},
+			},
+			members: osm.Members{from, via, to},
+			err:     ErrConflictingKinds,
+		},
+		{
+			name:    "missing from",
+			tags:    tags,
+			members: osm.Members{via, to},
+			err:     ErrMissingFrom,
+		},
+		{
+			name:    "missing via",
+			tags:    tags,
+			members: osm.Members{from, to},
+			err:     ErrMissingVia,
+		},
+		{
+			name:    "missing to",
+			tags:    tags,
+			members: osm.Members{from, via},
+			err:     ErrMissingTo,
+		},
+		{
+			name:    "multiple from",
+			tags:    tags,
+			members: osm.Members{from, from, via, to},
+			err:     ErrMultipleFrom,
+		},
+		{
+			name:    "multiple to",
+			tags:    tags,
+			members: osm.Members{from, via, to, to},
+			err:     ErrMultipleTo,
+		},
+		{
+			name:    "mixed via",
+			tags:    tags,
+			members: osm.Members{from, via, {Type: osm.TypeWay, Ref: 3, Role: "via"}, to},
+			err:     ErrMultipleVia,
+		},
+		{
+			name:    "node as from",
+			tags:    tags,
+			members: osm.Members{{Type: osm.TypeNode, Ref: 1, Role: "from"}, via, to},
+			err:     ErrInvalidMember,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(&osm.Relation{ID: 100, Tags: tc.tags, Members: tc.members})
+			if !errors.Is(err, tc.err) {
+				t.Errorf("incorrect error: %v", err)
+			}
+
+			var e *Error
+			if !errors.As(err, &e) || e.RelationID != 100 {
+				t.Errorf("expected restriction error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRestriction_AppliesTo(t *testing.T) {
+	r := &Restriction{Kind: NoLeftTurn, Except: []string{"bicycle"}}
+	if !r.AppliesTo("motorcar") || !r.AppliesTo("") {
+		t.Errorf("should apply to all vehicles")
+	}
+
+	if r.AppliesTo("bicycle") {
+		t.Errorf("should not apply to exceptions")
+	}
+
+	r = &Restriction{Kind: NoLeftTurn, Vehicles: []string{"hgv"}}
+	if !r.AppliesTo("hgv") || r.AppliesTo("motorcar") {
+		t.Errorf("should only apply to hgv")
+	}
+
+	r = &Restriction{Conditions: []Condition{{Kind: NoLeftTurn, Condition: "wet"}}}
+	if r.AppliesTo("motorcar") {
+		t.Errorf("conditional only should not apply")
+	}
+}
@@ -0,0 +1,179 @@
+package restriction
+
+import "github.com/pchchv/osm"
+
+// Validate checks the topology of the restriction, i.e. the from and to
+// ways contain the via node, or the via ways form a chain connected at
+// their end nodes with the from way at the start and the to way at the end.
+// The via ways must be in order, a single via way must be connected
+// to the from and to ways at different ends. An *Error is returned wrapping
+// ErrWayNotFound or ErrNotConnected.
+func (r *Restriction) Validate(ways map[osm.WayID]*osm.Way) error {
+	lookup := func(id osm.WayID) (*osm.Way, error) {
+		w := ways[id]
+		if w == nil || len(w.Nodes) == 0 {
+			return nil, &Error{RelationID: r.RelationID, WayID: id, Err: ErrWayNotFound}
+		}
+
+		return w, nil
+	}
+
+	notConnected := func(id osm.WayID) error {
+		return &Error{RelationID: r.RelationID, WayID: id, Err: ErrNotConnected}
+	}
+
+	// the nodes connecting the from and to ways to the via
+	fromVia, toVia := []osm.NodeID{r.ViaNode}, []osm.NodeID{r.ViaNode}
+	if len(r.ViaWays) > 0 {
+		first, err := lookup(r.ViaWays[0])
+		if err != nil {
+			return err
+		}
+
+		fromVia, toVia = endpoints(first), endpoints(first)
+		if len(r.ViaWays) > 1 {
+			second, err := lookup(r.ViaWays[1])
+			if err != nil {
+				return err
+			}
+
+			// the node connecting the first two via ways
+			var end osm.NodeID
+			for _, n := range endpoints(first) {
+				if contains(endpoints(second), n) {
+					end = n
+					break
+				}
+			}
+
+			if end == 0 {
+				return notConnected(second.ID)
+			}
+
+			fromVia = []osm.NodeID{otherEnd(first, end)}
+			for _, id := range r.ViaWays[1:] {
+				w, err := lookup(id)
+				if err != nil {
+					return err
+				}
+
+				if !contains(endpoints(w), end) {
+					return notConnected(id)
+				}
+				end = otherEnd(w, end)
+			}
+
+			toVia = []osm.NodeID{end}
+		}
+	}
+
+	for _, id := range r.From {
+		w, err := lookup(id)
+		if err != nil {
+			return err
+		}
+
+		if !containsAny(w, fromVia) {
+			return notConnected(id)
+		}
+	}
+
+	for _, id := range r.To {
+		w, err := lookup(id)
+		if err != nil {
+			return err
+		}
+
+		if !containsAny(w, toVia) {
+			return notConnected(id)
+		}
+	}
+
+	// the from and to ways of a single open via way are at different ends
+	if len(r.ViaWays) == 1 && len(fromVia) == 2 {
+		for _, from := range r.From {
+			for _, to := range r.To {
+				if !differentEnds(ways[from], ways[to], fromVia) {
+					return notConnected(to)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseAll parses and validates all the restriction relations. Relations
+// that are not of type restriction are skipped, the errors of the
+// broken restrictions are returned with the valid restrictions.
+func ParseAll(relations osm.Relations, ways map[osm.WayID]*osm.Way) ([]*Restriction, []error) {
+	var result []*Restriction
+	var errs []error
+	for _, rel := range relations {
+		if rel.Tags.Find("type") != "restriction" {
+			continue
+		}
+
+		r, err := Parse(rel)
+		if err == nil {
+			err = r.Validate(ways)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	return result, errs
+}
+
+// endpoints returns the first and last node of the way,
+// only one if the way is closed.
+func endpoints(w *osm.Way) []osm.NodeID {
+	first, last := w.Nodes[0].ID, w.Nodes[len(w.Nodes)-1].ID
+	if first == last {
+		return []osm.NodeID{first}
+	}
+
+	return []osm.NodeID{first, last}
+}
+
+func otherEnd(w *osm.Way, n osm.NodeID) osm.NodeID {
+	if w.Nodes[0].ID == n {
+		return w.Nodes[len(w.Nodes)-1].ID
+	}
+
+	return w.Nodes[0].ID
+}
+
+// differentEnds returns true if the ways are connected to different ends.
+func differentEnds(a, b *osm.Way, ends []osm.NodeID) bool {
+	at := func(w *osm.Way, n osm.NodeID) bool {
+		return containsAny(w, []osm.NodeID{n})
+	}
+
+	return (at(a, ends[0]) && at(b, ends[1])) || (at(a, ends[1]) && at(b, ends[0]))
+}
+
+func contains(ids []osm.NodeID, id osm.NodeID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(w *osm.Way, ids []osm.NodeID) bool {
+	for _, wn := range w.Nodes {
+		if contains(ids, wn.ID) {
+			return true
+		}
+	}
+
+	return false
+}
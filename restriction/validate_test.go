@@ -0,0 +1,142 @@
+package restriction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+// 1 --a-- 2 --b-- 3 --c-- 4
+//
+//	|
+//	d
+//	|
+//	5
+func testWays() map[osm.WayID]*osm.Way {
+	way := func(id osm.WayID, nodes ...osm.NodeID) *osm.Way {
+		w := &osm.Way{ID: id}
+		for _, n := range nodes {
+			w.Nodes = append(w.Nodes, osm.WayNode{ID: n})
+		}
+		return w
+	}
+
+	return map[osm.WayID]*osm.Way{
+		1: way(1, 1, 2),
+		2: way(2, 2, 3),
+		3: way(3, 3, 4),
+		4: way(4, 2, 5),
+	}
+}
+
+func TestRestriction_Validate(t *testing.T) {
+	ways := testWays()
+	cases := []struct {
+		name        string
+		restriction *Restriction
+		err         error
+		way         osm.WayID
+	}{
+		{
+			name:        "via node",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaNode: 2, To: []osm.WayID{4}},
+		},
+		{
+			name:        "via node not on to",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaNode: 2, To: []osm.WayID{3}},
+			err:         ErrNotConnected,
+			way:         3,
+		},
+		{
+			name:        "one via way",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaWays: []osm.WayID{2}, To: []osm.WayID{3}},
+		},
+		{
+			name:        "one via way same end",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaWays: []osm.WayID{2}, To: []osm.WayID{4}},
+			err:         ErrNotConnected,
+			way:         4,
+		},
+		{
+			name:        "two via ways",
+			restriction: &Restriction{From: []osm.WayID{4}, ViaWays: []osm.WayID{2, 3}, To: []osm.WayID{3}},
+		},
+		{
+			name:        "two via ways wrong from",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaWays: []osm.WayID{3, 2}, To: []osm.WayID{4}},
+			err:         ErrNotConnected,
+			way:         1,
+		},
+		{
+			name:        "via ways not connected",
+			restriction: &Restriction{From: []osm.WayID{1}, ViaWays: []osm.WayID{1, 3}, To: []osm.WayID{3}},
+			err:         ErrNotConnected,
+			way:         3,
+		},
+		{
+			name:        "missing way",
+			restriction: &Restriction{From: []osm.WayID{9}, ViaNode: 2, To: []osm.WayID{4}},
+			err:         ErrWayNotFound,
+			way:         9,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.restriction.RelationID = 100
+			err := tc.restriction.Validate(ways)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("incorrect error: %v", err)
+			}
+
+			if err != nil {
+				var e *Error
+				if !errors.As(err, &e) || e.WayID != tc.way || e.RelationID != 100 {
+					t.Errorf("incorrect error details: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	tags := osm.Tags{{Key: "type", Value: "restriction"}, {Key: "restriction", Value: "no_left_turn"}}
+	relations := osm.Relations{
+		{
+			ID:   1,
+			Tags: tags,
+			Members: osm.Members{
+				{Type: osm.TypeWay, Ref: 1, Role: "from"},
+				{Type: osm.TypeNode, Ref: 2, Role: "via"},
+				{Type: osm.TypeWay, Ref: 4, Role: "to"},
+			},
+		},
+		{
+			ID:   2,
+			Tags: tags,
+			Members: osm.Members{
+				{Type: osm.TypeWay, Ref: 1, Role: "from"},
+				{Type: osm.TypeNode, Ref: 4, Role: "via"},
+				{Type: osm.TypeWay, Ref: 4, Role: "to"},
+			},
+		},
+		{
+			ID:   3,
+			Tags: tags,
+		},
+		{
+			ID:   4,
+			Tags: osm.Tags{{Key: "type", Value: "route"}},
+		},
+	}
+
+	rs, errs := ParseAll(relations, testWays())
+	if len(rs) != 1 || rs[0].RelationID != 1 {
+		t.Errorf("incorrect restrictions: %v", rs)
+	}
+
+	if len(errs) != 2 || !errors.Is(errs[0], ErrNotConnected) || !errors.Is(errs[1], ErrMissingFrom) {
+		t.Errorf("incorrect errors: %v", errs)
+	}
+}
@@ -0,0 +1,410 @@
+package osm
+
+import "context"
+
+var (
+	_ Scanner = &concatScanner{}
+	_ Scanner = &filterScanner{}
+	_ Scanner = &mapScanner{}
+	_ Scanner = &teeScanner{}
+	_ Scanner = &chanScanner{}
+)
+
+// NewConcatScanner returns a scanner that reads
+// all the objects of the given scanners in order.
+// Scanning stops at the first error of any of the scanners.
+// Close closes all the scanners and
+// returns the first error encountered.
+func NewConcatScanner(scanners ...Scanner) Scanner {
+	return &concatScanner{scanners: scanners}
+}
+
+type concatScanner struct {
+	scanners []Scanner
+	index    int
+	closed   bool
+	err      error
+}
+
+func (s *concatScanner) Scan() bool {
+	if s.err != nil || s.closed {
+		return false
+	}
+
+	for s.index < len(s.scanners) {
+		current := s.scanners[s.index]
+		if current.Scan() {
+			return true
+		}
+
+		if err := current.Err(); err != nil {
+			s.err = err
+			return false
+		}
+
+		s.index++
+	}
+
+	return false
+}
+
+func (s *concatScanner) Object() Object {
+	if s.index >= len(s.scanners) {
+		return nil
+	}
+
+	return s.scanners[s.index].Object()
+}
+
+func (s *concatScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.closed {
+		return ErrScannerClosed
+	}
+
+	return nil
+}
+
+func (s *concatScanner) Close() (err error) {
+	s.closed = true
+	for _, scanner := range s.scanners {
+		if e := scanner.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// NewFilterScanner returns a scanner that
+// only returns the objects of the given scanner
+// for which the filter function returns true.
+// Close and Err are passed through to the underlying scanner.
+func NewFilterScanner(s Scanner, filter func(Object) bool) Scanner {
+	return &filterScanner{scanner: s, filter: filter}
+}
+
+type filterScanner struct {
+	scanner Scanner
+	filter  func(Object) bool
+}
+
+func (s *filterScanner) Scan() bool {
+	for s.scanner.Scan() {
+		if s.filter(s.scanner.Object()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *filterScanner) Object() Object {
+	return s.scanner.Object()
+}
+
+func (s *filterScanner) Err() error {
+	return s.scanner.Err()
+}
+
+func (s *filterScanner) Close() error {
+	return s.scanner.Close()
+}
+
+// NewMapScanner returns a scanner that returns the objects of the given scanner
+// transformed by the map function.
+// If the function returns a nil object the object is skipped.
+// If the function returns an error scanning stops and
+// the error is returned by Err.
+func NewMapScanner(s Scanner, f func(Object) (Object, error)) Scanner {
+	return &mapScanner{scanner: s, f: f}
+}
+
+type mapScanner struct {
+	scanner Scanner
+	f       func(Object) (Object, error)
+	next    Object
+	err     error
+}
+
+func (s *mapScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for s.scanner.Scan() {
+		s.next, s.err = s.f(s.scanner.Object())
+		if s.err != nil {
+			s.next = nil
+			return false
+		}
+
+		if s.next != nil {
+			return true
+		}
+	}
+
+	s.next = nil
+	return false
+}
+
+func (s *mapScanner) Object() Object {
+	return s.next
+}
+
+func (s *mapScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.scanner.Err()
+}
+
+func (s *mapScanner) Close() error {
+	return s.scanner.Close()
+}
+
+// NewTeeScanner returns a scanner that passes every object
+// of the given scanner to each of the consumers
+// before returning it from Scan.
+// If a consumer returns an error scanning stops and
+// the error is returned by Err.
+func NewTeeScanner(s Scanner, consumers ...func(Object) error) Scanner {
+	return &teeScanner{scanner: s, consumers: consumers}
+}
+
+type teeScanner struct {
+	scanner   Scanner
+	consumers []func(Object) error
+	err       error
+}
+
+func (s *teeScanner) Scan() bool {
+	if s.err != nil || !s.scanner.Scan() {
+		return false
+	}
+
+	o := s.scanner.Object()
+	for _, c := range s.consumers {
+		if s.err = c(o); s.err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *teeScanner) Object() Object {
+	return s.scanner.Object()
+}
+
+func (s *teeScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.scanner.Err()
+}
+
+func (s *teeScanner) Close() error {
+	return s.scanner.Close()
+}
+
+// ScannerChan reads all the objects from the scanner in a goroutine
+// and sends them on the returned object channel.
+// The object channel is closed when the scanner is done,
+// after which the error channel receives the result of scanning,
+// nil if everything was read. The scanner is closed when done.
+// Cancel the context to stop reading early,
+// the context error will be sent on the error channel.
+func ScannerChan(ctx context.Context, s Scanner) (<-chan Object, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	objects := make(chan Object)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		err := scanToChan(ctx, s, objects)
+		close(objects)
+		if e := s.Close(); e != nil && err == nil {
+			err = e
+		}
+
+		errc <- err
+	}()
+
+	return objects, errc
+}
+
+func scanToChan(ctx context.Context, s Scanner, objects chan<- Object) error {
+	for s.Scan() {
+		select {
+		case objects <- s.Object():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// NewChanScanner returns a scanner that reads objects from the given channel
+// until it is closed. After that the first value received from
+// the error channel, if not nil, is returned by Err.
+// It is the reverse of ScannerChan, i.e.
+//
+//	objects, errc := osm.ScannerChan(ctx, scanner)
+//	s := osm.NewChanScanner(ctx, objects, errc)
+//
+// Closing the scanner or cancelling the context stops the scan,
+// the producer should also be stopped, usually by cancelling the same context.
+func NewChanScanner(ctx context.Context, objects <-chan Object, errc <-chan error) Scanner {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s := &chanScanner{
+		objects: objects,
+		errc:    errc,
+	}
+	s.ctx, s.done = context.WithCancel(ctx)
+	return s
+}
+
+type chanScanner struct {
+	ctx     context.Context
+	done    context.CancelFunc
+	objects <-chan Object
+	errc    <-chan error
+	next    Object
+	closed  bool
+	err     error
+	ended   bool
+}
+
+func (s *chanScanner) Scan() bool {
+	if s.ended || s.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case o, ok := <-s.objects:
+		if ok {
+			s.next = o
+			return true
+		}
+	case <-s.ctx.Done():
+		return false
+	}
+
+	s.ended = true
+	s.next = nil
+	if s.errc == nil {
+		return false
+	}
+
+	select {
+	case s.err = <-s.errc:
+	case <-s.ctx.Done():
+	}
+
+	return false
+}
+
+func (s *chanScanner) Object() Object {
+	return s.next
+}
+
+func (s *chanScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.closed {
+		return ErrScannerClosed
+	}
+
+	if s.ended {
+		return nil
+	}
+
+	return s.ctx.Err()
+}
+
+func (s *chanScanner) Close() error {
+	s.closed = true
+	s.done()
+	return nil
+}
+
+// BatchScanner groups the objects of a scanner into batches.
+// This is useful when feeding objects to a set of workers.
+//
+//	bs := osm.NewBatchScanner(scanner, 1000)
+//	defer bs.Close()
+//
+//	for bs.Scan() {
+//		objects := bs.Batch()
+//		// do something
+//	}
+//
+//	if bs.Err() != nil {
+//		// scanner did not complete fully
+//	}
+type BatchScanner struct {
+	scanner Scanner
+	size    int
+	batch   Objects
+}
+
+// NewBatchScanner returns a batch scanner with
+// batches of up to size objects.
+// A size less than 1 is treated as 1.
+func NewBatchScanner(s Scanner, size int) *BatchScanner {
+	if size < 1 {
+		size = 1
+	}
+
+	return &BatchScanner{scanner: s, size: size}
+}
+
+// Scan advances the scanner to the next batch.
+// It returns false when there are no more objects.
+// The last batch may be smaller than the batch size.
+// If the underlying scanner stops with an error,
+// the objects read so far are still returned as a batch
+// and the error is available via Err.
+func (s *BatchScanner) Scan() bool {
+	s.batch = make(Objects, 0, s.size)
+	for len(s.batch) < s.size && s.scanner.Scan() {
+		s.batch = append(s.batch, s.scanner.Object())
+	}
+
+	return len(s.batch) > 0
+}
+
+// Batch returns the most recent batch of objects read by Scan.
+// A new slice is allocated for every batch,
+// so it is safe to hold onto it after the next call to Scan.
+func (s *BatchScanner) Batch() Objects {
+	return s.batch
+}
+
+// Err returns the error of the underlying scanner.
+func (s *BatchScanner) Err() error {
+	return s.scanner.Err()
+}
+
+// Close closes the underlying scanner.
+func (s *BatchScanner) Close() error {
+	return s.scanner.Close()
+}
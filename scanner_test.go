@@ -0,0 +1,304 @@
+package osm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testScanner struct {
+	offset  int
+	objects Objects
+	err     error
+	closed  bool
+}
+
+func newTestScanner(objects ...Object) *testScanner {
+	return &testScanner{offset: -1, objects: objects}
+}
+
+func (s *testScanner) Scan() bool {
+	if s.closed {
+		return false
+	}
+
+	s.offset++
+	if s.offset < len(s.objects) {
+		return true
+	}
+
+	return false
+}
+
+func (s *testScanner) Object() Object {
+	return s.objects[s.offset]
+}
+
+func (s *testScanner) Err() error {
+	if s.offset >= len(s.objects) && s.err != nil {
+		return s.err
+	}
+
+	if s.closed {
+		return ErrScannerClosed
+	}
+
+	return nil
+}
+
+func (s *testScanner) Close() error {
+	s.closed = true
+	return nil
+}
+
+func scanIDs(t testing.TB, s Scanner) ObjectIDs {
+	t.Helper()
+
+	ids := ObjectIDs{}
+	for s.Scan() {
+		ids = append(ids, s.Object().ObjectID())
+	}
+
+	return ids
+}
+
+func TestNewConcatScanner(t *testing.T) {
+	s1 := newTestScanner(&Node{ID: 1}, &Node{ID: 2})
+	s2 := newTestScanner()
+	s3 := newTestScanner(&Way{ID: 3})
+
+	s := NewConcatScanner(s1, s2, s3)
+	ids := scanIDs(t, s)
+
+	expected := ObjectIDs{NodeID(1).ObjectID(0), NodeID(2).ObjectID(0), WayID(3).ObjectID(0)}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect ids: %v", ids)
+	}
+
+	if err := s.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if o := s.Object(); o != nil {
+		t.Errorf("should return nil after exhausted: %v", o)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+
+	if !s1.closed || !s2.closed || !s3.closed {
+		t.Errorf("should close all scanners")
+	}
+
+	if err := s.Err(); err != ErrScannerClosed {
+		t.Errorf("should return closed error: %v", err)
+	}
+}
+
+func TestNewConcatScanner_error(t *testing.T) {
+	s1 := newTestScanner(&Node{ID: 1})
+	s1.err = errors.New("some error")
+	s2 := newTestScanner(&Node{ID: 2})
+
+	s := NewConcatScanner(s1, s2)
+	ids := scanIDs(t, s)
+	if len(ids) != 1 {
+		t.Errorf("should stop at first error: %v", ids)
+	}
+
+	if err := s.Err(); err != s1.err {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestNewFilterScanner(t *testing.T) {
+	s := NewFilterScanner(
+		newTestScanner(&Node{ID: 1}, &Way{ID: 2}, &Node{ID: 3}),
+		func(o Object) bool { return o.ObjectID().Type() == TypeNode },
+	)
+	defer s.Close()
+
+	ids := scanIDs(t, s)
+	expected := ObjectIDs{NodeID(1).ObjectID(0), NodeID(3).ObjectID(0)}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect ids: %v", ids)
+	}
+}
+
+func TestNewMapScanner(t *testing.T) {
+	t.Run("transform and skip", func(t *testing.T) {
+		s := NewMapScanner(
+			newTestScanner(&Node{ID: 1}, &Way{ID: 2}, &Node{ID: 3}),
+			func(o Object) (Object, error) {
+				n, ok := o.(*Node)
+				if !ok {
+					return nil, nil
+				}
+				return &Node{ID: n.ID * 10}, nil
+			},
+		)
+		defer s.Close()
+
+		ids := scanIDs(t, s)
+		expected := ObjectIDs{NodeID(10).ObjectID(0), NodeID(30).ObjectID(0)}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("incorrect ids: %v", ids)
+		}
+
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mapErr := errors.New("map error")
+		s := NewMapScanner(
+			newTestScanner(&Node{ID: 1}, &Way{ID: 2}, &Node{ID: 3}),
+			func(o Object) (Object, error) {
+				if o.ObjectID().Type() == TypeWay {
+					return nil, mapErr
+				}
+				return o, nil
+			},
+		)
+		defer s.Close()
+
+		ids := scanIDs(t, s)
+		if len(ids) != 1 {
+			t.Errorf("should stop on error: %v", ids)
+		}
+
+		if err := s.Err(); err != mapErr {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+}
+
+func TestNewTeeScanner(t *testing.T) {
+	var a, b Objects
+	teeErr := errors.New("tee error")
+	s := NewTeeScanner(
+		newTestScanner(&Node{ID: 1}, &Way{ID: 2}, &Node{ID: 3}),
+		func(o Object) error {
+			a = append(a, o)
+			return nil
+		},
+		func(o Object) error {
+			if o.ObjectID().Type() == TypeWay {
+				return teeErr
+			}
+			b = append(b, o)
+			return nil
+		},
+	)
+	defer s.Close()
+
+	ids := scanIDs(t, s)
+	if len(ids) != 1 {
+		t.Errorf("should stop on error: %v", ids)
+	}
+
+	if len(a) != 2 || len(b) != 1 {
+		t.Errorf("incorrect consumed objects: %v %v", a, b)
+	}
+
+	if err := s.Err(); err != teeErr {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestScannerChan(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		ctx := context.Background()
+		ts := newTestScanner(&Node{ID: 1}, &Way{ID: 2}, &Relation{ID: 3})
+
+		objects, errc := ScannerChan(ctx, ts)
+		s := NewChanScanner(ctx, objects, errc)
+		defer s.Close()
+
+		ids := scanIDs(t, s)
+		expected := ObjectIDs{NodeID(1).ObjectID(0), WayID(2).ObjectID(0), RelationID(3).ObjectID(0)}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("incorrect ids: %v", ids)
+		}
+
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !ts.closed {
+			t.Errorf("should close the scanner")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctx := context.Background()
+		ts := newTestScanner(&Node{ID: 1})
+		ts.err = errors.New("some error")
+
+		objects, errc := ScannerChan(ctx, ts)
+		s := NewChanScanner(ctx, objects, errc)
+		defer s.Close()
+
+		ids := scanIDs(t, s)
+		if len(ids) != 1 {
+			t.Errorf("incorrect ids: %v", ids)
+		}
+
+		if err := s.Err(); err != ts.err {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ts := newTestScanner(&Node{ID: 1}, &Node{ID: 2}, &Node{ID: 3})
+
+		objects, errc := ScannerChan(ctx, ts)
+		<-objects
+		cancel()
+
+		if err := <-errc; err != context.Canceled {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+
+	t.Run("close chan scanner", func(t *testing.T) {
+		objects := make(chan Object)
+		s := NewChanScanner(context.Background(), objects, nil)
+		s.Close()
+
+		if s.Scan() {
+			t.Errorf("should not scan after close")
+		}
+
+		if err := s.Err(); err != ErrScannerClosed {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+}
+
+func TestBatchScanner(t *testing.T) {
+	ts := newTestScanner(&Node{ID: 1}, &Node{ID: 2}, &Node{ID: 3}, &Node{ID: 4}, &Node{ID: 5})
+	s := NewBatchScanner(ts, 2)
+
+	var sizes []int
+	for s.Scan() {
+		sizes = append(sizes, len(s.Batch()))
+	}
+
+	if !reflect.DeepEqual(sizes, []int{2, 2, 1}) {
+		t.Errorf("incorrect batch sizes: %v", sizes)
+	}
+
+	if err := s.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	s.Close()
+	if !ts.closed {
+		t.Errorf("should close the underlying scanner")
+	}
+}
@@ -0,0 +1,357 @@
+package osm
+
+import (
+	"sort"
+	"time"
+)
+
+var _ Scanner = &snapshotScanner{}
+
+// SnapshotAt returns the data as it was at the given time. That is the
+// version of every node, way and relation committed at or before the time,
+// if that version is visible, i.e. the feature was not deleted.
+// Way nodes and relation members are annotated with the version,
+// changeset and, for nodes, the location of the child version at that time.
+// Children that did not exist at the time are not annotated.
+// The elements are copies sorted by id, the datasource is not modified.
+func (ds *HistoryDatasource) SnapshotAt(t time.Time) *OSM {
+	idx := newSnapshotIndex()
+	for _, h := range ds.Nodes {
+		if n := nodeAt(h, t); n != nil {
+			idx.nodes[n.ID] = n
+		}
+	}
+
+	for _, h := range ds.Ways {
+		if w := wayAt(h, t); w != nil {
+			idx.ways[w.ID] = w
+		}
+	}
+
+	for _, h := range ds.Relations {
+		if r := relationAt(h, t); r != nil {
+			idx.relations[r.ID] = r
+		}
+	}
+
+	o := &OSM{
+		Nodes:     make(Nodes, 0, len(idx.nodes)),
+		Ways:      make(Ways, 0, len(idx.ways)),
+		Relations: make(Relations, 0, len(idx.relations)),
+	}
+
+	for _, n := range idx.nodes {
+		o.Nodes = append(o.Nodes, cloneElement(n).(*Node))
+	}
+
+	for _, w := range idx.ways {
+		o.Ways = append(o.Ways, idx.annotateWay(w))
+	}
+
+	for _, r := range idx.relations {
+		o.Relations = append(o.Relations, idx.annotateRelation(r))
+	}
+
+	sort.Sort(nodesSort(o.Nodes))
+	sort.Sort(waysSort(o.Ways))
+	sort.Sort(relationsSort(o.Relations))
+
+	return o
+}
+
+// NewSnapshotScanner returns a scanner with the data as it was at the
+// given time, see HistoryDatasource.SnapshotAt, from a full history scanner.
+// The history must be sorted by type, node, way, relation, then id and
+// version like the history planet files, ErrUnsorted is returned otherwise.
+// The visible nodes at the time are kept in memory to annotate the ways,
+// the relations are kept in memory and returned at the end, so relation
+// members of type relation can be annotated. Objects that are not
+// nodes, ways or relations are skipped.
+// The returned elements must not be modified since they are used to
+// annotate later elements.
+func NewSnapshotScanner(s Scanner, t time.Time) Scanner {
+	return &snapshotScanner{
+		scanner: s,
+		t:       t,
+		idx:     newSnapshotIndex(),
+	}
+}
+
+type snapshotScanner struct {
+	scanner Scanner
+	t       time.Time
+	idx     *snapshotIndex
+
+	peek    Element
+	last    ElementID
+	started bool
+
+	done      bool
+	relations Relations
+	index     int
+
+	object Object
+	closed bool
+	err    error
+}
+
+func (s *snapshotScanner) Scan() bool {
+	if s.err != nil || s.closed {
+		return false
+	}
+
+	for !s.done {
+		e, found, err := s.nextFeature()
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		if !found {
+			s.done = true
+			break
+		}
+
+		switch e := e.(type) {
+		case *Node:
+			s.idx.nodes[e.ID] = e
+			s.object = e
+			return true
+		case *Way:
+			s.idx.ways[e.ID] = e
+			s.object = s.idx.annotateWay(e)
+			return true
+		case *Relation:
+			s.idx.relations[e.ID] = e
+			s.relations = append(s.relations, e)
+		}
+	}
+
+	if s.index < len(s.relations) {
+		s.object = s.idx.annotateRelation(s.relations[s.index])
+		s.index++
+		return true
+	}
+
+	return false
+}
+
+// nextFeature reads all the versions of the next feature and returns the
+// visible version at the time, nil if there is none.
+// Found is false if there are no more features.
+func (s *snapshotScanner) nextFeature() (Element, bool, error) {
+	e, err := s.readElement()
+	if err != nil || e == nil {
+		return nil, false, err
+	}
+
+	var result Element
+	fid := e.FeatureID()
+	for e != nil {
+		if e.FeatureID() != fid {
+			s.peek = e
+			break
+		}
+
+		if !elementCommittedAt(e).After(s.t) {
+			result = e
+		}
+
+		if e, err = s.readElement(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if result == nil || !elementVisible(result) {
+		return nil, true, nil
+	}
+
+	return result, true, nil
+}
+
+// readElement returns the next element of the scanner, nil at the end.
+func (s *snapshotScanner) readElement() (Element, error) {
+	if s.peek != nil {
+		e := s.peek
+		s.peek = nil
+		return e, nil
+	}
+
+	for s.scanner.Scan() {
+		e, ok := s.scanner.Object().(Element)
+		if !ok {
+			continue
+		}
+
+		id := e.ElementID()
+		if s.started && id < s.last {
+			return nil, ErrUnsorted
+		}
+		s.started, s.last = true, id
+
+		return e, nil
+	}
+
+	return nil, s.scanner.Err()
+}
+
+func (s *snapshotScanner) Object() Object {
+	return s.object
+}
+
+func (s *snapshotScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.closed {
+		return ErrScannerClosed
+	}
+
+	return nil
+}
+
+func (s *snapshotScanner) Close() error {
+	s.closed = true
+	return s.scanner.Close()
+}
+
+// snapshotIndex holds the versions of the features at a point in time
+// used to annotate the way nodes and relation members.
+type snapshotIndex struct {
+	nodes     map[NodeID]*Node
+	ways      map[WayID]*Way
+	relations map[RelationID]*Relation
+}
+
+func newSnapshotIndex() *snapshotIndex {
+	return &snapshotIndex{
+		nodes:     make(map[NodeID]*Node),
+		ways:      make(map[WayID]*Way),
+		relations: make(map[RelationID]*Relation),
+	}
+}
+
+// annotateWay returns a copy of the way with the way nodes
+// annotated with the node versions in the index.
+func (idx *snapshotIndex) annotateWay(w *Way) *Way {
+	w = cloneElement(w).(*Way)
+	w.Updates = nil
+	for i, wn := range w.Nodes {
+		w.Nodes[i] = WayNode{ID: wn.ID}
+		if n := idx.nodes[wn.ID]; n != nil {
+			w.Nodes[i].Version = n.Version
+			w.Nodes[i].ChangesetID = n.ChangesetID
+			w.Nodes[i].Lat = n.Lat
+			w.Nodes[i].Lon = n.Lon
+		}
+	}
+
+	return w
+}
+
+// annotateRelation returns a copy of the relation with the
+// members annotated with the versions in the index.
+func (idx *snapshotIndex) annotateRelation(r *Relation) *Relation {
+	r = cloneElement(r).(*Relation)
+	r.Updates = nil
+	for i, m := range r.Members {
+		m = Member{Type: m.Type, Ref: m.Ref, Role: m.Role}
+		switch m.Type {
+		case TypeNode:
+			if n := idx.nodes[NodeID(m.Ref)]; n != nil {
+				m.Version = n.Version
+				m.ChangesetID = n.ChangesetID
+				m.Lat = n.Lat
+				m.Lon = n.Lon
+			}
+		case TypeWay:
+			if w := idx.ways[WayID(m.Ref)]; w != nil {
+				m.Version = w.Version
+				m.ChangesetID = w.ChangesetID
+			}
+		case TypeRelation:
+			if r := idx.relations[RelationID(m.Ref)]; r != nil {
+				m.Version = r.Version
+				m.ChangesetID = r.ChangesetID
+			}
+		}
+		r.Members[i] = m
+	}
+
+	return r
+}
+
+// nodeAt returns the visible version at the time, nil if none.
+func nodeAt(h Nodes, t time.Time) *Node {
+	var result *Node
+	for _, n := range h {
+		if !n.CommittedAt().After(t) && (result == nil || n.Version > result.Version) {
+			result = n
+		}
+	}
+
+	if result == nil || !result.Visible {
+		return nil
+	}
+
+	return result
+}
+
+// wayAt returns the visible version at the time, nil if none.
+func wayAt(h Ways, t time.Time) *Way {
+	var result *Way
+	for _, w := range h {
+		if !w.CommittedAt().After(t) && (result == nil || w.Version > result.Version) {
+			result = w
+		}
+	}
+
+	if result == nil || !result.Visible {
+		return nil
+	}
+
+	return result
+}
+
+// relationAt returns the visible version at the time, nil if none.
+func relationAt(h Relations, t time.Time) *Relation {
+	var result *Relation
+	for _, r := range h {
+		if !r.CommittedAt().After(t) && (result == nil || r.Version > result.Version) {
+			result = r
+		}
+	}
+
+	if result == nil || !result.Visible {
+		return nil
+	}
+
+	return result
+}
+
+func elementCommittedAt(e Element) time.Time {
+	switch e := e.(type) {
+	case *Node:
+		return e.CommittedAt()
+	case *Way:
+		return e.CommittedAt()
+	case *Relation:
+		return e.CommittedAt()
+	}
+
+	return time.Time{}
+}
+
+func elementVisible(e Element) bool {
+	switch e := e.(type) {
+	case *Node:
+		return e.Visible
+	case *Way:
+		return e.Visible
+	case *Relation:
+		return e.Visible
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSnapshotHistory() *OSM {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+	t3 := t2.Add(24 * time.Hour)
+
+	return &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Lat: 1, Lon: 1},
+			{ID: 1, Version: 2, ChangesetID: 20, Visible: true, Timestamp: t2, Lat: 2, Lon: 2},
+			{ID: 2, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Lat: 3, Lon: 3},
+			{ID: 2, Version: 2, ChangesetID: 30, Visible: false, Timestamp: t3},
+			{ID: 3, Version: 1, ChangesetID: 30, Visible: true, Timestamp: t3, Lat: 4, Lon: 4},
+		},
+		Ways: Ways{
+			{ID: 5, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Nodes: WayNodes{{ID: 1}, {ID: 2}}},
+			{ID: 5, Version: 2, ChangesetID: 30, Visible: true, Timestamp: t3, Nodes: WayNodes{{ID: 1}, {ID: 3}}},
+		},
+		Relations: Relations{
+			{ID: 7, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Members: Members{
+				{Type: TypeNode, Ref: 1, Role: "a"},
+				{Type: TypeWay, Ref: 5},
+				{Type: TypeRelation, Ref: 8},
+			}},
+			{ID: 8, Version: 1, ChangesetID: 20, Visible: true, Timestamp: t2},
+			{ID: 8, Version: 2, ChangesetID: 30, Visible: false, Timestamp: t3},
+		},
+	}
+}
+
+func TestHistoryDatasource_SnapshotAt(t *testing.T) {
+	o := testSnapshotHistory()
+	ds := o.HistoryDatasource()
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		time     time.Time
+		expected *OSM
+	}{
+		{
+			name:     "before",
+			time:     t1.Add(-time.Hour),
+			expected: &OSM{Nodes: Nodes{}, Ways: Ways{}, Relations: Relations{}},
+		},
+		{
+			name: "t1",
+			time: t1,
+			expected: &OSM{
+				Nodes: Nodes{o.Nodes[0], o.Nodes[2]},
+				Ways: Ways{
+					{ID: 5, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Nodes: WayNodes{
+						{ID: 1, Version: 1, ChangesetID: 10, Lat: 1, Lon: 1},
+						{ID: 2, Version: 1, ChangesetID: 10, Lat: 3, Lon: 3},
+					}},
+				},
+				Relations: Relations{
+					{ID: 7, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Members: Members{
+						{Type: TypeNode, Ref: 1, Role: "a", Version: 1, ChangesetID: 10, Lat: 1, Lon: 1},
+						{Type: TypeWay, Ref: 5, Version: 1, ChangesetID: 10},
+						{Type: TypeRelation, Ref: 8},
+					}},
+				},
+			},
+		},
+		{
+			name: "t2",
+			time: t1.Add(36 * time.Hour),
+			expected: &OSM{
+				Nodes: Nodes{o.Nodes[1], o.Nodes[2]},
+				Ways: Ways{
+					{ID: 5, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Nodes: WayNodes{
+						{ID: 1, Version: 2, ChangesetID: 20, Lat: 2, Lon: 2},
+						{ID: 2, Version: 1, ChangesetID: 10, Lat: 3, Lon: 3},
+					}},
+				},
+				Relations: Relations{
+					{ID: 7, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Members: Members{
+						{Type: TypeNode, Ref: 1, Role: "a", Version: 2, ChangesetID: 20, Lat: 2, Lon: 2},
+						{Type: TypeWay, Ref: 5, Version: 1, ChangesetID: 10},
+						{Type: TypeRelation, Ref: 8, Version: 1, ChangesetID: 20},
+					}},
+					o.Relations[1],
+				},
+			},
+		},
+		{
+			name: "t3",
+			time: t1.Add(100 * time.Hour),
+			expected: &OSM{
+				Nodes: Nodes{o.Nodes[1], o.Nodes[4]},
+				Ways: Ways{
+					{ID: 5, Version: 2, ChangesetID: 30, Visible: true, Timestamp: o.Ways[1].Timestamp, Nodes: WayNodes{
+						{ID: 1, Version: 2, ChangesetID: 20, Lat: 2, Lon: 2},
+						{ID: 3, Version: 1, ChangesetID: 30, Lat: 4, Lon: 4},
+					}},
+				},
+				Relations: Relations{
+					{ID: 7, Version: 1, ChangesetID: 10, Visible: true, Timestamp: t1, Members: Members{
+						{Type: TypeNode, Ref: 1, Role: "a", Version: 2, ChangesetID: 20, Lat: 2, Lon: 2},
+						{Type: TypeWay, Ref: 5, Version: 2, ChangesetID: 30},
+						{Type: TypeRelation, Ref: 8},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := ds.SnapshotAt(tc.time)
+			if !reflect.DeepEqual(snapshot, tc.expected) {
+				t.Errorf("incorrect snapshot")
+				t.Logf("%+v", snapshot)
+				t.Logf("%+v", tc.expected)
+			}
+
+			// streaming version should match
+			scanner := NewSnapshotScanner(newTestScanner(o.Objects()...), tc.time)
+			result := &OSM{Nodes: Nodes{}, Ways: Ways{}, Relations: Relations{}}
+			for scanner.Scan() {
+				result.Append(scanner.Object())
+			}
+
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("scan error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("incorrect scanner snapshot")
+				t.Logf("%+v", result)
+				t.Logf("%+v", tc.expected)
+			}
+		})
+	}
+
+	// the datasource is not modified
+	if wn := o.Ways[0].Nodes[0]; wn.Version != 0 {
+		t.Errorf("way should not be annotated: %+v", wn)
+	}
+}
+
+func TestNewSnapshotScanner(t *testing.T) {
+	t.Run("unsorted", func(t *testing.T) {
+		scanner := NewSnapshotScanner(newTestScanner(
+			&Node{ID: 2, Version: 1, Visible: true},
+			&Node{ID: 1, Version: 1, Visible: true},
+		), time.Now())
+
+		for scanner.Scan() {
+		}
+
+		if err := scanner.Err(); err != ErrUnsorted {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+
+	t.Run("skips non elements", func(t *testing.T) {
+		scanner := NewSnapshotScanner(newTestScanner(
+			&Changeset{ID: 1},
+			&Node{ID: 1, Version: 1, Visible: true},
+		), time.Now())
+
+		if ids := scanIDs(t, scanner); len(ids) != 1 {
+			t.Errorf("incorrect objects: %v", ids)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		ts := newTestScanner(&Node{ID: 1, Version: 1, Visible: true})
+		scanner := NewSnapshotScanner(ts, time.Now())
+		if err := scanner.Close(); err != nil {
+			t.Fatalf("close error: %v", err)
+		}
+
+		if !ts.closed {
+			t.Errorf("should close underlying scanner")
+		}
+
+		if scanner.Scan() {
+			t.Errorf("should not scan after close")
+		}
+
+		if err := scanner.Err(); err != ErrScannerClosed {
+			t.Errorf("incorrect error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,506 @@
+package osm
+
+import (
+	"context"
+	"math"
+
+	"github.com/pchchv/geo"
+	"github.com/pchchv/osm/internal/rtree"
+)
+
+var _ HistoryDatasourcer = &Store{}
+
+// Store is an in-memory index of one version, usually the latest,
+// of a set of nodes, ways and relations.
+// Besides lookups by id it keeps back-references,
+// i.e. the ways using a node and the relations containing a member,
+// and an R-tree over the node locations and way and relation bounds.
+// Way and relation bounds are computed from the nodes in the store and
+// are updated when the nodes change.
+// It is safe for concurrent reads, but not for concurrent modification.
+type Store struct {
+	nodes     map[NodeID]*Node
+	ways      map[WayID]*Way
+	relations map[RelationID]*Relation
+
+	nodeWays        map[NodeID][]WayID
+	memberRelations map[FeatureID][]RelationID
+
+	index   *rtree.Tree
+	indexed map[FeatureID]geo.Bound
+}
+
+// NewStore creates a new empty store.
+func NewStore() *Store {
+	return &Store{
+		nodes:           make(map[NodeID]*Node),
+		ways:            make(map[WayID]*Way),
+		relations:       make(map[RelationID]*Relation),
+		nodeWays:        make(map[NodeID][]WayID),
+		memberRelations: make(map[FeatureID][]RelationID),
+		index:           rtree.New(),
+		indexed:         make(map[FeatureID]geo.Bound),
+	}
+}
+
+// Store creates a new store with the nodes, ways and relations of the osm data.
+// If there are several versions of a feature the last one is used.
+func (o *OSM) Store() *Store {
+	s := NewStore()
+	s.AddOSM(o)
+	return s
+}
+
+// AddOSM adds all the nodes, ways and relations of the osm data.
+func (s *Store) AddOSM(o *OSM) {
+	for _, e := range o.Elements() {
+		s.Add(e)
+	}
+}
+
+// Add adds the element to the store,
+// replacing the current element with the same feature id.
+// The elements are not copied, they should not be modified
+// while in the store. Use Add with the modified element instead.
+func (s *Store) Add(e Element) {
+	s.Remove(e.FeatureID())
+
+	switch e := e.(type) {
+	case *Node:
+		s.nodes[e.ID] = e
+	case *Way:
+		s.ways[e.ID] = e
+		for _, wn := range e.Nodes {
+			s.nodeWays[wn.ID] = appendWayID(s.nodeWays[wn.ID], e.ID)
+		}
+	case *Relation:
+		s.relations[e.ID] = e
+		for _, m := range e.Members {
+			fid := m.FeatureID()
+			s.memberRelations[fid] = appendRelationID(s.memberRelations[fid], e.ID)
+		}
+	}
+
+	s.reindex(e.FeatureID(), make(map[FeatureID]struct{}))
+}
+
+// Remove removes the feature from the store.
+// The back-references to the feature, e.g. the ways using a removed node,
+// are kept so the feature can be added again.
+// Returns false if the feature was not found.
+func (s *Store) Remove(id FeatureID) bool {
+	switch id.Type() {
+	case TypeNode:
+		if _, ok := s.nodes[id.NodeID()]; !ok {
+			return false
+		}
+		delete(s.nodes, id.NodeID())
+	case TypeWay:
+		w, ok := s.ways[id.WayID()]
+		if !ok {
+			return false
+		}
+
+		delete(s.ways, w.ID)
+		for _, wn := range w.Nodes {
+			s.nodeWays[wn.ID] = removeWayID(s.nodeWays[wn.ID], w.ID)
+			if len(s.nodeWays[wn.ID]) == 0 {
+				delete(s.nodeWays, wn.ID)
+			}
+		}
+	case TypeRelation:
+		r, ok := s.relations[id.RelationID()]
+		if !ok {
+			return false
+		}
+
+		delete(s.relations, r.ID)
+		for _, m := range r.Members {
+			fid := m.FeatureID()
+			s.memberRelations[fid] = removeRelationID(s.memberRelations[fid], r.ID)
+			if len(s.memberRelations[fid]) == 0 {
+				delete(s.memberRelations, fid)
+			}
+		}
+	default:
+		return false
+	}
+
+	s.reindex(id, make(map[FeatureID]struct{}))
+	return true
+}
+
+// Len returns the number of nodes, ways and relations in the store.
+func (s *Store) Len() int {
+	return len(s.nodes) + len(s.ways) + len(s.relations)
+}
+
+// Elements returns all the nodes, ways and relations
+// in the store, sorted by type and id.
+func (s *Store) Elements() Elements {
+	result := make(Elements, 0, s.Len())
+	for _, n := range s.nodes {
+		result = append(result, n)
+	}
+
+	for _, w := range s.ways {
+		result = append(result, w)
+	}
+
+	for _, r := range s.relations {
+		result = append(result, r)
+	}
+
+	result.Sort()
+	return result
+}
+
+// Node returns the node with the id, nil if not found.
+func (s *Store) Node(id NodeID) *Node {
+	return s.nodes[id]
+}
+
+// Way returns the way with the id, nil if not found.
+func (s *Store) Way(id WayID) *Way {
+	return s.ways[id]
+}
+
+// Relation returns the relation with the id, nil if not found.
+func (s *Store) Relation(id RelationID) *Relation {
+	return s.relations[id]
+}
+
+// Element returns the node, way or relation for the feature id, nil if not found.
+func (s *Store) Element(id FeatureID) Element {
+	switch id.Type() {
+	case TypeNode:
+		if n := s.nodes[id.NodeID()]; n != nil {
+			return n
+		}
+	case TypeWay:
+		if w := s.ways[id.WayID()]; w != nil {
+			return w
+		}
+	case TypeRelation:
+		if r := s.relations[id.RelationID()]; r != nil {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// WaysForNode returns the ways in the store using the node,
+// sorted by id.
+func (s *Store) WaysForNode(id NodeID) Ways {
+	var result Ways
+	for _, wid := range s.nodeWays[id] {
+		if w := s.ways[wid]; w != nil {
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// RelationsForMember returns the relations in the store
+// with the feature as a member, sorted by id.
+func (s *Store) RelationsForMember(id FeatureID) Relations {
+	var result Relations
+	for _, rid := range s.memberRelations[id] {
+		if r := s.relations[rid]; r != nil {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// Bound returns the indexed bound of the feature.
+// For ways and relations this is computed from the nodes in the store.
+// Returns false if the feature is not in the store or has no location,
+// e.g. a way with none of its nodes in the store.
+func (s *Store) Bound(id FeatureID) (geo.Bound, bool) {
+	b, ok := s.indexed[id]
+	return b, ok
+}
+
+// Search returns the nodes, ways and relations
+// whose bound intersects the given bounds, sorted by type and id.
+func (s *Store) Search(bounds *Bounds) Elements {
+	var result Elements
+	s.index.Search(bounds.Bound(), func(v int64) bool {
+		if e := s.Element(FeatureID(v)); e != nil {
+			result = append(result, e)
+		}
+		return true
+	})
+	result.Sort()
+
+	return result
+}
+
+// Map returns the elements in the bounds like the map call of the osm api.
+// That is all the nodes in the bounds, all the ways using at least one of these nodes,
+// all the nodes of these ways, even if they are outside the bounds,
+// all the relations referencing any of these nodes or ways and
+// the relations referencing these relations, not recursively.
+// The result is referentially complete with respect to the ways,
+// but not the relation members.
+// The elements are sorted by id.
+func (s *Store) Map(bounds *Bounds) *OSM {
+	o := &OSM{Bounds: bounds}
+	nodes := make(map[NodeID]struct{})
+	ways := make(map[WayID]struct{})
+	relations := make(map[RelationID]struct{})
+
+	addRelations := func(id FeatureID) {
+		for _, rid := range s.memberRelations[id] {
+			if r := s.relations[rid]; r != nil {
+				if _, ok := relations[rid]; !ok {
+					relations[rid] = struct{}{}
+					o.Relations = append(o.Relations, r)
+				}
+			}
+		}
+	}
+
+	var inside Nodes
+	b := bounds.Bound()
+	s.index.Search(b, func(v int64) bool {
+		if fid := FeatureID(v); fid.Type() == TypeNode {
+			inside = append(inside, s.nodes[fid.NodeID()])
+		}
+		return true
+	})
+
+	for _, n := range inside {
+		nodes[n.ID] = struct{}{}
+		o.Nodes = append(o.Nodes, n)
+		addRelations(n.FeatureID())
+	}
+
+	for _, n := range inside {
+		for _, w := range s.WaysForNode(n.ID) {
+			if _, ok := ways[w.ID]; ok {
+				continue
+			}
+
+			ways[w.ID] = struct{}{}
+			o.Ways = append(o.Ways, w)
+			addRelations(w.FeatureID())
+
+			for _, wn := range w.Nodes {
+				if _, ok := nodes[wn.ID]; ok {
+					continue
+				}
+
+				if n := s.nodes[wn.ID]; n != nil {
+					nodes[n.ID] = struct{}{}
+					o.Nodes = append(o.Nodes, n)
+					addRelations(n.FeatureID())
+				}
+			}
+		}
+	}
+
+	// relations referencing the found relations, one level
+	for _, r := range o.Relations {
+		for _, rid := range s.memberRelations[r.FeatureID()] {
+			if parent := s.relations[rid]; parent != nil {
+				if _, ok := relations[rid]; !ok {
+					relations[rid] = struct{}{}
+					o.Relations = append(o.Relations, parent)
+				}
+			}
+		}
+	}
+
+	o.Nodes.SortByIDVersion()
+	o.Ways.SortByIDVersion()
+	o.Relations.SortByIDVersion()
+	return o
+}
+
+// NodeHistory returns the node as a single version history.
+// Allows the store to be used as a HistoryDatasourcer, e.g. to annotate elements.
+func (s *Store) NodeHistory(ctx context.Context, id NodeID) (Nodes, error) {
+	if n := s.nodes[id]; n != nil {
+		return Nodes{n}, nil
+	}
+
+	return nil, errNotFound
+}
+
+// WayHistory returns the way as a single version history.
+func (s *Store) WayHistory(ctx context.Context, id WayID) (Ways, error) {
+	if w := s.ways[id]; w != nil {
+		return Ways{w}, nil
+	}
+
+	return nil, errNotFound
+}
+
+// RelationHistory returns the relation as a single version history.
+func (s *Store) RelationHistory(ctx context.Context, id RelationID) (Relations, error) {
+	if r := s.relations[id]; r != nil {
+		return Relations{r}, nil
+	}
+
+	return nil, errNotFound
+}
+
+// NotFound returns true if the error returned is a not found error.
+func (s *Store) NotFound(err error) bool {
+	return err == errNotFound
+}
+
+// reindex updates the index for the feature and
+// all the ways and relations depending on its bound.
+func (s *Store) reindex(id FeatureID, visited map[FeatureID]struct{}) {
+	if _, ok := visited[id]; ok {
+		// relation loops
+		return
+	}
+	visited[id] = struct{}{}
+
+	b, ok := s.computeBound(id)
+	old, indexed := s.indexed[id]
+	if indexed && (!ok || old != b) {
+		s.index.Delete(old, int64(id))
+		delete(s.indexed, id)
+	}
+
+	if ok && (!indexed || old != b) {
+		s.index.Insert(b, int64(id))
+		s.indexed[id] = b
+	}
+
+	if ok == indexed && old == b {
+		// nothing changed for the parents
+		return
+	}
+
+	if id.Type() == TypeNode {
+		for _, wid := range s.nodeWays[id.NodeID()] {
+			s.reindex(wid.FeatureID(), visited)
+		}
+	}
+
+	for _, rid := range s.memberRelations[id] {
+		s.reindex(rid.FeatureID(), visited)
+	}
+}
+
+func (s *Store) computeBound(id FeatureID) (geo.Bound, bool) {
+	switch id.Type() {
+	case TypeNode:
+		n := s.nodes[id.NodeID()]
+		if n == nil {
+			return geo.Bound{}, false
+		}
+
+		return geo.Bound{Min: n.Point(), Max: n.Point()}, true
+	case TypeWay:
+		w := s.ways[id.WayID()]
+		if w == nil {
+			return geo.Bound{}, false
+		}
+
+		b, found := emptyBound(), false
+		for _, wn := range w.Nodes {
+			if n := s.nodes[wn.ID]; n != nil {
+				b = extendBound(b, n.Point())
+				found = true
+			}
+		}
+
+		return b, found
+	case TypeRelation:
+		r := s.relations[id.RelationID()]
+		if r == nil {
+			return geo.Bound{}, false
+		}
+
+		b, found := emptyBound(), false
+		for _, m := range r.Members {
+			if mb, ok := s.indexed[m.FeatureID()]; ok {
+				b = extendBound(extendBound(b, mb.Min), mb.Max)
+				found = true
+			}
+		}
+
+		return b, found
+	}
+
+	return geo.Bound{}, false
+}
+
+func emptyBound() geo.Bound {
+	return geo.Bound{
+		Min: geo.Point{math.MaxFloat64, math.MaxFloat64},
+		Max: geo.Point{-math.MaxFloat64, -math.MaxFloat64},
+	}
+}
+
+func extendBound(b geo.Bound, p geo.Point) geo.Bound {
+	b.Min[0] = math.Min(b.Min[0], p[0])
+	b.Min[1] = math.Min(b.Min[1], p[1])
+	b.Max[0] = math.Max(b.Max[0], p[0])
+	b.Max[1] = math.Max(b.Max[1], p[1])
+	return b
+}
+
+// appendWayID inserts the id keeping the list sorted and unique.
+func appendWayID(ids []WayID, id WayID) []WayID {
+	i := 0
+	for i < len(ids) && ids[i] < id {
+		i++
+	}
+
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+func removeWayID(ids []WayID, id WayID) []WayID {
+	for i := range ids {
+		if ids[i] == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+
+	return ids
+}
+
+// appendRelationID inserts the id keeping the list sorted and unique.
+func appendRelationID(ids []RelationID, id RelationID) []RelationID {
+	i := 0
+	for i < len(ids) && ids[i] < id {
+		i++
+	}
+
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+func removeRelationID(ids []RelationID, id RelationID) []RelationID {
+	for i := range ids {
+		if ids[i] == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+
+	return ids
+}
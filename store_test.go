@@ -0,0 +1,192 @@
+package osm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/geo"
+)
+
+func testStoreOSM() *OSM {
+	return &OSM{
+		Nodes: Nodes{
+			{ID: 1, Version: 1, Lon: 0, Lat: 0},
+			{ID: 2, Version: 1, Lon: 1, Lat: 1},
+			{ID: 3, Version: 1, Lon: 5, Lat: 5},
+			{ID: 4, Version: 1, Lon: 6, Lat: 6},
+			{ID: 5, Version: 1, Lon: 10, Lat: 10},
+		},
+		Ways: Ways{
+			{ID: 10, Version: 1, Nodes: WayNodes{{ID: 1}, {ID: 2}, {ID: 3}}},
+			{ID: 11, Version: 1, Nodes: WayNodes{{ID: 3}, {ID: 4}}},
+			{ID: 12, Version: 1, Nodes: WayNodes{{ID: 4}, {ID: 5}}},
+		},
+		Relations: Relations{
+			{ID: 20, Version: 1, Members: Members{
+				{Type: TypeWay, Ref: 11},
+				{Type: TypeNode, Ref: 5},
+			}},
+			{ID: 21, Version: 1, Members: Members{
+				{Type: TypeRelation, Ref: 20},
+			}},
+			{ID: 22, Version: 1, Members: Members{
+				{Type: TypeNode, Ref: 1},
+			}},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := testStoreOSM().Store()
+	if s.Len() != 11 {
+		t.Errorf("incorrect length: %d", s.Len())
+	}
+
+	if n := s.Node(2); n == nil || n.Lat != 1 {
+		t.Errorf("incorrect node: %v", n)
+	}
+
+	if e := s.Element(WayID(10).FeatureID()); e == nil || e.FeatureID() != WayID(10).FeatureID() {
+		t.Errorf("incorrect element: %v", e)
+	}
+
+	if e := s.Element(WayID(100).FeatureID()); e != nil {
+		t.Errorf("should not find element: %v", e)
+	}
+
+	if ids := s.WaysForNode(3).IDs(); !reflect.DeepEqual(ids, []WayID{10, 11}) {
+		t.Errorf("incorrect ways for node: %v", ids)
+	}
+
+	if ids := s.RelationsForMember(RelationID(20).FeatureID()).IDs(); !reflect.DeepEqual(ids, []RelationID{21}) {
+		t.Errorf("incorrect relations for member: %v", ids)
+	}
+
+	b, ok := s.Bound(RelationID(21).FeatureID())
+	if !ok || !b.Equal(geo.Bound{Min: geo.Point{5, 5}, Max: geo.Point{10, 10}}) {
+		t.Errorf("incorrect relation bound: %v", b)
+	}
+}
+
+func TestStore_update(t *testing.T) {
+	s := testStoreOSM().Store()
+
+	// moving a node updates the way and relation bounds
+	s.Add(&Node{ID: 4, Version: 2, Lon: 20, Lat: 20})
+	b, _ := s.Bound(WayID(11).FeatureID())
+	if !b.Equal(geo.Bound{Min: geo.Point{5, 5}, Max: geo.Point{20, 20}}) {
+		t.Errorf("incorrect way bound: %v", b)
+	}
+
+	b, _ = s.Bound(RelationID(21).FeatureID())
+	if !b.Equal(geo.Bound{Min: geo.Point{5, 5}, Max: geo.Point{20, 20}}) {
+		t.Errorf("incorrect relation bound: %v", b)
+	}
+
+	// changing the way nodes updates the back references
+	s.Add(&Way{ID: 11, Version: 2, Nodes: WayNodes{{ID: 2}, {ID: 4}}})
+	if ids := s.WaysForNode(3).IDs(); !reflect.DeepEqual(ids, []WayID{10}) {
+		t.Errorf("incorrect ways for node: %v", ids)
+	}
+
+	if ids := s.WaysForNode(2).IDs(); !reflect.DeepEqual(ids, []WayID{10, 11}) {
+		t.Errorf("incorrect ways for node: %v", ids)
+	}
+
+	if !s.Remove(NodeID(4).FeatureID()) {
+		t.Errorf("should remove node")
+	}
+
+	if s.Remove(NodeID(4).FeatureID()) {
+		t.Errorf("should not remove node twice")
+	}
+
+	b, _ = s.Bound(WayID(11).FeatureID())
+	if !b.Equal(geo.Bound{Min: geo.Point{1, 1}, Max: geo.Point{1, 1}}) {
+		t.Errorf("incorrect way bound after remove: %v", b)
+	}
+
+	s.Remove(NodeID(2).FeatureID())
+	if _, ok := s.Bound(WayID(11).FeatureID()); ok {
+		t.Errorf("way without nodes should not have a bound")
+	}
+}
+
+func TestStore_Elements(t *testing.T) {
+	o := testStoreOSM()
+	s := o.Store()
+	s.Remove(NodeID(2).FeatureID())
+
+	expected := o.ElementIDs()
+	expected = append(expected[:1], expected[2:]...)
+	if ids := s.Elements().ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	s := testStoreOSM().Store()
+
+	es := s.Search(&Bounds{MinLon: 2, MaxLon: 4, MinLat: 2, MaxLat: 4})
+	expected := ElementIDs{WayID(10).ElementID(1)}
+	if ids := es.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+
+	es = s.Search(&Bounds{MinLon: 9, MaxLon: 11, MinLat: 9, MaxLat: 11})
+	expected = ElementIDs{
+		NodeID(5).ElementID(1),
+		WayID(12).ElementID(1),
+		RelationID(20).ElementID(1),
+		RelationID(21).ElementID(1),
+	}
+	if ids := es.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+}
+
+func TestStore_Map(t *testing.T) {
+	s := testStoreOSM().Store()
+
+	o := s.Map(&Bounds{MinLon: 4, MaxLon: 5.5, MinLat: 4, MaxLat: 5.5})
+	expected := ElementIDs{
+		NodeID(1).ElementID(1),
+		NodeID(2).ElementID(1),
+		NodeID(3).ElementID(1),
+		NodeID(4).ElementID(1),
+		WayID(10).ElementID(1),
+		WayID(11).ElementID(1),
+		RelationID(20).ElementID(1),
+		RelationID(21).ElementID(1),
+		RelationID(22).ElementID(1),
+	}
+	if ids := o.ElementIDs(); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("incorrect elements: %v", ids)
+	}
+
+	if o.Bounds == nil {
+		t.Errorf("should set the bounds")
+	}
+}
+
+func TestStore_HistoryDatasource(t *testing.T) {
+	ctx := context.Background()
+	s := testStoreOSM().Store()
+
+	if ns, err := s.NodeHistory(ctx, 1); err != nil || len(ns) != 1 {
+		t.Errorf("incorrect node history: %v %v", ns, err)
+	}
+
+	if ws, err := s.WayHistory(ctx, 10); err != nil || len(ws) != 1 {
+		t.Errorf("incorrect way history: %v %v", ws, err)
+	}
+
+	if rs, err := s.RelationHistory(ctx, 20); err != nil || len(rs) != 1 {
+		t.Errorf("incorrect relation history: %v %v", rs, err)
+	}
+
+	if _, err := s.NodeHistory(ctx, 100); !s.NotFound(err) {
+		t.Errorf("should be not found error: %v", err)
+	}
+}
@@ -118,6 +118,26 @@ func (ts Tags) AnyInteresting() bool {
 	return false
 }
 
+// tagsEqual returns true if the tags contain the same key/values
+// independent of the order.
+func tagsEqual(a, b Tags) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	as := append(Tags(nil), a...)
+	bs := append(Tags(nil), b...)
+	as.SortByKeyValue()
+	bs.SortByKeyValue()
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
 type tagsSort Tags
 
 func (ts tagsSort) Len() int {
@@ -204,3 +204,42 @@ func TestTags_SortByKeyValue(t *testing.T) {
 		t.Errorf("incorrect sort got %v", v)
 	}
 }
+
+func TestTagsEqual(t *testing.T) {
+	cases := []struct {
+		name  string
+		a, b  Tags
+		equal bool
+	}{
+		{
+			name:  "empty",
+			equal: true,
+		},
+		{
+			name:  "different order",
+			a:     Tags{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+			b:     Tags{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}},
+			equal: true,
+		},
+		{
+			name:  "different value",
+			a:     Tags{{Key: "a", Value: "1"}},
+			b:     Tags{{Key: "a", Value: "2"}},
+			equal: false,
+		},
+		{
+			name:  "duplicate keys",
+			a:     Tags{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+			b:     Tags{{Key: "a", Value: "1"}, {Key: "a", Value: "1"}},
+			equal: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := tagsEqual(tc.a, tc.b); v != tc.equal {
+				t.Errorf("incorrect equal: %v != %v", v, tc.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package tagfilter
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pchchv/osm"
+)
+
+// The operators supported by a term.
+const (
+	opExists   operator = ""
+	opEqual    operator = "="
+	opNotEqual operator = "!="
+	opMatch    operator = "~"
+	opNotMatch operator = "!~"
+)
+
+var elementTypes = []osm.Type{osm.TypeNode, osm.TypeWay, osm.TypeRelation}
+
+// Filter is a compiled tag filter expression.
+// It is safe for concurrent use.
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parse compiles a tag filter expression.
+// The grammar is similar to osmium tags-filter and Overpass:
+//
+//	building                       has the key
+//	highway=primary,secondary      value is one of the list
+//	access!=private,no             value is not one of the list, or key is missing
+//	name~"^Main"                   value matches the regular expression
+//	name!~"^Main"                  value does not match, or key is missing
+//	w/highway                      only ways, also n, r and combinations like nw
+//	"addr:street"="Main Street"    keys and values can be double quoted
+//
+// Terms can be combined with 'and', 'or', 'not' and parentheses,
+// 'and' binds stronger than 'or'. For example:
+//
+//	w/highway=primary,secondary and not access=private
+//
+// A SyntaxError with the position of the problem is
+// returned if the expression is not valid.
+func Parse(expr string) (*Filter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{expr: expr, tokens: tokens}
+	if p.peek().typ == tokenEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, p.errorf(t, "unexpected %v", t)
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// MustParse is like Parse but panics if the expression is not valid.
+// Useful for filters defined as package variables.
+func MustParse(expr string) *Filter {
+	f, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// String returns the expression the filter was parsed from.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match returns true if the object is a node, way or relation matching the filter.
+// All other objects, e.g. changesets, never match.
+func (f *Filter) Match(o osm.Object) bool {
+	switch o := o.(type) {
+	case *osm.Node:
+		return f.root.eval(osm.TypeNode, o.Tags)
+	case *osm.Way:
+		return f.root.eval(osm.TypeWay, o.Tags)
+	case *osm.Relation:
+		return f.root.eval(osm.TypeRelation, o.Tags)
+	}
+
+	return false
+}
+
+// MatchTags returns true if an element of the given type with the tags matches the filter.
+func (f *Filter) MatchTags(t osm.Type, tags osm.Tags) bool {
+	return f.root.eval(t, tags)
+}
+
+// Func returns the filter as a predicate function,
+// e.g. for use with osm.NewFilterScanner.
+func (f *Filter) Func() func(osm.Object) bool {
+	return f.Match
+}
+
+// Node returns a node predicate, e.g. for osmpbf.Scanner.FilterNode.
+func (f *Filter) Node() func(*osm.Node) bool {
+	return func(n *osm.Node) bool {
+		return f.root.eval(osm.TypeNode, n.Tags)
+	}
+}
+
+// Way returns a way predicate, e.g. for osmpbf.Scanner.FilterWay.
+func (f *Filter) Way() func(*osm.Way) bool {
+	return func(w *osm.Way) bool {
+		return f.root.eval(osm.TypeWay, w.Tags)
+	}
+}
+
+// Relation returns a relation predicate, e.g. for osmpbf.Scanner.FilterRelation.
+func (f *Filter) Relation() func(*osm.Relation) bool {
+	return func(r *osm.Relation) bool {
+		return f.root.eval(osm.TypeRelation, r.Tags)
+	}
+}
+
+// Types returns the element types that can match the filter.
+// For example, if the filter only matches ways the nodes
+// and relations can be skipped, e.g. using osmpbf.Scanner.SkipNodes.
+func (f *Filter) Types() []osm.Type {
+	var result []osm.Type
+	for _, t := range elementTypes {
+		if f.root.canMatch(t) {
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
+// Keys returns the sorted set of tag keys used by the filter.
+func (f *Filter) Keys() []string {
+	set := make(map[string]struct{})
+	f.root.keys(set)
+
+	result := make([]string, 0, len(set))
+	for k := range set {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// KeyPrefilter returns the keys of the filter and true if an element
+// must have at least one of these keys to match.
+// In that case elements without any of the keys can be skipped
+// before fully decoding them.
+// It returns false for filters like 'not building' that match
+// elements without any of the keys, e.g. untagged nodes.
+func (f *Filter) KeyPrefilter() ([]string, bool) {
+	for _, t := range elementTypes {
+		if f.root.eval(t, nil) {
+			return f.Keys(), false
+		}
+	}
+
+	return f.Keys(), true
+}
+
+type operator string
+
+type node interface {
+	eval(t osm.Type, tags osm.Tags) bool
+	// canMatch returns false if the node will never match elements of the type.
+	canMatch(t osm.Type) bool
+	keys(set map[string]struct{})
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(t osm.Type, tags osm.Tags) bool {
+	return n.left.eval(t, tags) || n.right.eval(t, tags)
+}
+
+func (n *orNode) canMatch(t osm.Type) bool {
+	return n.left.canMatch(t) || n.right.canMatch(t)
+}
+
+func (n *orNode) keys(set map[string]struct{}) {
+	n.left.keys(set)
+	n.right.keys(set)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(t osm.Type, tags osm.Tags) bool {
+	return n.left.eval(t, tags) && n.right.eval(t, tags)
+}
+
+func (n *andNode) canMatch(t osm.Type) bool {
+	return n.left.canMatch(t) && n.right.canMatch(t)
+}
+
+func (n *andNode) keys(set map[string]struct{}) {
+	n.left.keys(set)
+	n.right.keys(set)
+}
+
+type notNode struct {
+	node node
+}
+
+func (n *notNode) eval(t osm.Type, tags osm.Tags) bool {
+	return !n.node.eval(t, tags)
+}
+
+func (n *notNode) canMatch(t osm.Type) bool {
+	// 'not w/highway' matches all nodes and relations,
+	// but also ways without highway tags
+	return true
+}
+
+func (n *notNode) keys(set map[string]struct{}) {
+	n.node.keys(set)
+}
+
+type termNode struct {
+	types  []osm.Type
+	key    string
+	op     operator
+	values []string
+	re     *regexp.Regexp
+}
+
+func (n *termNode) eval(t osm.Type, tags osm.Tags) bool {
+	if !n.canMatch(t) {
+		return false
+	}
+
+	tag := tags.FindTag(n.key)
+	switch n.op {
+	case opExists:
+		return tag != nil
+	case opEqual:
+		return tag != nil && n.contains(tag.Value)
+	case opNotEqual:
+		return tag == nil || !n.contains(tag.Value)
+	case opMatch:
+		return tag != nil && n.re.MatchString(tag.Value)
+	case opNotMatch:
+		return tag == nil || !n.re.MatchString(tag.Value)
+	}
+
+	return false
+}
+
+func (n *termNode) contains(v string) bool {
+	for _, value := range n.values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *termNode) canMatch(t osm.Type) bool {
+	if len(n.types) == 0 {
+		return true
+	}
+
+	for _, nt := range n.types {
+		if nt == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *termNode) keys(set map[string]struct{}) {
+	set[n.key] = struct{}{}
+}
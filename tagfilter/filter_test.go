@@ -0,0 +1,196 @@
+package tagfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestFilter_Match(t *testing.T) {
+	primary := &osm.Way{Tags: osm.Tags{{Key: "highway", Value: "primary"}}}
+	private := &osm.Way{Tags: osm.Tags{
+		{Key: "highway", Value: "secondary"},
+		{Key: "access", Value: "private"},
+	}}
+	mainStreet := &osm.Way{Tags: osm.Tags{
+		{Key: "highway", Value: "residential"},
+		{Key: "name", Value: "Main Street"},
+	}}
+	building := &osm.Relation{Tags: osm.Tags{{Key: "building", Value: "yes"}}}
+	node := &osm.Node{Tags: osm.Tags{{Key: "highway", Value: "primary"}}}
+
+	cases := []struct {
+		name    string
+		expr    string
+		objects osm.Objects
+		match   []bool
+	}{
+		{
+			name:    "exists",
+			expr:    "building",
+			objects: osm.Objects{building, primary},
+			match:   []bool{true, false},
+		},
+		{
+			name:    "value list",
+			expr:    "highway=primary,secondary",
+			objects: osm.Objects{primary, private, mainStreet},
+			match:   []bool{true, true, false},
+		},
+		{
+			name:    "and not",
+			expr:    "highway=primary,secondary and not access=private",
+			objects: osm.Objects{primary, private, mainStreet},
+			match:   []bool{true, false, false},
+		},
+		{
+			name:    "not equal includes missing",
+			expr:    "access!=private",
+			objects: osm.Objects{primary, private},
+			match:   []bool{true, false},
+		},
+		{
+			name:    "regexp",
+			expr:    `name~"^Main"`,
+			objects: osm.Objects{primary, mainStreet},
+			match:   []bool{false, true},
+		},
+		{
+			name:    "not regexp",
+			expr:    `name!~"^Main"`,
+			objects: osm.Objects{primary, mainStreet},
+			match:   []bool{true, false},
+		},
+		{
+			name:    "type prefix",
+			expr:    "w/highway",
+			objects: osm.Objects{primary, node},
+			match:   []bool{true, false},
+		},
+		{
+			name:    "multiple types",
+			expr:    "nr/highway or nr/building",
+			objects: osm.Objects{primary, node, building},
+			match:   []bool{false, true, true},
+		},
+		{
+			name:    "or binds weaker than and",
+			expr:    "building or highway and access",
+			objects: osm.Objects{building, primary, private},
+			match:   []bool{true, false, true},
+		},
+		{
+			name:    "parentheses",
+			expr:    "(building or highway) and not access",
+			objects: osm.Objects{building, primary, private},
+			match:   []bool{true, true, false},
+		},
+		{
+			name:    "quoted",
+			expr:    `"name"="Main Street"`,
+			objects: osm.Objects{mainStreet, primary},
+			match:   []bool{true, false},
+		},
+		{
+			name:    "non elements",
+			expr:    "not building",
+			objects: osm.Objects{&osm.Changeset{}, &osm.User{}},
+			match:   []bool{false, false},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			for i, o := range tc.objects {
+				if v := f.Match(o); v != tc.match[i] {
+					t.Errorf("incorrect match for %d: %v != %v", i, v, tc.match[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_typed(t *testing.T) {
+	f := MustParse("highway=primary")
+	tags := osm.Tags{{Key: "highway", Value: "primary"}}
+
+	if !f.Node()(&osm.Node{Tags: tags}) {
+		t.Errorf("node should match")
+	}
+
+	if !f.Way()(&osm.Way{Tags: tags}) {
+		t.Errorf("way should match")
+	}
+
+	if f.Relation()(&osm.Relation{}) {
+		t.Errorf("relation should not match")
+	}
+
+	if !f.Func()(&osm.Way{Tags: tags}) {
+		t.Errorf("func should match")
+	}
+}
+
+func TestFilter_Types(t *testing.T) {
+	cases := []struct {
+		expr  string
+		types []osm.Type
+	}{
+		{"highway", []osm.Type{osm.TypeNode, osm.TypeWay, osm.TypeRelation}},
+		{"w/highway", []osm.Type{osm.TypeWay}},
+		{"w/highway or r/route", []osm.Type{osm.TypeWay, osm.TypeRelation}},
+		{"w/highway and nw/name", []osm.Type{osm.TypeWay}},
+		{"not w/highway", []osm.Type{osm.TypeNode, osm.TypeWay, osm.TypeRelation}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			types := MustParse(tc.expr).Types()
+			if !reflect.DeepEqual(types, tc.types) {
+				t.Errorf("incorrect types: %v", types)
+			}
+		})
+	}
+}
+
+func TestFilter_KeyPrefilter(t *testing.T) {
+	cases := []struct {
+		expr string
+		keys []string
+		ok   bool
+	}{
+		{"highway=primary and not access=private", []string{"access", "highway"}, true},
+		{"building or w/highway", []string{"building", "highway"}, true},
+		{"not building", []string{"building"}, false},
+		{"access!=private", []string{"access"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			keys, ok := MustParse(tc.expr).KeyPrefilter()
+			if !reflect.DeepEqual(keys, tc.keys) {
+				t.Errorf("incorrect keys: %v", keys)
+			}
+
+			if ok != tc.ok {
+				t.Errorf("incorrect ok: %v", ok)
+			}
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("should panic on invalid expression")
+		}
+	}()
+
+	MustParse("highway=")
+}
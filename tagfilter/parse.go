@@ -0,0 +1,313 @@
+package tagfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pchchv/osm"
+)
+
+var _ error = &SyntaxError{}
+
+// SyntaxError is returned by Parse if the expression is not valid.
+type SyntaxError struct {
+	Expr string
+	// Pos is the byte offset in the expression where the error was found.
+	Pos int
+	Msg string
+}
+
+// Error returns a pretty string of the error.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("tagfilter: %s at position %d in %q", e.Msg, e.Pos, e.Expr)
+}
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenIdent
+	tokenString
+	tokenOp
+	tokenComma
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	switch t.typ {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return fmt.Sprintf("%q", t.val)
+	}
+
+	return fmt.Sprintf("'%s'", t.val)
+}
+
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenLParen, val: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenRParen, val: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{typ: tokenComma, val: ",", pos: i})
+			i++
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{typ: tokenOp, val: string(r), pos: i})
+			i++
+		case r == '!':
+			if i+1 < len(expr) && (expr[i+1] == '=' || expr[i+1] == '~') {
+				tokens = append(tokens, token{typ: tokenOp, val: expr[i : i+2], pos: i})
+				i += 2
+				continue
+			}
+
+			return nil, &SyntaxError{Expr: expr, Pos: i, Msg: "expected '=' or '~' after '!'"}
+		case r == '"':
+			s, n, err := lexString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{typ: tokenString, val: s, pos: i})
+			i += n
+		default:
+			start := i
+			for i < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[i:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()=!~,"`, r) {
+					break
+				}
+				i += size
+			}
+
+			tokens = append(tokens, token{typ: tokenIdent, val: expr[start:i], pos: start})
+		}
+	}
+
+	return append(tokens, token{typ: tokenEOF, pos: len(expr)}), nil
+}
+
+// lexString reads a double quoted string starting at the given offset.
+// Backslash escapes the next character.
+// Returns the unquoted value and the number of bytes read.
+func lexString(expr string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if i+1 == len(expr) {
+				return "", 0, &SyntaxError{Expr: expr, Pos: i, Msg: "unterminated escape"}
+			}
+
+			i++
+			sb.WriteByte(expr[i])
+		case '"':
+			return sb.String(), i - start + 1, nil
+		default:
+			sb.WriteByte(expr[i])
+		}
+	}
+
+	return "", 0, &SyntaxError{Expr: expr, Pos: start, Msg: "unterminated string"}
+}
+
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: p.expr, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isKeyword(t token, kw string) bool {
+	return t.typ == tokenIdent && t.val == kw
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	switch {
+	case p.isKeyword(t, "not"):
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{node: n}, nil
+	case t.typ == tokenLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.next(); t.typ != tokenRParen {
+			return nil, p.errorf(t, "expected ')' but found %v", t)
+		}
+
+		return n, nil
+	}
+
+	return p.parseTerm()
+}
+
+func (p *parser) parseTerm() (node, error) {
+	t := p.next()
+	if t.typ != tokenIdent && t.typ != tokenString {
+		return nil, p.errorf(t, "expected key but found %v", t)
+	}
+
+	if t.typ == tokenIdent && (t.val == "and" || t.val == "or") {
+		return nil, p.errorf(t, "expected key but found %v", t)
+	}
+
+	term := &termNode{key: t.val}
+	if t.typ == tokenIdent {
+		if i := strings.IndexByte(t.val, '/'); i > 0 {
+			if types, ok := parseTypes(t.val[:i]); ok {
+				term.types = types
+				term.key = t.val[i+1:]
+				if term.key == "" {
+					// allows for w/"quoted key"
+					k := p.next()
+					if k.typ != tokenString {
+						return nil, p.errorf(k, "expected key but found %v", k)
+					}
+					term.key = k.val
+				}
+			}
+		}
+	}
+
+	if p.peek().typ != tokenOp {
+		term.op = opExists
+		return term, nil
+	}
+
+	op := p.next()
+	term.op = operator(op.val)
+	for {
+		v := p.next()
+		if v.typ != tokenIdent && v.typ != tokenString {
+			return nil, p.errorf(v, "expected value but found %v", v)
+		}
+
+		term.values = append(term.values, v.val)
+		if p.peek().typ != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	if term.op == opMatch || term.op == opNotMatch {
+		if len(term.values) != 1 {
+			return nil, p.errorf(op, "regular expression operator %s takes one value", op.val)
+		}
+
+		re, err := regexp.Compile(term.values[0])
+		if err != nil {
+			return nil, p.errorf(op, "invalid regular expression: %v", err)
+		}
+		term.re = re
+	}
+
+	return term, nil
+}
+
+// parseTypes parses the element type prefix of a term, e.g. "nw".
+func parseTypes(s string) (types []osm.Type, ok bool) {
+	for _, r := range s {
+		var t osm.Type
+		switch r {
+		case 'n':
+			t = osm.TypeNode
+		case 'w':
+			t = osm.TypeWay
+		case 'r':
+			t = osm.TypeRelation
+		default:
+			return nil, false
+		}
+
+		for _, existing := range types {
+			if existing == t {
+				return nil, false
+			}
+		}
+		types = append(types, t)
+	}
+
+	return types, len(types) > 0
+}
@@ -0,0 +1,78 @@
+package tagfilter
+
+import "testing"
+
+func TestParse_errors(t *testing.T) {
+	cases := []struct {
+		expr string
+		pos  int
+	}{
+		{"", 0},
+		{"highway=", 8},
+		{"highway !primary", 8},
+		{`name="Main`, 5},
+		{"(highway", 8},
+		{"highway)", 7},
+		{"highway and", 11},
+		{"and highway", 0},
+		{"name~a,b", 4},
+		{"name~[", 4},
+		{`w/`, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			se, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected syntax error, got: %v", err)
+			}
+
+			if se.Pos != tc.pos {
+				t.Errorf("incorrect position: %d != %d: %v", se.Pos, tc.pos, se)
+			}
+		})
+	}
+}
+
+func TestParse_keys(t *testing.T) {
+	cases := []struct {
+		expr string
+		key  string
+	}{
+		{"addr:street", "addr:street"},
+		{"w/name:en", "name:en"},
+		{`w/"name en"`, "name en"},
+		{`"w/highway"`, "w/highway"},
+		{"x/y", "x/y"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			keys := f.Keys()
+			if len(keys) != 1 || keys[0] != tc.key {
+				t.Errorf("incorrect keys: %v", keys)
+			}
+		})
+	}
+}
+
+func TestLexString(t *testing.T) {
+	s, n, err := lexString(`"a \"b\" c" rest`, 0)
+	if err != nil {
+		t.Fatalf("lex error: %v", err)
+	}
+
+	if s != `a "b" c` {
+		t.Errorf("incorrect string: %v", s)
+	}
+
+	if n != 11 {
+		t.Errorf("incorrect length: %v", n)
+	}
+}
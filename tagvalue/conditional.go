@@ -0,0 +1,83 @@
+package tagvalue
+
+import (
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// Conditional is a value that applies under a condition,
+// e.g. "no @ (Mo-Fr 07:00-09:00)".
+type Conditional struct {
+	Value string
+	// Condition is without the parentheses, e.g. "Mo-Fr 07:00-09:00".
+	// Conditions combined with AND are not split.
+	Condition string
+}
+
+// Conditionals returns the conditional values of the key,
+// i.e. of the <key>:conditional tag.
+func Conditionals(tags osm.Tags, key string) ([]Conditional, error) {
+	key += ":conditional"
+	v, err := find(tags, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ParseConditional(v)
+	return c, withKey(err, key)
+}
+
+// ParseConditional parses a conditional value like
+// "no @ (Mo-Fr 07:00-09:00; Sa 10:00-12:00); 30 @ wet".
+// The parts are separated by semicolons outside of parentheses.
+func ParseConditional(value string) ([]Conditional, error) {
+	var result []Conditional
+	var depth, start int
+	var parts []string
+	for i, c := range value {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, &ParseError{Value: value, Reason: "unbalanced parentheses"}
+			}
+		case ';':
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, &ParseError{Value: value, Reason: "unbalanced parentheses"}
+	}
+	parts = append(parts, value[start:])
+
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		v, condition, ok := strings.Cut(part, "@")
+		v, condition = strings.TrimSpace(v), strings.TrimSpace(condition)
+		if strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+			condition = strings.TrimSpace(condition[1 : len(condition)-1])
+		}
+
+		if !ok || v == "" || condition == "" {
+			return nil, &ParseError{Value: value, Reason: "expected <value> @ <condition>"}
+		}
+
+		result = append(result, Conditional{Value: v, Condition: condition})
+	}
+
+	if len(result) == 0 {
+		return nil, &ParseError{Value: value, Reason: "no conditions"}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package tagvalue
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestParseConditional(t *testing.T) {
+	v, err := ParseConditional("no @ (Mo-Fr 07:00-09:00; Sa 10:00-12:00); 30 @ wet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Conditional{
+		{Value: "no", Condition: "Mo-Fr 07:00-09:00; Sa 10:00-12:00"},
+		{Value: "30", Condition: "wet"},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("incorrect conditionals: %v", v)
+	}
+}
+
+func TestParseConditional_errors(t *testing.T) {
+	for _, value := range []string{"", "no", "no @", "@ wet", "no @ (wet", "no @ wet)"} {
+		t.Run(value, func(t *testing.T) {
+			var pe *ParseError
+			if _, err := ParseConditional(value); !errors.As(err, &pe) {
+				t.Errorf("expected parse error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	tags := osm.Tags{{Key: "maxspeed:conditional", Value: "80 @ (22:00-06:00)"}}
+	v, err := Conditionals(tags, "maxspeed")
+	if err != nil || len(v) != 1 || v[0].Value != "80" || v[0].Condition != "22:00-06:00" {
+		t.Errorf("incorrect conditionals: %v %v", v, err)
+	}
+
+	if _, err := Conditionals(tags, "access"); err != ErrNotFound {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package tagvalue
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// lengthUnits are the meters per unit.
+var lengthUnits = map[string]float64{
+	"m":   1,
+	"km":  1000,
+	"cm":  0.01,
+	"mm":  0.001,
+	"ft":  0.3048,
+	"in":  0.0254,
+	"mi":  1609.344,
+	"nmi": 1852,
+}
+
+// Length returns the value of the key in meters, see ParseLength.
+func Length(tags osm.Tags, key string) (float64, error) {
+	v, err := find(tags, key)
+	if err != nil {
+		return 0, err
+	}
+
+	l, err := ParseLength(v)
+	return l, withKey(err, key)
+}
+
+// Width returns the width in meters.
+func Width(tags osm.Tags) (float64, error) {
+	return Length(tags, "width")
+}
+
+// Height returns the height in meters.
+func Height(tags osm.Tags) (float64, error) {
+	return Length(tags, "height")
+}
+
+// ParseLength parses a length in meters. The value is in meters if no
+// unit is given, else one of m, km, cm, mm, ft, in, mi and nmi,
+// or in feet and inches, e.g. 12'6".
+func ParseLength(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if feet, rest, ok := strings.Cut(value, "'"); ok {
+		return parseFeetInches(value, feet, rest)
+	}
+
+	if inches, ok := strings.CutSuffix(value, `"`); ok {
+		return parseFeetInches(value, "0", inches+`"`)
+	}
+
+	number, unit := splitUnit(value)
+	factor, ok := lengthUnits[unit]
+	if unit == "" {
+		factor, ok = 1, true
+	}
+
+	if !ok {
+		return 0, &ParseError{Value: value, Reason: "unknown unit"}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, &ParseError{Value: value, Reason: "not a number"}
+	}
+
+	if v < 0 {
+		return 0, &ParseError{Value: value, Reason: "negative length"}
+	}
+
+	return v * factor, nil
+}
+
+func parseFeetInches(value, feet, inches string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(feet), 64)
+	if err != nil || f < 0 {
+		return 0, &ParseError{Value: value, Reason: "invalid feet"}
+	}
+
+	var i float64
+	if inches = strings.TrimSpace(inches); inches != "" {
+		number, ok := strings.CutSuffix(inches, `"`)
+		if ok {
+			i, err = strconv.ParseFloat(strings.TrimSpace(number), 64)
+		}
+
+		if !ok || err != nil || i < 0 {
+			return 0, &ParseError{Value: value, Reason: "invalid inches"}
+		}
+	}
+
+	return f*lengthUnits["ft"] + i*lengthUnits["in"], nil
+}
+
+// splitUnit splits a value like "3.5 m" or "20mph" into the number and unit.
+func splitUnit(value string) (string, string) {
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		return value, ""
+	}
+
+	return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i:])
+}
@@ -0,0 +1,62 @@
+package tagvalue
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestParseLength(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected float64
+	}{
+		{value: "3.5", expected: 3.5},
+		{value: "3.5 m", expected: 3.5},
+		{value: "3.5m", expected: 3.5},
+		{value: "2 km", expected: 2000},
+		{value: "350 cm", expected: 3.5},
+		{value: "10 ft", expected: 3.048},
+		{value: "1 mi", expected: 1609.344},
+		{value: `12'6"`, expected: 12*0.3048 + 6*0.0254},
+		{value: `12'`, expected: 12 * 0.3048},
+		{value: `6"`, expected: 6 * 0.0254},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			v, err := ParseLength(tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if math.Abs(v-tc.expected) > 1e-9 {
+				t.Errorf("incorrect length: %v != %v", v, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseLength_errors(t *testing.T) {
+	for _, value := range []string{"wide", "3 furlongs", "-2", "3,5", `a'`, `3'b`} {
+		t.Run(value, func(t *testing.T) {
+			var pe *ParseError
+			if _, err := ParseLength(value); !errors.As(err, &pe) {
+				t.Errorf("expected parse error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if v, err := Width(osm.Tags{{Key: "width", Value: "4 m"}}); err != nil || v != 4 {
+		t.Errorf("incorrect width: %v %v", v, err)
+	}
+
+	var pe *ParseError
+	if _, err := Height(osm.Tags{{Key: "height", Value: "tall"}}); !errors.As(err, &pe) || pe.Key != "height" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package tagvalue
+
+import (
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// Names returns the name:<lang> variants by language, e.g. "de" or
+// "zh-Hans". Keys like name:etymology:wikidata are not included.
+func Names(tags osm.Tags) map[string]string {
+	result := make(map[string]string)
+	for _, t := range tags {
+		lang, ok := strings.CutPrefix(t.Key, "name:")
+		if !ok || lang == "" || strings.Contains(lang, ":") {
+			continue
+		}
+
+		result[lang] = t.Value
+	}
+
+	return result
+}
+
+// Name returns the name in the first of the languages found,
+// else the name tag. Empty if there is no name.
+func Name(tags osm.Tags, langs ...string) string {
+	for _, lang := range langs {
+		if v := tags.Find("name:" + lang); v != "" {
+			return v
+		}
+	}
+
+	return tags.Find("name")
+}
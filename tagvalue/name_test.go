@@ -0,0 +1,30 @@
+package tagvalue
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestNames(t *testing.T) {
+	tags := osm.Tags{
+		{Key: "name", Value: "München"},
+		{Key: "name:en", Value: "Munich"},
+		{Key: "name:zh-Hans", Value: "慕尼黑"},
+		{Key: "name:etymology:wikidata", Value: "Q1"},
+	}
+
+	expected := map[string]string{"en": "Munich", "zh-Hans": "慕尼黑"}
+	if names := Names(tags); !reflect.DeepEqual(names, expected) {
+		t.Errorf("incorrect names: %v", names)
+	}
+
+	if v := Name(tags, "fr", "en"); v != "Munich" {
+		t.Errorf("incorrect name: %v", v)
+	}
+
+	if v := Name(tags, "fr"); v != "München" {
+		t.Errorf("incorrect name: %v", v)
+	}
+}
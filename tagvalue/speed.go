@@ -0,0 +1,155 @@
+package tagvalue
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+const (
+	mphToKmh   = 1.609344
+	knotsToKmh = 1.852
+	// walkSpeed is the speed of maxspeed=walk in km/h.
+	walkSpeed = 5
+)
+
+// speedUnits are the km/h per unit.
+var speedUnits = map[string]float64{
+	"km/h":  1,
+	"kmh":   1,
+	"kph":   1,
+	"mph":   mphToKmh,
+	"knots": knotsToKmh,
+}
+
+// DefaultSpeedTable are the implicit speeds used by
+// ParseSpeed, Speed and MaxSpeed. Entries can be
+// added or replaced, or a custom table can be used.
+var DefaultSpeedTable = SpeedTable{
+	"AT:urban":         50,
+	"AT:rural":         100,
+	"AT:trunk":         100,
+	"AT:motorway":      130,
+	"CH:urban":         50,
+	"CH:rural":         80,
+	"CH:trunk":         100,
+	"CH:motorway":      120,
+	"DE:living_street": walkSpeed,
+	"DE:bicycle_road":  30,
+	"DE:urban":         50,
+	"DE:rural":         100,
+	"DE:motorway":      math.Inf(1),
+	"FR:urban":         50,
+	"FR:rural":         80,
+	"FR:motorway":      130,
+	"GB:nsl_single":    60 * mphToKmh,
+	"GB:nsl_dual":      70 * mphToKmh,
+	"GB:motorway":      70 * mphToKmh,
+	"IT:urban":         50,
+	"IT:rural":         90,
+	"IT:motorway":      130,
+	"NL:urban":         50,
+	"NL:rural":         80,
+	"NL:motorway":      100,
+	"RU:living_street": 20,
+	"RU:urban":         60,
+	"RU:rural":         90,
+	"RU:motorway":      110,
+}
+
+// SpeedTable maps implicit speed values, e.g. DE:urban, to the speed in km/h.
+type SpeedTable map[string]float64
+
+// ParseSpeed parses a speed using the DefaultSpeedTable, see SpeedTable.ParseSpeed.
+func ParseSpeed(value string) (float64, error) {
+	return DefaultSpeedTable.ParseSpeed(value)
+}
+
+// Speed returns the value of the key in km/h using the DefaultSpeedTable.
+func Speed(tags osm.Tags, key string) (float64, error) {
+	return DefaultSpeedTable.Speed(tags, key)
+}
+
+// MaxSpeed returns the maxspeed in km/h using the DefaultSpeedTable.
+func MaxSpeed(tags osm.Tags) (float64, error) {
+	return DefaultSpeedTable.MaxSpeed(tags)
+}
+
+// ParseSpeed parses a speed in km/h. The value is in km/h if no unit is
+// given, else one of km/h, mph and knots. Implicit speeds like DE:urban
+// are looked up in the table and zones like DE:zone30 or DE:zone:30 are
+// the given speed. Returns +Inf for none and walking speed for walk.
+func (t SpeedTable) ParseSpeed(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "none":
+		return math.Inf(1), nil
+	case "walk":
+		return walkSpeed, nil
+	case "signals", "variable":
+		return 0, &ParseError{Value: value, Reason: "variable speed"}
+	}
+
+	if country, zone, ok := strings.Cut(value, ":"); ok {
+		if v, ok := t[value]; ok {
+			return v, nil
+		}
+
+		zone = strings.TrimPrefix(strings.TrimPrefix(zone, "zone"), ":")
+		if v, err := strconv.ParseFloat(zone, 64); len(country) == 2 && err == nil && v > 0 {
+			return v, nil
+		}
+
+		return 0, &ParseError{Value: value, Reason: "unknown implicit speed"}
+	}
+
+	number, unit := splitUnit(value)
+	factor, ok := speedUnits[unit]
+	if unit == "" {
+		factor, ok = 1, true
+	}
+
+	if !ok {
+		return 0, &ParseError{Value: value, Reason: "unknown unit"}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, &ParseError{Value: value, Reason: "not a number"}
+	}
+
+	if v <= 0 {
+		return 0, &ParseError{Value: value, Reason: "must be positive"}
+	}
+
+	return v * factor, nil
+}
+
+// Speed returns the value of the key in km/h, see ParseSpeed.
+func (t SpeedTable) Speed(tags osm.Tags, key string) (float64, error) {
+	v, err := find(tags, key)
+	if err != nil {
+		return 0, err
+	}
+
+	s, err := t.ParseSpeed(v)
+	return s, withKey(err, key)
+}
+
+// MaxSpeed returns the maxspeed in km/h. If maxspeed is not set the
+// implicit speed of maxspeed:type or source:maxspeed is used.
+func (t SpeedTable) MaxSpeed(tags osm.Tags) (float64, error) {
+	if tags.Find("maxspeed") != "" {
+		return t.Speed(tags, "maxspeed")
+	}
+
+	for _, key := range []string{"maxspeed:type", "source:maxspeed"} {
+		if v := tags.Find(key); strings.Contains(v, ":") {
+			return t.Speed(tags, key)
+		}
+	}
+
+	return 0, ErrNotFound
+}
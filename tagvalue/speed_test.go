@@ -0,0 +1,82 @@
+package tagvalue
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestParseSpeed(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected float64
+	}{
+		{value: "50", expected: 50},
+		{value: "50 km/h", expected: 50},
+		{value: "50 mph", expected: 50 * mphToKmh},
+		{value: "20mph", expected: 20 * mphToKmh},
+		{value: "10 knots", expected: 10 * knotsToKmh},
+		{value: "walk", expected: walkSpeed},
+		{value: "none", expected: math.Inf(1)},
+		{value: "DE:urban", expected: 50},
+		{value: "GB:nsl_single", expected: 60 * mphToKmh},
+		{value: "DE:zone30", expected: 30},
+		{value: "DE:zone:20", expected: 20},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			v, err := ParseSpeed(tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if v != tc.expected {
+				t.Errorf("incorrect speed: %v != %v", v, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseSpeed_errors(t *testing.T) {
+	for _, value := range []string{"fast", "50 m/s", "-5", "0", "signals", "XX:urban", "DE:zone"} {
+		t.Run(value, func(t *testing.T) {
+			var pe *ParseError
+			if _, err := ParseSpeed(value); !errors.As(err, &pe) {
+				t.Errorf("expected parse error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSpeedTable(t *testing.T) {
+	table := SpeedTable{"XX:urban": 40}
+	if v, err := table.ParseSpeed("XX:urban"); err != nil || v != 40 {
+		t.Errorf("incorrect speed: %v %v", v, err)
+	}
+
+	if _, err := table.ParseSpeed("DE:urban"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestMaxSpeed(t *testing.T) {
+	if v, err := MaxSpeed(osm.Tags{{Key: "maxspeed", Value: "30"}, {Key: "maxspeed:type", Value: "DE:urban"}}); err != nil || v != 30 {
+		t.Errorf("incorrect speed: %v %v", v, err)
+	}
+
+	if v, err := MaxSpeed(osm.Tags{{Key: "source:maxspeed", Value: "FR:rural"}}); err != nil || v != 80 {
+		t.Errorf("incorrect speed: %v %v", v, err)
+	}
+
+	if _, err := MaxSpeed(osm.Tags{{Key: "source:maxspeed", Value: "sign"}}); err != ErrNotFound {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	var pe *ParseError
+	if _, err := MaxSpeed(osm.Tags{{Key: "maxspeed", Value: "fast"}}); !errors.As(err, &pe) || pe.Key != "maxspeed" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
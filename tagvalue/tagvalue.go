@@ -0,0 +1,206 @@
+package tagvalue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/osm"
+)
+
+// The directions of the oneway tag.
+const (
+	// Both is for ways without oneway or oneway=no.
+	Both Direction = "both"
+	// Forward is for oneway=yes, the direction of the way.
+	Forward Direction = "forward"
+	// Backward is for oneway=-1, against the direction of the way.
+	Backward Direction = "backward"
+	// Reversible ways change direction, e.g. by time of day.
+	Reversible Direction = "reversible"
+	// Alternating ways are used in both directions in turn, e.g. at road works.
+	Alternating Direction = "alternating"
+)
+
+var (
+	// ErrNotFound is returned if the tag is not set.
+	ErrNotFound = errors.New("tagvalue: tag not found")
+
+	_ error = &ParseError{}
+)
+
+// ParseError is returned if a tag value can not be parsed.
+// Key is empty when parsing a value directly.
+type ParseError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+// Error returns a pretty string of the error.
+func (e *ParseError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("tagvalue: invalid value %q: %s", e.Value, e.Reason)
+	}
+
+	return fmt.Sprintf("tagvalue: invalid %s value %q: %s", e.Key, e.Value, e.Reason)
+}
+
+// Direction is a strong type for the value of the oneway tag.
+type Direction string
+
+// find returns the value of the key or ErrNotFound.
+func find(tags osm.Tags, key string) (string, error) {
+	v := strings.TrimSpace(tags.Find(key))
+	if v == "" {
+		return "", ErrNotFound
+	}
+
+	return v, nil
+}
+
+// withKey sets the key of parse errors.
+func withKey(err error, key string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.Key = key
+	}
+
+	return err
+}
+
+// Int returns the value of the key as an integer.
+func Int(tags osm.Tags, key string) (int, error) {
+	v, err := find(tags, key)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &ParseError{Key: key, Value: v, Reason: "not an integer"}
+	}
+
+	return i, nil
+}
+
+// Float returns the value of the key as a float.
+func Float(tags osm.Tags, key string) (float64, error) {
+	v, err := find(tags, key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, &ParseError{Key: key, Value: v, Reason: "not a number"}
+	}
+
+	return f, nil
+}
+
+// Bool returns the value of the key as a boolean,
+// yes, true and 1 are true, no, false and 0 are false.
+func Bool(tags osm.Tags, key string) (bool, error) {
+	v, err := find(tags, key)
+	if err != nil {
+		return false, err
+	}
+
+	switch v {
+	case "yes", "true", "1":
+		return true, nil
+	case "no", "false", "0":
+		return false, nil
+	}
+
+	return false, &ParseError{Key: key, Value: v, Reason: "not a boolean"}
+}
+
+// List returns the semicolon separated values of the key,
+// nil if the tag is not set. A double semicolon is an
+// escaped semicolon that is part of the value.
+func List(tags osm.Tags, key string) []string {
+	return ParseList(tags.Find(key))
+}
+
+// ParseList splits a semicolon separated value, see List.
+func ParseList(value string) []string {
+	var result []string
+	var current strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != ';' {
+			current.WriteByte(value[i])
+			continue
+		}
+
+		if i+1 < len(value) && value[i+1] == ';' {
+			current.WriteByte(';')
+			i++
+			continue
+		}
+
+		if v := strings.TrimSpace(current.String()); v != "" {
+			result = append(result, v)
+		}
+		current.Reset()
+	}
+
+	if v := strings.TrimSpace(current.String()); v != "" {
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// Lanes returns the number of lanes, it must be positive.
+func Lanes(tags osm.Tags) (int, error) {
+	lanes, err := Int(tags, "lanes")
+	if err != nil {
+		return 0, err
+	}
+
+	if lanes <= 0 {
+		return 0, &ParseError{Key: "lanes", Value: tags.Find("lanes"), Reason: "must be positive"}
+	}
+
+	return lanes, nil
+}
+
+// Layer returns the layer, 0 if the tag is not set.
+// Valid layers are between -5 and 5.
+func Layer(tags osm.Tags) (int, error) {
+	layer, err := Int(tags, "layer")
+	if err == ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if layer < -5 || layer > 5 {
+		return 0, &ParseError{Key: "layer", Value: tags.Find("layer"), Reason: "must be between -5 and 5"}
+	}
+
+	return layer, nil
+}
+
+// Oneway returns the direction of the oneway tag, Both if it is not set.
+// Implied oneways, e.g. for roundabouts, are not considered.
+func Oneway(tags osm.Tags) (Direction, error) {
+	v := strings.TrimSpace(tags.Find("oneway"))
+	switch v {
+	case "", "no", "false", "0":
+		return Both, nil
+	case "yes", "true", "1":
+		return Forward, nil
+	case "-1", "reverse":
+		return Backward, nil
+	case "reversible":
+		return Reversible, nil
+	case "alternating":
+		return Alternating, nil
+	}
+
+	return "", &ParseError{Key: "oneway", Value: v, Reason: "unknown direction"}
+}
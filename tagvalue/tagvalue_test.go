@@ -0,0 +1,111 @@
+package tagvalue
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pchchv/osm"
+)
+
+func TestInt(t *testing.T) {
+	tags := osm.Tags{{Key: "lanes", Value: "2"}, {Key: "bad", Value: "2.5"}}
+	if v, err := Int(tags, "lanes"); err != nil || v != 2 {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if _, err := Int(tags, "missing"); err != ErrNotFound {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	var pe *ParseError
+	if _, err := Int(tags, "bad"); !errors.As(err, &pe) || pe.Key != "bad" || pe.Value != "2.5" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestBool(t *testing.T) {
+	tags := osm.Tags{{Key: "a", Value: "yes"}, {Key: "b", Value: "0"}, {Key: "c", Value: "maybe"}}
+	if v, err := Bool(tags, "a"); err != nil || !v {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if v, err := Bool(tags, "b"); err != nil || v {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if _, err := Bool(tags, "c"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "", expected: nil},
+		{value: "a", expected: []string{"a"}},
+		{value: "a; b ;c", expected: []string{"a", "b", "c"}},
+		{value: "a;;b;c;", expected: []string{"a;b", "c"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			if v := ParseList(tc.value); !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("incorrect list: %q", v)
+			}
+		})
+	}
+}
+
+func TestLanes(t *testing.T) {
+	if v, err := Lanes(osm.Tags{{Key: "lanes", Value: "3"}}); err != nil || v != 3 {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if _, err := Lanes(osm.Tags{{Key: "lanes", Value: "0"}}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestLayer(t *testing.T) {
+	if v, err := Layer(nil); err != nil || v != 0 {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if v, err := Layer(osm.Tags{{Key: "layer", Value: "-1"}}); err != nil || v != -1 {
+		t.Errorf("incorrect value: %v %v", v, err)
+	}
+
+	if _, err := Layer(osm.Tags{{Key: "layer", Value: "7"}}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestOneway(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected Direction
+	}{
+		{value: "", expected: Both},
+		{value: "no", expected: Both},
+		{value: "yes", expected: Forward},
+		{value: "-1", expected: Backward},
+		{value: "reversible", expected: Reversible},
+		{value: "alternating", expected: Alternating},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			d, err := Oneway(osm.Tags{{Key: "oneway", Value: tc.value}})
+			if err != nil || d != tc.expected {
+				t.Errorf("incorrect direction: %v %v", d, err)
+			}
+		})
+	}
+
+	if _, err := Oneway(osm.Tags{{Key: "oneway", Value: "sometimes"}}); err == nil {
+		t.Errorf("expected error")
+	}
+}
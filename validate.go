@@ -0,0 +1,353 @@
+package osm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of the OSM API 0.6 as returned by the capabilities call.
+const (
+	MinWayNodes          = 2
+	MaxWayNodes          = 2000
+	MaxRelationMembers   = 32000
+	MaxTagLength         = 255
+	MaxChangesetElements = 10000
+)
+
+var (
+	_ error = &ValidationError{}
+	_ error = ValidationErrors{}
+)
+
+// existingVersionReason is the reason of a missing version of an existing object.
+const existingVersionReason = "existing object must have a positive version"
+
+// ValidationError is a single problem found when validating an object.
+// The object is identified by type, ref and version since new objects
+// have negative placeholder ids that can not be represented by an ObjectID.
+type ValidationError struct {
+	Type    Type
+	Ref     int64
+	Version int
+	// Field is the name of the invalid field,
+	// e.g. "Lat", "Tags", "Nodes" or "Members".
+	Field string
+	// Index is the index in Tags, Nodes or Members
+	// of the invalid item, -1 if not applicable.
+	Index  int
+	Reason string
+}
+
+// Error returns a pretty string of the error.
+func (e *ValidationError) Error() string {
+	id := fmt.Sprintf("%s/%d:%d", e.Type, e.Ref, e.Version)
+	if e.Version == 0 {
+		id = fmt.Sprintf("%s/%d:-", e.Type, e.Ref)
+	}
+
+	if e.Index >= 0 {
+		return fmt.Sprintf("osm: %s: %s[%d]: %s", id, e.Field, e.Index, e.Reason)
+	}
+
+	return fmt.Sprintf("osm: %s: %s: %s", id, e.Field, e.Reason)
+}
+
+// ValidationErrors is returned by the Validate functions
+// with all the problems found.
+type ValidationErrors []*ValidationError
+
+// Error returns a pretty string of the errors.
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e))
+	for _, v := range e {
+		msgs = append(msgs, strings.TrimPrefix(v.Error(), "osm: "))
+	}
+
+	return fmt.Sprintf("osm: %d validation errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// validationID identifies the validated object.
+type validationID struct {
+	Type    Type
+	Ref     int64
+	Version int
+}
+
+func (e *ValidationErrors) add(id validationID, field string, index int, format string, args ...interface{}) {
+	*e = append(*e, &ValidationError{
+		Type:    id.Type,
+		Ref:     id.Ref,
+		Version: id.Version,
+		Field:   field,
+		Index:   index,
+		Reason:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate checks the node against the constraints of the OSM API 0.6.
+// Returns nil or ValidationErrors with all the problems found.
+func (n *Node) Validate() error {
+	var errs ValidationErrors
+	id := validationID{TypeNode, int64(n.ID), n.Version}
+	validateVersion(&errs, id)
+	if n.Lat < -90 || n.Lat > 90 {
+		errs.add(id, "Lat", -1, "latitude %v out of range [-90, 90]", n.Lat)
+	}
+
+	if n.Lon < -180 || n.Lon > 180 {
+		errs.add(id, "Lon", -1, "longitude %v out of range [-180, 180]", n.Lon)
+	}
+
+	validateTags(&errs, id, n.Tags)
+	return errs.err()
+}
+
+// Validate checks the way against the constraints of the OSM API 0.6,
+// e.g. it must have between 2 and 2000 nodes.
+// Returns nil or ValidationErrors with all the problems found.
+func (w *Way) Validate() error {
+	var errs ValidationErrors
+	id := validationID{TypeWay, int64(w.ID), w.Version}
+	validateVersion(&errs, id)
+	if l := len(w.Nodes); l < MinWayNodes || l > MaxWayNodes {
+		errs.add(id, "Nodes", -1, "%d nodes, must be between %d and %d", l, MinWayNodes, MaxWayNodes)
+	}
+
+	for i, wn := range w.Nodes {
+		if wn.ID == 0 {
+			errs.add(id, "Nodes", i, "missing node id")
+		}
+	}
+
+	validateTags(&errs, id, w.Tags)
+	return errs.err()
+}
+
+// Validate checks the relation against the constraints of the OSM API 0.6,
+// e.g. member types and the maximum number of members.
+// Returns nil or ValidationErrors with all the problems found.
+func (r *Relation) Validate() error {
+	var errs ValidationErrors
+	id := validationID{TypeRelation, int64(r.ID), r.Version}
+	validateVersion(&errs, id)
+	if l := len(r.Members); l > MaxRelationMembers {
+		errs.add(id, "Members", -1, "%d members, must be at most %d", l, MaxRelationMembers)
+	}
+
+	for i, m := range r.Members {
+		switch m.Type {
+		case TypeNode, TypeWay, TypeRelation:
+		default:
+			errs.add(id, "Members", i, "invalid member type %q", m.Type)
+			continue
+		}
+
+		if m.Ref == 0 {
+			errs.add(id, "Members", i, "missing member ref")
+		}
+
+		if l := utf8.RuneCountInString(m.Role); l > MaxTagLength {
+			errs.add(id, "Members", i, "role has %d characters, must be at most %d", l, MaxTagLength)
+		}
+	}
+
+	validateTags(&errs, id, r.Tags)
+	return errs.err()
+}
+
+// Validate checks the changeset against the constraints of the OSM API 0.6.
+// Returns nil or ValidationErrors with all the problems found.
+func (c *Changeset) Validate() error {
+	var errs ValidationErrors
+	id := validationID{Type: TypeChangeset, Ref: int64(c.ID)}
+	if c.ChangesCount > MaxChangesetElements {
+		errs.add(id, "ChangesCount", -1, "%d changes, must be at most %d", c.ChangesCount, MaxChangesetElements)
+	}
+
+	if c.MinLat > c.MaxLat || c.MinLat < -90 || c.MaxLat > 90 {
+		errs.add(id, "MinLat", -1, "invalid latitude range [%v, %v]", c.MinLat, c.MaxLat)
+	}
+
+	if c.MinLon > c.MaxLon || c.MinLon < -180 || c.MaxLon > 180 {
+		errs.add(id, "MinLon", -1, "invalid longitude range [%v, %v]", c.MinLon, c.MaxLon)
+	}
+
+	validateTags(&errs, id, c.Tags)
+	return errs.err()
+}
+
+// Validate checks all the elements of the change and the structure of
+// the change itself against the constraints of the OSM API 0.6 for uploads.
+// That is the total number of elements is at most MaxChangesetElements,
+// modified and deleted elements are existing objects with a positive version,
+// only the id and version of deleted elements are checked,
+// references to new objects, i.e. with negative placeholder ids,
+// are created in the change and deleted features are not
+// referenced by created or modified elements.
+// Returns nil or ValidationErrors with all the problems found.
+func (c *Change) Validate() error {
+	var errs ValidationErrors
+	actions := c.actions()
+	created := make(map[validationID]struct{})
+	deleted := make(map[validationID]struct{})
+	for _, a := range actions {
+		id := elementValidationID(a.element)
+		key := validationID{Type: id.Type, Ref: id.Ref}
+		if a.action == ActionDelete {
+			validateDelete(&errs, id)
+			deleted[key] = struct{}{}
+			continue
+		}
+
+		if err := validateElement(a.element); err != nil {
+			for _, e := range err.(ValidationErrors) {
+				// a create with a positive id is reported as a wrong id below
+				if a.action == ActionCreate && e.Field == "Version" && e.Reason == existingVersionReason {
+					continue
+				}
+
+				errs = append(errs, e)
+			}
+		}
+
+		switch a.action {
+		case ActionCreate:
+			if id.Ref > 0 {
+				errs.add(id, "ID", -1, "new object must have a negative id")
+			}
+
+			created[key] = struct{}{}
+		case ActionModify:
+			// existing objects without a version are reported by the element validation
+			if id.Ref < 0 {
+				errs.add(id, "ID", -1, "%s of a new object", a.action)
+			}
+		}
+	}
+
+	if len(actions) > MaxChangesetElements {
+		errs.add(validationID{}, "Change", -1, "%d elements, must be at most %d", len(actions), MaxChangesetElements)
+	}
+
+	for _, a := range actions {
+		if a.action == ActionDelete {
+			continue
+		}
+
+		id := elementValidationID(a.element)
+		check := func(field string, index int, ref validationID) {
+			if _, ok := deleted[ref]; ok {
+				errs.add(id, field, index, "references deleted %s/%d", ref.Type, ref.Ref)
+			}
+
+			if _, ok := created[ref]; !ok && ref.Ref < 0 {
+				errs.add(id, field, index, "references %s/%d which is not created", ref.Type, ref.Ref)
+			}
+		}
+
+		switch e := a.element.(type) {
+		case *Way:
+			for i, wn := range e.Nodes {
+				check("Nodes", i, validationID{Type: TypeNode, Ref: int64(wn.ID)})
+			}
+		case *Relation:
+			for i, m := range e.Members {
+				check("Members", i, validationID{Type: m.Type, Ref: m.Ref})
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+func elementValidationID(e Element) validationID {
+	switch e := e.(type) {
+	case *Node:
+		return validationID{TypeNode, int64(e.ID), e.Version}
+	case *Way:
+		return validationID{TypeWay, int64(e.ID), e.Version}
+	case *Relation:
+		return validationID{TypeRelation, int64(e.ID), e.Version}
+	}
+
+	return validationID{}
+}
+
+func validateElement(e Element) error {
+	switch e := e.(type) {
+	case *Node:
+		return e.Validate()
+	case *Way:
+		return e.Validate()
+	case *Relation:
+		return e.Validate()
+	}
+
+	return nil
+}
+
+// validateVersion checks that existing objects, i.e. with a positive id,
+// have a positive version. New objects have a negative placeholder id.
+func validateVersion(errs *ValidationErrors, id validationID) {
+	if id.Ref == 0 {
+		errs.add(id, "ID", -1, "missing id")
+	}
+
+	if id.Ref > 0 && id.Version <= 0 {
+		errs.add(id, "Version", -1, existingVersionReason)
+	}
+
+	if id.Version < 0 {
+		errs.add(id, "Version", -1, "negative version %d", id.Version)
+	}
+}
+
+// validateDelete checks a deleted element. Only the id and version
+// are checked since deletes do not need to include the way nodes,
+// members or tags of the element.
+func validateDelete(errs *ValidationErrors, id validationID) {
+	if id.Ref == 0 {
+		errs.add(id, "ID", -1, "missing id")
+	} else if id.Ref < 0 {
+		errs.add(id, "ID", -1, "%s of a new object", ActionDelete)
+	}
+
+	if id.Version <= 0 {
+		errs.add(id, "Version", -1, "deleted object must have a positive version")
+	}
+}
+
+func validateTags(errs *ValidationErrors, id validationID, tags Tags) {
+	seen := make(map[string]struct{}, len(tags))
+	for i, t := range tags {
+		if t.Key == "" {
+			errs.add(id, "Tags", i, "empty key")
+		}
+
+		if l := utf8.RuneCountInString(t.Key); l > MaxTagLength {
+			errs.add(id, "Tags", i, "key has %d characters, must be at most %d", l, MaxTagLength)
+		}
+
+		if l := utf8.RuneCountInString(t.Value); l > MaxTagLength {
+			errs.add(id, "Tags", i, "value of %q has %d characters, must be at most %d", t.Key, l, MaxTagLength)
+		}
+
+		if _, ok := seen[t.Key]; ok {
+			errs.add(id, "Tags", i, "duplicate key %q", t.Key)
+		}
+		seen[t.Key] = struct{}{}
+	}
+}
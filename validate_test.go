@@ -0,0 +1,288 @@
+package osm
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNode_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		node   *Node
+		fields []string
+	}{
+		{
+			name: "valid",
+			node: &Node{ID: 1, Version: 1, Lat: 10, Lon: 20, Tags: Tags{{Key: "a", Value: "b"}}},
+		},
+		{
+			name: "new node",
+			node: &Node{ID: -1, Lat: -90, Lon: 180},
+		},
+		{
+			name:   "missing version",
+			node:   &Node{ID: 1},
+			fields: []string{"Version"},
+		},
+		{
+			name:   "missing id",
+			node:   &Node{Version: 1},
+			fields: []string{"ID"},
+		},
+		{
+			name:   "out of range",
+			node:   &Node{ID: 1, Version: 1, Lat: 91, Lon: -181},
+			fields: []string{"Lat", "Lon"},
+		},
+		{
+			name: "bad tags",
+			node: &Node{ID: 1, Version: 1, Tags: Tags{
+				{Key: "a", Value: "b"},
+				{Key: "a", Value: "c"},
+				{Key: "", Value: "c"},
+				{Key: strings.Repeat("k", 256), Value: strings.Repeat("ü", 255)},
+				{Key: "v", Value: strings.Repeat("ü", 256)},
+			}},
+			fields: []string{"Tags", "Tags", "Tags", "Tags"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkValidationFields(t, tc.node.Validate(), tc.fields)
+		})
+	}
+}
+
+func TestWay_Validate(t *testing.T) {
+	nodes := make(WayNodes, MaxWayNodes+1)
+	for i := range nodes {
+		nodes[i].ID = NodeID(i + 1)
+	}
+
+	cases := []struct {
+		name   string
+		way    *Way
+		fields []string
+	}{
+		{
+			name: "valid",
+			way:  &Way{ID: 1, Version: 1, Nodes: nodes[:MaxWayNodes]},
+		},
+		{
+			name:   "too few nodes",
+			way:    &Way{ID: 1, Version: 1, Nodes: nodes[:1]},
+			fields: []string{"Nodes"},
+		},
+		{
+			name:   "too many nodes",
+			way:    &Way{ID: 1, Version: 1, Nodes: nodes},
+			fields: []string{"Nodes"},
+		},
+		{
+			name:   "missing node id",
+			way:    &Way{ID: 1, Version: 1, Nodes: WayNodes{{ID: 1}, {}}},
+			fields: []string{"Nodes"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkValidationFields(t, tc.way.Validate(), tc.fields)
+		})
+	}
+}
+
+func TestRelation_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		relation *Relation
+		fields   []string
+	}{
+		{
+			name: "valid",
+			relation: &Relation{ID: 1, Version: 1, Members: Members{
+				{Type: TypeNode, Ref: 1},
+				{Type: TypeWay, Ref: 1, Role: "outer"},
+				{Type: TypeRelation, Ref: 1},
+			}},
+		},
+		{
+			name:     "no members",
+			relation: &Relation{ID: 1, Version: 1},
+		},
+		{
+			name:     "too many members",
+			relation: &Relation{ID: 1, Version: 1, Members: make(Members, MaxRelationMembers+1)},
+			// the individual members are also invalid
+			fields: append([]string{"Members"}, make([]string, MaxRelationMembers+1)...),
+		},
+		{
+			name: "invalid members",
+			relation: &Relation{ID: 1, Version: 1, Members: Members{
+				{Type: TypeChangeset, Ref: 1},
+				{Type: TypeNode},
+				{Type: TypeWay, Ref: 1, Role: strings.Repeat("r", 256)},
+			}},
+			fields: []string{"Members", "Members", "Members"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.relation.Validate()
+			if len(tc.fields) > 0 && tc.fields[len(tc.fields)-1] == "" {
+				var errs ValidationErrors
+				if !errors.As(err, &errs) || len(errs) != len(tc.fields) {
+					t.Fatalf("incorrect errors: %v", err)
+				}
+				return
+			}
+
+			checkValidationFields(t, err, tc.fields)
+		})
+	}
+}
+
+func TestChangeset_Validate(t *testing.T) {
+	cs := &Changeset{ID: 1, MinLat: 1, MaxLat: 2, MinLon: 3, MaxLon: 4, ChangesCount: 10}
+	checkValidationFields(t, cs.Validate(), nil)
+
+	cs = &Changeset{
+		ID:           1,
+		MinLat:       2,
+		MaxLat:       1,
+		MinLon:       -181,
+		MaxLon:       4,
+		ChangesCount: MaxChangesetElements + 1,
+		Tags:         Tags{{Key: "comment", Value: strings.Repeat("c", 256)}},
+	}
+	checkValidationFields(t, cs.Validate(), []string{"ChangesCount", "MinLat", "MinLon", "Tags"})
+}
+
+func TestChange_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		c := &Change{
+			Create: &OSM{
+				Nodes: Nodes{{ID: -1, Lat: 1, Lon: 1}, {ID: -2, Lat: 2, Lon: 2}},
+				Ways:  Ways{{ID: -1, Nodes: WayNodes{{ID: -1}, {ID: -2}, {ID: 3}}}},
+			},
+			Modify: &OSM{
+				Relations: Relations{{ID: 1, Version: 2, Members: Members{
+					{Type: TypeWay, Ref: -1},
+				}}},
+			},
+			Delete: &OSM{
+				Nodes: Nodes{{ID: 4, Version: 1}},
+				// deletes do not need the way nodes or members
+				Ways:      Ways{{ID: 5, Version: 3}},
+				Relations: Relations{{ID: 6, Version: 2}},
+			},
+		}
+		checkValidationFields(t, c.Validate(), nil)
+	})
+
+	t.Run("delete from osmChange", func(t *testing.T) {
+		data := []byte(`<osmChange version="0.6">
+  <delete>
+    <node id="1" version="3" changeset="10"/>
+    <way id="2" version="4" changeset="10"/>
+    <relation id="3" version="2" changeset="10"/>
+  </delete>
+</osmChange>`)
+
+		c := &Change{}
+		if err := xml.Unmarshal(data, c); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		checkValidationFields(t, c.Validate(), nil)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		c := &Change{
+			Create: &OSM{
+				Ways: Ways{{ID: -1, Nodes: WayNodes{{ID: -1}, {ID: 4}}}},
+			},
+			Modify: &OSM{
+				Nodes: Nodes{{ID: 1, Lat: 100}},
+			},
+			Delete: &OSM{
+				Nodes: Nodes{{ID: 4, Version: 1}, {ID: -5}},
+			},
+		}
+
+		err := c.Validate()
+		checkValidationFields(t, err, []string{"Version", "Lat", "ID", "Version", "Nodes", "Nodes"})
+
+		var errs ValidationErrors
+		errors.As(err, &errs)
+		if e := errs[4]; e.Type != TypeWay || e.Ref != -1 || e.Index != 0 {
+			t.Errorf("incorrect error: %v", e)
+		}
+	})
+
+	t.Run("create with positive id", func(t *testing.T) {
+		c := &Change{
+			Create: &OSM{Nodes: Nodes{{ID: 1}, {ID: 2, Version: 1}}},
+		}
+
+		err := c.Validate()
+		checkValidationFields(t, err, []string{"ID", "ID"})
+
+		var errs ValidationErrors
+		errors.As(err, &errs)
+		if e := errs[0]; e.Ref != 1 || e.Reason != "new object must have a negative id" {
+			t.Errorf("incorrect error: %v", e)
+		}
+	})
+
+	t.Run("too many elements", func(t *testing.T) {
+		c := &Change{Create: &OSM{}}
+		for i := 0; i <= MaxChangesetElements; i++ {
+			c.Create.Nodes = append(c.Create.Nodes, &Node{ID: NodeID(-i - 1)})
+		}
+		checkValidationFields(t, c.Validate(), []string{"Change"})
+	})
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	errs := ValidationErrors{
+		{Type: TypeNode, Ref: 1, Version: 1, Field: "Lat", Index: -1, Reason: "bad"},
+	}
+	if v := errs.Error(); v != "osm: node/1:1: Lat: bad" {
+		t.Errorf("incorrect error: %v", v)
+	}
+
+	errs = append(errs, &ValidationError{Type: TypeWay, Ref: -2, Field: "Nodes", Index: 3, Reason: "worse"})
+	if v := errs.Error(); v != "osm: 2 validation errors: node/1:1: Lat: bad; way/-2:-: Nodes[3]: worse" {
+		t.Errorf("incorrect error: %v", v)
+	}
+}
+
+func checkValidationFields(t testing.TB, err error, fields []string) {
+	t.Helper()
+
+	if len(fields) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("incorrect error type: %v", err)
+	}
+
+	if len(errs) != len(fields) {
+		t.Fatalf("incorrect number of errors: %v", err)
+	}
+
+	for i, e := range errs {
+		if e.Field != fields[i] {
+			t.Errorf("incorrect field %d: %v != %v", i, e.Field, fields[i])
+		}
+	}
+}